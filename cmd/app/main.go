@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"github.com/GolangAssignment/internal/config"
 	"github.com/GolangAssignment/internal/models"
 	"github.com/GolangAssignment/internal/routes"
+	"github.com/GolangAssignment/internal/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -16,31 +18,104 @@ func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
 
-	// Database DSN
-	dsn := "host=" + cfg.DBHost +
-		" user=" + cfg.DBUser +
-		" password=" + cfg.DBPassword +
-		" dbname=" + cfg.DBName +
-		" port=" + cfg.DBPort +
-		" sslmode=disable"
-
 	// Connect to the database
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseDSN()), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
 	// Auto-migrate models
-	err = db.AutoMigrate(&models.User{}, &models.Profile{}, &models.Job{}, &models.Application{})
+	err = db.AutoMigrate(
+		&models.User{}, &models.Profile{}, &models.Job{}, &models.Application{},
+		&models.EducationEntry{}, &models.ExperienceEntry{}, &models.ProfileSkill{}, &models.ProfileFieldSource{},
+		&models.ApplicationTag{}, &models.ApplicationStageEvent{}, &models.ApplicationFilter{},
+		&models.MessageTemplate{}, &models.Message{},
+		&models.BulkOperation{}, &models.BulkOperationItem{},
+		&models.TalentPool{}, &models.TalentPoolMember{}, &models.UserTag{}, &models.JobInvitation{},
+		&models.Referral{}, &models.TrackedLink{},
+		&models.DuplicateCandidate{}, &models.CandidateMerge{},
+		&models.ResumeJob{}, &models.ResumeVersion{}, &models.DownloadAudit{},
+		&models.LLMUsage{}, &models.ParseCacheEntry{},
+		&models.JobSkill{}, &models.Embedding{}, &models.Skill{}, &models.SkillSynonym{},
+		&models.SavedSearch{}, &models.AlertPreference{}, &models.JobAlert{}, &models.UserPermission{},
+	)
 	if err != nil {
 		log.Fatalf("Failed to auto-migrate models: %v", err)
 	}
 
+	// Move flat profile fields written by earlier versions into structured records
+	if err := services.MigrateLegacyProfiles(db); err != nil {
+		log.Fatalf("Failed to migrate legacy profiles: %v", err)
+	}
+
+	// Skills taxonomy used to normalize parsed and entered skills
+	skills := services.NewSkillTaxonomy(db)
+	if err := skills.Seed(cfg.SkillsTaxonomyPath); err != nil {
+		log.Fatalf("Failed to load skills taxonomy: %v", err)
+	}
+	skills.Start(cfg.SkillsTaxonomyRefresh)
+
+	// Blind hiring: who may see the identity of candidates
+	identity := services.NewIdentityGuard(db)
+	if err := identity.Seed(cfg.RevealIdentityEmails); err != nil {
+		log.Fatalf("Failed to grant reveal identity permissions: %v", err)
+	}
+	if jobs, err := identity.UnguardedBlindJobs(); err != nil {
+		log.Fatalf("Failed to check reveal identity permissions: %v", err)
+	} else if jobs > 0 {
+		log.Printf("Warning: %d jobs use blind hiring but no admin holds the reveal_identity permission; set REVEAL_IDENTITY_EMAILS to grant it", jobs)
+	}
+
+	// Start background duplicate candidate detection
+	services.NewDuplicateDetector(db).Start(cfg.DuplicateScanInterval)
+
+	// Embeddings for similarity search, kept up to date in the background
+	embeddingProvider, err := services.NewEmbeddingProvider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure embedding provider: %v", err)
+	}
+	embeddings := services.NewEmbeddingService(db, embeddingProvider)
+	embeddings.Start(cfg.EmbeddingSyncInterval)
+
+	// Job alerts for saved searches and recommendations, with digests sent in the background
+	alerts := services.NewJobAlertService(db, skills, embeddings)
+	alerts.Start(cfg.JobAlertInterval)
+
+	// Bulk recruiter actions, run in the background and resumed after a restart
+	bulk := services.NewBulkService(db)
+	bulk.Start(context.Background())
+
+	// Storage for uploaded resumes
+	store, err := services.NewBlobStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure blob store: %v", err)
+	}
+
+	// Signed resume download links
+	signer, err := services.NewURLSigner(cfg.DownloadSigningKey, cfg.DownloadURLTTL)
+	if err != nil {
+		log.Fatalf("Failed to configure download links: %v", err)
+	}
+
+	// Metering and budgets for paid LLM calls
+	meter, err := services.NewUsageMeter(db, cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure LLM usage metering: %v", err)
+	}
+
+	// Start the resume processing workers
+	resumeParser, err := services.NewResumeParser(cfg, services.NewParseCache(db), meter)
+	if err != nil {
+		log.Fatalf("Failed to configure resume parser: %v", err)
+	}
+	pipeline := services.NewResumePipeline(db, store, resumeParser, skills, cfg.ResumeWorkers, cfg.ResumeMaxAttempts, cfg.ResumeRetryBackoff)
+	pipeline.Start(context.Background())
+
 	// Set up Gin router
 	router := gin.Default()
 
 	// Initialize routes
-	routes.SetupRoutes(router, db, cfg)
+	routes.SetupRoutes(router, db, cfg, store, pipeline, meter, embeddings, skills, alerts, bulk, identity, signer)
 
 	// Start the server
 	port := os.Getenv("PORT")
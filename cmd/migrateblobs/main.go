@@ -0,0 +1,156 @@
+// Command migrateblobs moves stored resume files into the configured blob
+// store and rewrites the database references to their content keys.
+//
+// Files written before blob storage live at local paths such as
+// uploads/resumes/3_1700000000_cv.pdf. To move from one store to another,
+// e.g. from the local store to S3, point -source-root at the old local
+// store root. Re-running the command is safe: blobs that are already in
+// place are not copied again.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/GolangAssignment/internal/config"
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	sourceRoot := flag.String("source-root", "", "root of a local blob store to copy keys from (default: only migrate legacy file paths)")
+	dryRun := flag.Bool("dry-run", false, "report what would be migrated without changing anything")
+	remove := flag.Bool("delete", false, "delete source files after they have been migrated")
+	flag.Parse()
+
+	cfg := config.LoadConfig()
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseDSN()), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	target, err := services.NewBlobStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure blob store: %v", err)
+	}
+
+	var source services.BlobStore
+	if *sourceRoot != "" {
+		source = services.NewLocalBlobStore(*sourceRoot)
+	}
+
+	paths, err := storedPaths(db)
+	if err != nil {
+		log.Fatalf("Failed to list stored files: %v", err)
+	}
+
+	ctx := context.Background()
+	migrated, skipped, failed := 0, 0, 0
+	for _, path := range paths {
+		content, err := readSource(ctx, source, path)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, services.ErrBlobNotFound) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			log.Printf("FAIL %s: %v", path, err)
+			failed++
+			continue
+		}
+
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		key := services.ContentKey(services.ResumeKeyPrefix, hash, path)
+		if *dryRun {
+			fmt.Printf("would move %s -> %s\n", path, key)
+			migrated++
+			continue
+		}
+
+		if err := migrate(ctx, db, target, path, key, hash, content); err != nil {
+			log.Printf("FAIL %s: %v", path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("moved %s -> %s\n", path, key)
+		migrated++
+
+		if *remove && key != path {
+			if source != nil {
+				err = source.Delete(ctx, path)
+			} else {
+				err = os.Remove(path)
+			}
+			if err != nil {
+				log.Printf("Failed to delete %s: %v", path, err)
+			}
+		}
+	}
+
+	fmt.Printf("%d migrated, %d skipped, %d failed\n", migrated, skipped, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// storedPaths returns every distinct file reference held in the database.
+func storedPaths(db *gorm.DB) ([]string, error) {
+	var paths []string
+	err := db.Raw(`
+		SELECT file_path FROM resume_versions WHERE deleted_at IS NULL
+		UNION
+		SELECT resume_file_path FROM profiles WHERE deleted_at IS NULL AND resume_file_path <> ''
+	`).Scan(&paths).Error
+	return paths, err
+}
+
+// readSource reads a file from the source store, or from its legacy local
+// path when no source store is given.
+func readSource(ctx context.Context, source services.BlobStore, path string) ([]byte, error) {
+	if source == nil {
+		return os.ReadFile(path)
+	}
+	r, err := source.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// migrate copies content to the target store and points every reference to
+// path at the new key.
+func migrate(ctx context.Context, db *gorm.DB, target services.BlobStore, path, key, hash string, content []byte) error {
+	exists, err := target.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := target.Put(ctx, key, bytes.NewReader(content), int64(len(content)), ""); err != nil {
+			return err
+		}
+	}
+	if key == path {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ResumeVersion{}).Where("file_path = ?", path).
+			Updates(map[string]interface{}{"file_path": key, "content_hash": hash}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ResumeJob{}).Where("file_path = ?", path).Update("file_path", key).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Profile{}).Where("resume_file_path = ?", path).Update("resume_file_path", key).Error
+	})
+}
@@ -0,0 +1,213 @@
+// Command parsereval measures how well a resume parser extracts each field
+// from a labeled corpus.
+//
+// Each <name>.txt in the corpus directory is parsed and compared with the
+// hand-labeled <name>.labels.json. The report gives per-field precision and
+// recall over the whole corpus. Scalar fields count as one item; education,
+// experience and skills count each entry, matched by institution and degree,
+// company and title, and skill name.
+//
+// LLM providers are served from recorded fixtures, so a prompt template can
+// be compared across providers offline. Use -record with an API key in the
+// environment to record responses for a new prompt version.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/config"
+	"github.com/GolangAssignment/internal/services"
+)
+
+// counts tallies true positives, false positives and false negatives.
+type counts struct {
+	TP, FP, FN int
+}
+
+func (c counts) precision() float64 {
+	if c.TP+c.FP == 0 {
+		return 1
+	}
+	return float64(c.TP) / float64(c.TP+c.FP)
+}
+
+func (c counts) recall() float64 {
+	if c.TP+c.FN == 0 {
+		return 1
+	}
+	return float64(c.TP) / float64(c.TP+c.FN)
+}
+
+var fields = []string{"name", "email", "phone", "education", "experience", "skills"}
+
+func main() {
+	dir := flag.String("dir", "internal/services/testdata/resumes", "directory containing the labeled resume corpus")
+	parserName := flag.String("parser", "gemini", "parser to evaluate: local, or an LLM provider (gemini, openai)")
+	prompt := flag.String("prompt", "", "resume extraction prompt version (default: latest)")
+	fixtures := flag.String("fixtures", "internal/services/testdata/llm", "directory of recorded LLM responses, one sub-directory per provider")
+	record := flag.Bool("record", false, "call the real API for requests without a fixture and record the responses")
+	verbose := flag.Bool("v", false, "list every missed and spurious item")
+	flag.Parse()
+
+	cfg := config.FromEnv()
+	cfg.ResumeParsers = []string{"local"}
+	cfg.ResumePromptVersion = *prompt
+	if *parserName != "local" {
+		cfg.ResumeParsers = []string{"llm"}
+		cfg.LLMProviders = []string{*parserName}
+		cfg.LLMRetryBackoff = 10 * time.Millisecond
+		cfg.LLMFixturesDir = *fixtures
+		cfg.LLMFixturesMode = services.FixturesReplay
+		if *record {
+			cfg.LLMFixturesMode = services.FixturesRecord
+		}
+	}
+	parser, err := services.NewResumeParser(cfg, nil, nil)
+	if err != nil {
+		log.Fatalf("Failed to create parser: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(*dir, "*.labels.json"))
+	if err != nil || len(files) == 0 {
+		log.Fatalf("No labeled resumes found in %s", *dir)
+	}
+
+	totals := map[string]*counts{}
+	for _, field := range fields {
+		totals[field] = &counts{}
+	}
+	promptVersion := ""
+	for _, labelFile := range files {
+		var want services.ResumeData
+		content, err := os.ReadFile(labelFile)
+		if err == nil {
+			err = json.Unmarshal(content, &want)
+		}
+		if err != nil {
+			log.Fatalf("Invalid labels %s: %v", labelFile, err)
+		}
+
+		file := strings.TrimSuffix(labelFile, ".labels.json") + ".txt"
+		text, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", file, err)
+		}
+		got, err := parser.Parse(context.Background(), file, string(text))
+		if err != nil {
+			// A failed parse misses every labeled item
+			log.Printf("FAIL %s: %v", file, err)
+			got = &services.ResumeData{}
+		}
+		if got.PromptVersion != "" {
+			promptVersion = got.PromptVersion
+		}
+
+		gotItems, wantItems := fieldItems(got), fieldItems(&want)
+		for _, field := range fields {
+			missed, spurious := compare(totals[field], gotItems[field], wantItems[field])
+			if *verbose {
+				for _, item := range missed {
+					fmt.Printf("%s: %s missed %q\n", filepath.Base(file), field, item)
+				}
+				for _, item := range spurious {
+					fmt.Printf("%s: %s spurious %q\n", filepath.Base(file), field, item)
+				}
+			}
+		}
+	}
+
+	fmt.Printf("parser %s", parser.Name())
+	if *parserName != "local" {
+		fmt.Printf(" (%s)", *parserName)
+	}
+	if promptVersion != "" {
+		fmt.Printf(", prompt %s", promptVersion)
+	}
+	fmt.Printf(", %d resumes\n\n", len(files))
+	fmt.Printf("%-12s %5s %5s %5s %10s %8s\n", "field", "tp", "fp", "fn", "precision", "recall")
+	var all counts
+	for _, field := range fields {
+		c := totals[field]
+		all.TP, all.FP, all.FN = all.TP+c.TP, all.FP+c.FP, all.FN+c.FN
+		fmt.Printf("%-12s %5d %5d %5d %10.3f %8.3f\n", field, c.TP, c.FP, c.FN, c.precision(), c.recall())
+	}
+	fmt.Printf("%-12s %5d %5d %5d %10.3f %8.3f\n", "all", all.TP, all.FP, all.FN, all.precision(), all.recall())
+}
+
+// fieldItems reduces parsed data to normalized items per field.
+func fieldItems(data *services.ResumeData) map[string][]string {
+	items := map[string][]string{
+		"name":  nonEmpty(normalize(data.Name)),
+		"email": nonEmpty(strings.ToLower(strings.TrimSpace(data.Email))),
+		"phone": nonEmpty(digits(data.Phone)),
+	}
+	for _, e := range data.EducationEntries {
+		items["education"] = append(items["education"], normalize(e.Institution)+" / "+normalize(e.Degree))
+	}
+	for _, e := range data.ExperienceEntries {
+		items["experience"] = append(items["experience"], normalize(e.Company)+" / "+normalize(e.Title))
+	}
+	for _, s := range data.SkillEntries {
+		items["skills"] = append(items["skills"], normalize(s.Name))
+	}
+	return items
+}
+
+// compare matches predicted items against labeled ones, each label at most
+// once, and returns the labels missed and the predictions that matched none.
+func compare(c *counts, got, want []string) (missed, spurious []string) {
+	remaining := map[string]int{}
+	for _, item := range want {
+		remaining[item]++
+	}
+	for _, item := range got {
+		if remaining[item] > 0 {
+			remaining[item]--
+			c.TP++
+		} else {
+			c.FP++
+			spurious = append(spurious, item)
+		}
+	}
+	for _, item := range want {
+		if remaining[item] > 0 {
+			remaining[item]--
+			c.FN++
+			missed = append(missed, item)
+		}
+	}
+	return missed, spurious
+}
+
+var punctuation = regexp.MustCompile(`[^\pL\pN+#]+`)
+
+// normalize ignores case, punctuation and spacing, so "B.Sc." matches
+// "BSc" and "Node.js" matches "NodeJS".
+func normalize(s string) string {
+	return strings.Join(strings.Fields(punctuation.ReplaceAllString(strings.ToLower(s), " ")), "")
+}
+
+func digits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,6 +20,120 @@ type Config struct {
 	JWTSecret    string
 	APIKey       string
 	GeminiAPIKey string
+	// GeminiAPIURL is the Generative Language API base URL.
+	GeminiAPIURL string
+	APILayerURL  string
+
+	GeminiModel string
+
+	// OpenAIAPIURL is the base URL of an OpenAI-compatible API, including
+	// the version (e.g. https://api.openai.com/v1).
+	OpenAIAPIURL string
+	OpenAIAPIKey string
+	OpenAIModel  string
+	// OpenAIResponseFormat is json_schema, json_object or text, for servers
+	// without structured output support.
+	OpenAIResponseFormat string
+
+	// LLMProviders lists the model backends to use, in failover order:
+	// gemini, openai, fake.
+	LLMProviders []string
+	// LLMFailoverCooldown is how long a failed provider is skipped.
+	LLMFailoverCooldown time.Duration
+	LLMTimeout          time.Duration
+	LLMMaxRetries       int
+	LLMRetryBackoff     time.Duration
+	// LLMFakeResponsesPath holds the canned answers of the fake provider.
+	LLMFakeResponsesPath string
+	// LLMPrices overrides model prices, as model=input/output in USD per
+	// million tokens, comma separated.
+	LLMPrices string
+	// Budgets in USD; zero means unlimited. Parses over budget fall back to
+	// the local parser.
+	LLMDailyBudget    float64
+	LLMMonthlyBudget  float64
+	LLMOrgDailyBudget float64
+	// ResumePromptVersion pins the resume extraction prompt template; empty
+	// means the latest version.
+	ResumePromptVersion string
+
+	// LLMFixturesDir, when set, serves LLM API calls from recorded fixture
+	// files in a sub-directory per provider instead of the network.
+	// LLMFixturesMode is replay or record.
+	LLMFixturesDir  string
+	LLMFixturesMode string
+
+	// ResumeParsers lists the parsers to try, in order: llm, apilayer, local.
+	ResumeParsers []string
+	// SkillsDictionaryPath points to the skills list used by the local
+	// parser; empty means the built-in list.
+	SkillsDictionaryPath string
+	// SkillsTaxonomyPath is a CSV or JSON file seeding an empty skills
+	// taxonomy; empty means the built-in seed.
+	SkillsTaxonomyPath string
+	// SkillsTaxonomyRefresh is how often the taxonomy is checked for
+	// changes made through other instances.
+	SkillsTaxonomyRefresh time.Duration
+
+	ResumeWorkers      int
+	ResumeMaxAttempts  int
+	ResumeRetryBackoff time.Duration
+
+	DuplicateScanInterval time.Duration
+	MergeUndoWindow       time.Duration
+
+	// EmbeddingProvider is local, openai or gemini. The API providers reuse
+	// the LLM API keys and URLs; EmbeddingModel overrides their default
+	// model and EmbeddingDimensions sizes the local vectors.
+	EmbeddingProvider     string
+	EmbeddingModel        string
+	EmbeddingDimensions   int
+	EmbeddingSyncInterval time.Duration
+
+	// JobAlertInterval is how often due daily and weekly job alert digests
+	// are sent.
+	JobAlertInterval time.Duration
+
+	// StorageBackend selects where uploaded files are kept: local or s3.
+	StorageBackend   string
+	StorageLocalRoot string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+	// S3ServerSideEncryption is AES256 or aws:kms; empty disables it.
+	S3ServerSideEncryption string
+	S3KMSKeyID             string
+
+	// DownloadSigningKey signs resume download links; it defaults to the
+	// JWT secret.
+	DownloadSigningKey string
+	DownloadURLTTL     time.Duration
+
+	// RevealIdentityEmails are admins granted the reveal identity
+	// permission on startup, so blind hiring grants can be bootstrapped.
+	RevealIdentityEmails []string
+
+	UploadMaxBytes int64
+	UploadMaxPages int
+	// ClamAVAddress is the clamd host:port or unix socket path; empty
+	// disables malware scanning.
+	ClamAVAddress string
+	ClamAVTimeout time.Duration
+}
+
+// DatabaseDSN returns the Postgres connection string for the configured
+// database.
+func (c Config) DatabaseDSN() string {
+	return "host=" + c.DBHost +
+		" user=" + c.DBUser +
+		" password=" + c.DBPassword +
+		" dbname=" + c.DBName +
+		" port=" + c.DBPort +
+		" sslmode=disable"
 }
 
 func LoadConfig() Config {
@@ -25,6 +142,12 @@ func LoadConfig() Config {
 		log.Fatalf("Error loading .env file")
 	}
 
+	return FromEnv()
+}
+
+// FromEnv reads the configuration from the environment alone, for tools
+// that run without a .env file.
+func FromEnv() Config {
 	return Config{
 		Port:         os.Getenv("PORT"),
 		DBHost:       os.Getenv("DB_HOST"),
@@ -35,5 +158,139 @@ func LoadConfig() Config {
 		JWTSecret:    os.Getenv("JWT_SECRET"),
 		APIKey:       os.Getenv("API_LAYER_KEY"),
 		GeminiAPIKey: os.Getenv("GEMINI_API_KEY"),
+		GeminiAPIURL: getEnv("GEMINI_API_URL", "https://generativelanguage.googleapis.com/v1beta"),
+		APILayerURL:  getEnv("API_LAYER_URL", "https://api.apilayer.com/resume_parser/upload"),
+
+		GeminiModel: getEnv("GEMINI_MODEL", "gemini-1.5-pro"),
+
+		OpenAIAPIURL:         getEnv("OPENAI_API_URL", "https://api.openai.com/v1"),
+		OpenAIAPIKey:         os.Getenv("OPENAI_API_KEY"),
+		OpenAIModel:          getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OpenAIResponseFormat: getEnv("OPENAI_RESPONSE_FORMAT", "json_schema"),
+
+		LLMProviders:         getEnvList("LLM_PROVIDERS", []string{"gemini"}),
+		LLMFailoverCooldown:  time.Duration(getEnvInt("LLM_FAILOVER_COOLDOWN_SECONDS", 60)) * time.Second,
+		LLMTimeout:           time.Duration(getEnvInt("LLM_TIMEOUT_SECONDS", 60)) * time.Second,
+		LLMMaxRetries:        getEnvInt("LLM_MAX_RETRIES", 3),
+		LLMRetryBackoff:      time.Duration(getEnvInt("LLM_RETRY_BACKOFF_MS", 500)) * time.Millisecond,
+		LLMFakeResponsesPath: os.Getenv("LLM_FAKE_RESPONSES_PATH"),
+		ResumePromptVersion:  os.Getenv("RESUME_PROMPT_VERSION"),
+
+		LLMPrices:         os.Getenv("LLM_PRICES"),
+		LLMDailyBudget:    getEnvFloat("LLM_DAILY_BUDGET_USD", 0),
+		LLMMonthlyBudget:  getEnvFloat("LLM_MONTHLY_BUDGET_USD", 0),
+		LLMOrgDailyBudget: getEnvFloat("LLM_ORG_DAILY_BUDGET_USD", 0),
+
+		LLMFixturesDir:  os.Getenv("LLM_FIXTURES_DIR"),
+		LLMFixturesMode: getEnv("LLM_FIXTURES_MODE", "replay"),
+
+		ResumeParsers:         getEnvList("RESUME_PARSERS", []string{"llm", "local"}),
+		SkillsDictionaryPath:  os.Getenv("SKILLS_DICTIONARY_PATH"),
+		SkillsTaxonomyPath:    os.Getenv("SKILLS_TAXONOMY_PATH"),
+		SkillsTaxonomyRefresh: time.Duration(getEnvInt("SKILLS_TAXONOMY_REFRESH_SECONDS", 60)) * time.Second,
+
+		ResumeWorkers:      getEnvInt("RESUME_WORKERS", 2),
+		ResumeMaxAttempts:  getEnvInt("RESUME_MAX_ATTEMPTS", 5),
+		ResumeRetryBackoff: time.Duration(getEnvInt("RESUME_RETRY_BACKOFF_SECONDS", 30)) * time.Second,
+
+		DuplicateScanInterval: time.Duration(getEnvInt("DUPLICATE_SCAN_INTERVAL_MINUTES", 60)) * time.Minute,
+		MergeUndoWindow:       time.Duration(getEnvInt("MERGE_UNDO_WINDOW_HOURS", 72)) * time.Hour,
+
+		EmbeddingProvider:     getEnv("EMBEDDING_PROVIDER", "local"),
+		EmbeddingModel:        os.Getenv("EMBEDDING_MODEL"),
+		EmbeddingDimensions:   getEnvInt("EMBEDDING_DIMENSIONS", 256),
+		EmbeddingSyncInterval: time.Duration(getEnvInt("EMBEDDING_SYNC_INTERVAL_MINUTES", 10)) * time.Minute,
+
+		JobAlertInterval: time.Duration(getEnvInt("JOB_ALERT_INTERVAL_MINUTES", 15)) * time.Minute,
+
+		StorageBackend:   getEnv("STORAGE_BACKEND", "local"),
+		StorageLocalRoot: getEnv("STORAGE_LOCAL_ROOT", "uploads"),
+
+		S3Endpoint:             os.Getenv("S3_ENDPOINT"),
+		S3Region:               getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:               os.Getenv("S3_BUCKET"),
+		S3AccessKey:            os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:            os.Getenv("S3_SECRET_KEY"),
+		S3PathStyle:            getEnvBool("S3_PATH_STYLE", false),
+		S3ServerSideEncryption: os.Getenv("S3_SSE"),
+		S3KMSKeyID:             os.Getenv("S3_SSE_KMS_KEY_ID"),
+
+		DownloadSigningKey: getEnv("DOWNLOAD_SIGNING_KEY", os.Getenv("JWT_SECRET")),
+		DownloadURLTTL:     time.Duration(getEnvInt("DOWNLOAD_URL_TTL_MINUTES", 15)) * time.Minute,
+
+		RevealIdentityEmails: getEnvList("REVEAL_IDENTITY_EMAILS", nil),
+
+		UploadMaxBytes: int64(getEnvInt("UPLOAD_MAX_SIZE_MB", 10)) << 20,
+		UploadMaxPages: getEnvInt("UPLOAD_MAX_PAGES", 20),
+		ClamAVAddress:  os.Getenv("CLAMAV_ADDRESS"),
+		ClamAVTimeout:  time.Duration(getEnvInt("CLAMAV_TIMEOUT_SECONDS", 30)) * time.Second,
+	}
+}
+
+// getEnv reads an environment variable, falling back to def when it is unset.
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getEnvList reads a comma separated environment variable.
+func getEnvList(key string, def []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvInt reads an integer environment variable, falling back to def when
+// it is unset or malformed.
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using %d", key, value, def)
+		return def
+	}
+	return n
+}
+
+// getEnvFloat reads a decimal environment variable, falling back to def
+// when it is unset or malformed.
+func getEnvFloat(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using %g", key, value, def)
+		return def
+	}
+	return f
+}
+
+// getEnvBool reads a boolean environment variable, falling back to def when
+// it is unset or malformed.
+func getEnvBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using %t", key, value, def)
+		return def
 	}
+	return b
 }
@@ -1,26 +1,66 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
 	"github.com/GolangAssignment/internal/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type AdminController struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Skills   *services.SkillTaxonomy
+	Alerts   *services.JobAlertService
+	Identity *services.IdentityGuard
 }
 
-func NewAdminController(db *gorm.DB) *AdminController {
-	return &AdminController{DB: db}
+func NewAdminController(db *gorm.DB, skills *services.SkillTaxonomy, alerts *services.JobAlertService, identity *services.IdentityGuard) *AdminController {
+	return &AdminController{DB: db, Skills: skills, Alerts: alerts, Identity: identity}
 }
 
 type CreateJobInput struct {
 	Title       string `json:"title" binding:"required"`
 	Description string `json:"description" binding:"required"`
 	CompanyName string `json:"company_name" binding:"required"`
+	JobRequirementsInput
+	BlindHiringInput
+}
+
+// JobRequirementsInput holds the criteria candidates are scored against.
+type JobRequirementsInput struct {
+	RequiredSkills     []string `json:"required_skills"`
+	PreferredSkills    []string `json:"preferred_skills"`
+	MinExperienceYears int      `json:"min_experience_years" binding:"min=0"`
+	EducationLevel     string   `json:"education_level"`
+}
+
+// educationLevel validates the requested education level.
+func (input JobRequirementsInput) educationLevel() (models.EducationLevel, error) {
+	level := models.EducationLevel(strings.ToLower(strings.TrimSpace(input.EducationLevel)))
+	if !level.IsValid() {
+		return "", fmt.Errorf("education_level must be one of diploma, bachelor, master, doctorate")
+	}
+	return level, nil
+}
+
+// BlindHiringInput turns blind hiring on or off for a job.
+type BlindHiringInput struct {
+	BlindHiring bool   `json:"blind_hiring"`
+	RevealStage string `json:"reveal_stage"`
+}
+
+// revealStage validates the requested reveal stage.
+func (input BlindHiringInput) revealStage() (models.ApplicationStage, error) {
+	stage := models.ApplicationStage(strings.TrimSpace(input.RevealStage))
+	if stage != "" && models.StagesFrom(stage) == nil {
+		return "", fmt.Errorf("reveal_stage must be one of Applied, Screening, Interview, Offer, Hired")
+	}
+	return stage, nil
 }
 
 func (ac *AdminController) CreateJob(c *gin.Context) {
@@ -30,6 +70,17 @@ func (ac *AdminController) CreateJob(c *gin.Context) {
 		return
 	}
 
+	educationLevel, err := input.educationLevel()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	revealStage, err := input.revealStage()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	userID, exists := c.Get("userID")
 	if !exists {
 		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
@@ -37,34 +88,151 @@ func (ac *AdminController) CreateJob(c *gin.Context) {
 	}
 
 	job := models.Job{
-		Title:       input.Title,
-		Description: input.Description,
-		CompanyName: input.CompanyName,
-		PostedByID:  userID.(uint),
+		Title:              input.Title,
+		Description:        input.Description,
+		CompanyName:        input.CompanyName,
+		PostedByID:         userID.(uint),
+		MinExperienceYears: input.MinExperienceYears,
+		EducationLevel:     educationLevel,
+		BlindHiring:        input.BlindHiring,
+		RevealStage:        revealStage,
+		AlertsPending:      true,
 	}
 
-	if err := ac.DB.Create(&job).Error; err != nil {
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		return services.ReplaceJobSkills(tx, &job, ac.Skills.Normalize(input.RequiredSkills), ac.Skills.Normalize(input.PreferredSkills))
+	})
+	if err != nil {
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create job")
 		return
 	}
 
+	// Tell applicants whose saved searches or profiles match
+	ac.Alerts.Notify(job.ID)
+
 	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Job created successfully", "job_id": job.ID})
 }
 
+// UpdateJobRequirements replaces the criteria applicants to a job are
+// scored against.
+func (ac *AdminController) UpdateJobRequirements(c *gin.Context) {
+	var input JobRequirementsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	educationLevel, err := input.educationLevel()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var job models.Job
+	if err := ac.DB.First(&job, c.Param("job_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	job.MinExperienceYears = input.MinExperienceYears
+	job.EducationLevel = educationLevel
+	err = ac.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&job).Select("MinExperienceYears", "EducationLevel").Updates(&job).Error; err != nil {
+			return err
+		}
+		return services.ReplaceJobSkills(tx, &job, ac.Skills.Normalize(input.RequiredSkills), ac.Skills.Normalize(input.PreferredSkills))
+	})
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update job requirements")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"job": job})
+}
+
+// UpdateBlindHiring turns blind hiring on or off for a job and sets the
+// stage at which applicants are revealed. Once a job is blind, only admins
+// who may reveal identities can turn it off or change the stage.
+func (ac *AdminController) UpdateBlindHiring(c *gin.Context) {
+	var input BlindHiringInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	revealStage, err := input.revealStage()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var job models.Job
+	if err := ac.DB.First(&job, c.Param("job_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	// Turning blind hiring off or moving the reveal stage can reveal
+	// candidates, so it takes the permission to see them anyway
+	if job.BlindHiring && (!input.BlindHiring || revealStage != job.RevealStage) {
+		userID, _ := c.Get("userID")
+		allowed, err := ac.Identity.HasPermission(userID.(uint), models.PermissionRevealIdentity)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check permissions")
+			return
+		}
+		if !allowed {
+			utils.RespondWithError(c, http.StatusForbidden, "Changing blind hiring on this job requires the reveal_identity permission")
+			return
+		}
+	}
+
+	job.BlindHiring = input.BlindHiring
+	job.RevealStage = revealStage
+	if err := ac.DB.Model(&job).Select("BlindHiring", "RevealStage").Updates(&job).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update blind hiring")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"job": job})
+}
+
+// GetJob returns a job with its applicants. On blind hiring jobs, the
+// applicants the caller may not identify are redacted.
 func (ac *AdminController) GetJob(c *gin.Context) {
 	jobID := c.Param("job_id")
 	var job models.Job
-	if err := ac.DB.Preload("Applications").Preload("Applications.Applicant").First(&job, jobID).Error; err != nil {
+	if err := ac.DB.Preload("Skills").Preload("Applications").Preload("Applications.Applicant").First(&job, jobID).Error; err != nil {
 		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
 		return
 	}
 
 	// Fetch applicants
 	var applicants []models.User
+	var applicantIDs []uint
 	for _, application := range job.Applications {
 		var applicant models.User
 		if err := ac.DB.First(&applicant, application.ApplicantID).Error; err == nil {
 			applicants = append(applicants, applicant)
+			applicantIDs = append(applicantIDs, applicant.ID)
+		}
+	}
+
+	userID, _ := c.Get("userID")
+	hidden, err := ac.Identity.HiddenForJob(userID.(uint), &job, applicantIDs)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identities")
+		return
+	}
+	for i := range job.Applications {
+		if hidden[job.Applications[i].ApplicantID] {
+			services.RedactUser(&job.Applications[i].Applicant)
+		}
+	}
+	for i := range applicants {
+		if hidden[applicants[i].ID] {
+			services.RedactUser(&applicants[i])
 		}
 	}
 
@@ -80,6 +248,9 @@ func (ac *AdminController) GetAllApplicants(c *gin.Context) {
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch applicants")
 		return
 	}
+	if !redactApplicants(c, ac.Identity, applicants) {
+		return
+	}
 
 	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"applicants": applicants})
 }
@@ -87,10 +258,168 @@ func (ac *AdminController) GetAllApplicants(c *gin.Context) {
 func (ac *AdminController) GetApplicantData(c *gin.Context) {
 	applicantID := c.Param("applicant_id")
 	var profile models.Profile
-	if err := ac.DB.Where("user_id = ?", applicantID).First(&profile).Error; err != nil {
+	if err := ac.DB.Preload("Educations").Preload("Experiences").Preload("ProfileSkills").
+		Where("user_id = ?", applicantID).First(&profile).Error; err != nil {
 		utils.RespondWithError(c, http.StatusNotFound, "Applicant profile not found")
 		return
 	}
+	sources, err := services.ProfileFieldSources(ac.DB, profile.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch profile field sources")
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	hidden, err := ac.Identity.HiddenApplicants(userID.(uint), []uint{profile.UserID})
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identity")
+		return
+	}
+	if hidden[profile.UserID] {
+		identity, err := ac.Identity.Identity(profile.UserID)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identity")
+			return
+		}
+		services.RedactProfile(&profile, identity)
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"profile": profile, "sources": sources})
+}
+
+// GetApplication returns an application with the resume version the
+// candidate submitted when applying. On blind hiring jobs, the applicant
+// and resume are redacted until the caller may identify them.
+func (ac *AdminController) GetApplication(c *gin.Context) {
+	var application models.Application
+	if err := ac.DB.Preload("Applicant").Preload("Job").Preload("Tags").Preload("ResumeVersion").
+		First(&application, c.Param("application_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Application not found")
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	hidden, err := ac.Identity.HiddenForJob(userID.(uint), &application.Job, []uint{application.ApplicantID})
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identity")
+		return
+	}
+	if hidden[application.ApplicantID] {
+		identity, err := ac.Identity.Identity(application.ApplicantID)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identity")
+			return
+		}
+		services.RedactUser(&application.Applicant)
+		if application.ResumeVersion != nil {
+			services.RedactResumeVersion(application.ResumeVersion, identity)
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"application": application})
+}
+
+type TrackedLinkInput struct {
+	Source      string `json:"source" binding:"required"`
+	UTMSource   string `json:"utm_source"`
+	UTMMedium   string `json:"utm_medium"`
+	UTMCampaign string `json:"utm_campaign"`
+	UTMTerm     string `json:"utm_term"`
+	UTMContent  string `json:"utm_content"`
+}
+
+// CreateTrackedLink issues an apply link for a job that attributes resulting
+// applications to the given source and UTM parameters.
+func (ac *AdminController) CreateTrackedLink(c *gin.Context) {
+	var input TrackedLinkInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	source := models.ApplicationSource(strings.ToLower(strings.TrimSpace(input.Source)))
+	if !source.IsValid() || source.IsReserved() {
+		utils.RespondWithError(c, http.StatusBadRequest, "source must be up to 50 letters, digits, dots, dashes or underscores, and not referral or talent_pool")
+		return
+	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"profile": profile})
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var job models.Job
+	if err := ac.DB.First(&job, c.Param("job_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	code, err := utils.RandomToken(6)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to generate link code")
+		return
+	}
+
+	link := models.TrackedLink{
+		JobID:       job.ID,
+		Code:        code,
+		Source:      source,
+		UTMSource:   input.UTMSource,
+		UTMMedium:   input.UTMMedium,
+		UTMCampaign: input.UTMCampaign,
+		UTMTerm:     input.UTMTerm,
+		UTMContent:  input.UTMContent,
+		CreatedByID: userID.(uint),
+	}
+	if err := ac.DB.Create(&link).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create tracked link")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{
+		"link": link,
+		"url":  fmt.Sprintf("/jobs/apply?job_id=%d&src=%s", job.ID, link.Code),
+	})
+}
+
+func (ac *AdminController) GetTrackedLinks(c *gin.Context) {
+	var links []models.TrackedLink
+	if err := ac.DB.Where("job_id = ?", c.Param("job_id")).Find(&links).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch tracked links")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"links": links})
+}
+
+// redactApplicants redacts the applicants the caller may not identify
+// because of blind hiring, responding with an error if that fails.
+func redactApplicants(c *gin.Context, guard *services.IdentityGuard, applicants []models.User) bool {
+	ids := make([]uint, len(applicants))
+	for i, applicant := range applicants {
+		ids[i] = applicant.ID
+	}
+	hidden, ok := hiddenApplicants(c, guard, ids)
+	if !ok {
+		return false
+	}
+	for i := range applicants {
+		if hidden[applicants[i].ID] {
+			services.RedactUser(&applicants[i])
+		}
+	}
+	return true
+}
+
+// hiddenApplicants returns which of the users the caller may not identify
+// because of blind hiring, responding with an error if that fails.
+func hiddenApplicants(c *gin.Context, guard *services.IdentityGuard, ids []uint) (map[uint]bool, bool) {
+	userID, _ := c.Get("userID")
+	hidden, err := guard.HiddenApplicants(userID.(uint), ids)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identities")
+		return nil, false
+	}
+	return hidden, true
 }
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AlertController lets applicants save job searches and choose how they
+// hear about new jobs that match them.
+type AlertController struct {
+	DB     *gorm.DB
+	Skills *services.SkillTaxonomy
+}
+
+// NewAlertController creates a new instance of AlertController.
+func NewAlertController(db *gorm.DB, skills *services.SkillTaxonomy) *AlertController {
+	return &AlertController{DB: db, Skills: skills}
+}
+
+// SavedSearchInput creates or changes a saved search. Fields left out of an
+// update are kept.
+type SavedSearchInput struct {
+	Name      *string  `json:"name"`
+	Keywords  *string  `json:"q"`
+	Company   *string  `json:"company"`
+	Skills    []string `json:"skills"`
+	Frequency *string  `json:"frequency"`
+}
+
+// apply validates the input and copies it onto search.
+func (input SavedSearchInput) apply(search *models.SavedSearch, skills *services.SkillTaxonomy) error {
+	if input.Name != nil {
+		search.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Keywords != nil {
+		search.Keywords = strings.Join(strings.Fields(*input.Keywords), " ")
+	}
+	if input.Company != nil {
+		search.Company = strings.TrimSpace(*input.Company)
+	}
+	if input.Skills != nil {
+		search.Skills = strings.Join(skills.Normalize(input.Skills), ",")
+	}
+	if input.Frequency != nil {
+		search.Frequency = models.AlertFrequency(strings.ToLower(strings.TrimSpace(*input.Frequency)))
+	}
+
+	if search.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !search.Frequency.IsValid() {
+		return fmt.Errorf("frequency must be one of instant, daily, weekly")
+	}
+	if services.SavedSearchFilter(search).IsEmpty() {
+		return fmt.Errorf("a saved search needs keywords, a company or skills")
+	}
+	return nil
+}
+
+func (ac *AlertController) GetSavedSearches(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var searches []models.SavedSearch
+	if err := ac.DB.Where("user_id = ?", userID.(uint)).Order("id").Find(&searches).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch saved searches")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"saved_searches": searches})
+}
+
+func (ac *AlertController) CreateSavedSearch(c *gin.Context) {
+	var input SavedSearchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	search := models.SavedSearch{UserID: userID.(uint), Frequency: models.AlertDaily}
+	if err := input.apply(&search, ac.Skills); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := ac.DB.Create(&search).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to save search")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"saved_search": search})
+}
+
+func (ac *AlertController) UpdateSavedSearch(c *gin.Context) {
+	var input SavedSearchInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var search models.SavedSearch
+	if err := ac.DB.Where("user_id = ?", userID.(uint)).First(&search, c.Param("search_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Saved search not found")
+		return
+	}
+	if err := input.apply(&search, ac.Skills); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := ac.DB.Save(&search).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to save search")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"saved_search": search})
+}
+
+// DeleteSavedSearch removes a saved search. Alerts it already raised are
+// still delivered.
+func (ac *AlertController) DeleteSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var search models.SavedSearch
+	if err := ac.DB.Where("user_id = ?", userID.(uint)).First(&search, c.Param("search_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Saved search not found")
+		return
+	}
+	if err := ac.DB.Delete(&search).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to delete saved search")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Saved search deleted"})
+}
+
+// AlertPreferenceInput sets up recommendation alerts.
+type AlertPreferenceInput struct {
+	Recommendations bool     `json:"recommendations"`
+	MinSimilarity   *float64 `json:"min_similarity" binding:"omitempty,min=0,max=1"`
+	Frequency       string   `json:"frequency"`
+}
+
+// GetAlertPreference shows the caller's recommendation alert settings, or
+// the defaults if they have not chosen any.
+func (ac *AlertController) GetAlertPreference(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	preference, err := ac.alertPreference(userID.(uint))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch alert preferences")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"preferences": preference})
+}
+
+func (ac *AlertController) UpdateAlertPreference(c *gin.Context) {
+	var input AlertPreferenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	preference, err := ac.alertPreference(userID.(uint))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch alert preferences")
+		return
+	}
+	preference.Recommendations = input.Recommendations
+	if input.MinSimilarity != nil {
+		preference.MinSimilarity = *input.MinSimilarity
+	}
+	if input.Frequency != "" {
+		preference.Frequency = models.AlertFrequency(strings.ToLower(strings.TrimSpace(input.Frequency)))
+	}
+	if !preference.Frequency.IsValid() {
+		utils.RespondWithError(c, http.StatusBadRequest, "frequency must be one of instant, daily, weekly")
+		return
+	}
+	if err := ac.DB.Save(preference).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update alert preferences")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"preferences": preference})
+}
+
+// alertPreference loads a user's preference, or an unsaved one with the
+// defaults.
+func (ac *AlertController) alertPreference(userID uint) (*models.AlertPreference, error) {
+	preference := models.AlertPreference{
+		UserID:        userID,
+		MinSimilarity: services.DefaultAlertSimilarity,
+		Frequency:     models.AlertDaily,
+	}
+	err := ac.DB.Where("user_id = ?", userID).First(&preference).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// GetAlerts lists the jobs the caller was alerted about, newest first,
+// including those still waiting for a digest.
+func (ac *AlertController) GetAlerts(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+	limit, ok := limitQuery(c, 50)
+	if !ok {
+		return
+	}
+
+	var alerts []models.JobAlert
+	if err := ac.DB.Joins("Job").Preload("SavedSearch").Where("job_alerts.user_id = ?", userID.(uint)).
+		Order("job_alerts.id DESC").Limit(limit).Find(&alerts).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch job alerts")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"alerts": alerts})
+}
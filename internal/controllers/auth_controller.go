@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/GolangAssignment/internal/config"
 	"github.com/GolangAssignment/internal/models"
@@ -35,12 +36,58 @@ func (ac *AuthController) SignUp(c *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := utils.HashPassword(input.Password)
+	if _, err := ac.createUser(input); err != nil {
+		utils.RespondWithError(c, err.status, err.message)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "User registered successfully"})
+}
+
+// EmployeeInput creates an employee account.
+type EmployeeInput struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	Address  string `json:"address"`
+}
+
+// CreateEmployee registers an employee. Employees can refer candidates, so
+// they cannot sign up themselves and are created by an admin.
+func (ac *AuthController) CreateEmployee(c *gin.Context) {
+	var input EmployeeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := ac.createUser(SignUpInput{
+		Name:     input.Name,
+		Email:    input.Email,
+		Password: input.Password,
+		UserType: string(models.Employee),
+		Address:  input.Address,
+	})
 	if err != nil {
-		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to hash password")
+		utils.RespondWithError(c, err.status, err.message)
 		return
 	}
 
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Employee registered successfully", "user_id": user.ID})
+}
+
+// signUpError is a failed registration with the response to give.
+type signUpError struct {
+	status  int
+	message string
+}
+
+func (ac *AuthController) createUser(input SignUpInput) (*models.User, *signUpError) {
+	hashedPassword, err := utils.HashPassword(input.Password)
+	if err != nil {
+		return nil, &signUpError{http.StatusInternalServerError, "Failed to hash password"}
+	}
+
 	user := models.User{
 		Name:            input.Name,
 		Email:           input.Email,
@@ -50,12 +97,32 @@ func (ac *AuthController) SignUp(c *gin.Context) {
 		ProfileHeadline: input.ProfileHeadline,
 	}
 
-	if err := ac.DB.Create(&user).Error; err != nil {
-		utils.RespondWithError(c, http.StatusBadRequest, "Email already exists")
-		return
+	// An applicant claims the account made when they were referred
+	if user.UserType == models.Applicant {
+		result := ac.DB.Model(&models.User{}).
+			Where("LOWER(email) = ? AND user_type = ? AND unclaimed = ?", strings.ToLower(user.Email), models.Applicant, true).
+			Updates(map[string]interface{}{
+				"name":             user.Name,
+				"address":          user.Address,
+				"password_hash":    user.PasswordHash,
+				"profile_headline": user.ProfileHeadline,
+				"unclaimed":        false,
+			})
+		if result.Error != nil {
+			return nil, &signUpError{http.StatusInternalServerError, "Failed to register user"}
+		}
+		if result.RowsAffected > 0 {
+			if err := ac.DB.Where("LOWER(email) = ?", strings.ToLower(user.Email)).First(&user).Error; err != nil {
+				return nil, &signUpError{http.StatusInternalServerError, "Failed to register user"}
+			}
+			return &user, nil
+		}
 	}
 
-	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "User registered successfully"})
+	if err := ac.DB.Create(&user).Error; err != nil {
+		return nil, &signUpError{http.StatusBadRequest, "Email already exists"}
+	}
+	return &user, nil
 }
 
 type LoginInput struct {
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GolangAssignment/internal/config"
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+	"github.com/GolangAssignment/internal/utils"
+)
+
+func TestSignUpClaimsReferredAccount(t *testing.T) {
+	db := testdb.Open(t, &models.User{})
+	candidate, err := findOrCreateCandidate(db, "Jane", "jane@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := NewAuthController(db, config.Config{JWTSecret: "secret"})
+
+	input := SignUpInput{Name: "Jane Doe", Email: "Jane@example.com", Password: "hunter22", UserType: string(models.Applicant)}
+	if code := serve(t, auth.SignUp, http.MethodPost, "/signup", 0, nil, input, nil); code != http.StatusCreated {
+		t.Fatalf("SignUp = %d, want 201", code)
+	}
+	var user models.User
+	db.First(&user, candidate.ID)
+	if user.Unclaimed || user.Name != "Jane Doe" || !utils.CheckPasswordHash("hunter22", user.PasswordHash) {
+		t.Errorf("account = %+v, want it claimed with the new name and password", user)
+	}
+
+	// Once claimed, the account is like any other
+	input.Email = "jane@example.com"
+	if code := serve(t, auth.SignUp, http.MethodPost, "/signup", 0, nil, input, nil); code != http.StatusBadRequest {
+		t.Errorf("second SignUp = %d, want 400", code)
+	}
+	var count int64
+	db.Model(&models.User{}).Count(&count)
+	if count != 1 {
+		t.Errorf("%d users, want 1", count)
+	}
+}
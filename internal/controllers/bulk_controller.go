@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BulkController handles recruiter bulk actions, saved application filters
+// and message templates.
+type BulkController struct {
+	DB   *gorm.DB
+	Bulk *services.BulkService
+}
+
+// NewBulkController creates a new instance of BulkController.
+func NewBulkController(db *gorm.DB, bulk *services.BulkService) *BulkController {
+	return &BulkController{DB: db, Bulk: bulk}
+}
+
+type BulkActionInput struct {
+	Action         string `json:"action" binding:"required,oneof=move_stage reject tag assign send_message"`
+	ApplicationIDs []uint `json:"application_ids"`
+	FilterID       *uint  `json:"filter_id"`
+	Stage          string `json:"stage"`
+	Reason         string `json:"reason"`
+	Tag            string `json:"tag"`
+	ReviewerID     *uint  `json:"reviewer_id"`
+	TemplateID     *uint  `json:"template_id"`
+}
+
+// StartBulkAction queues a bulk action over the selected applications of a job.
+func (bc *BulkController) StartBulkAction(c *gin.Context) {
+	var input BulkActionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var job models.Job
+	if err := bc.DB.First(&job, c.Param("job_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	op := models.BulkOperation{
+		JobID:         job.ID,
+		RequestedByID: userID.(uint),
+		Action:        models.BulkAction(input.Action),
+		Status:        models.BulkPending,
+		Stage:         models.ApplicationStage(input.Stage),
+		Reason:        input.Reason,
+		Tag:           input.Tag,
+		ReviewerID:    input.ReviewerID,
+		TemplateID:    input.TemplateID,
+	}
+
+	if msg := validateBulkOperation(bc.DB, &op); msg != "" {
+		utils.RespondWithError(c, http.StatusBadRequest, msg)
+		return
+	}
+
+	applicationIDs := input.ApplicationIDs
+	if input.FilterID != nil {
+		var filter models.ApplicationFilter
+		if err := bc.DB.Where("owner_id = ?", userID.(uint)).First(&filter, *input.FilterID).Error; err != nil {
+			utils.RespondWithError(c, http.StatusNotFound, "Filter not found")
+			return
+		}
+		var ids []uint
+		query := filter.Apply(bc.DB.Model(&models.Application{})).Where("applications.job_id = ?", job.ID)
+		if err := query.Pluck("applications.id", &ids).Error; err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to resolve filter")
+			return
+		}
+		applicationIDs = append(applicationIDs, ids...)
+	}
+	applicationIDs = uniqueIDs(applicationIDs)
+
+	if len(applicationIDs) == 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "No applications selected")
+		return
+	}
+
+	if err := bc.Bulk.Enqueue(&op, applicationIDs); err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create bulk operation")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusAccepted, gin.H{"operation_id": op.ID, "total": op.Total, "status": op.Status})
+}
+
+func validateBulkOperation(db *gorm.DB, op *models.BulkOperation) string {
+	switch op.Action {
+	case models.BulkMoveStage:
+		if !op.Stage.IsValid() {
+			return "A valid stage is required"
+		}
+	case models.BulkReject:
+		if op.Reason == "" {
+			return "A rejection reason is required"
+		}
+	case models.BulkTag:
+		if op.Tag == "" {
+			return "A tag is required"
+		}
+	case models.BulkAssign:
+		var reviewer models.User
+		if op.ReviewerID == nil || db.Where("user_type = ?", models.Admin).First(&reviewer, *op.ReviewerID).Error != nil {
+			return "A valid reviewer is required"
+		}
+	case models.BulkSendMessage:
+		var tmpl models.MessageTemplate
+		if op.TemplateID == nil || db.First(&tmpl, *op.TemplateID).Error != nil {
+			return "A valid message template is required"
+		}
+	}
+	return ""
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	var result []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// GetBulkOperation reports the progress and per-item results of an operation.
+func (bc *BulkController) GetBulkOperation(c *gin.Context) {
+	var op models.BulkOperation
+	if err := bc.DB.Preload("Items").First(&op, c.Param("operation_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Bulk operation not found")
+		return
+	}
+
+	var failures []models.BulkOperationItem
+	for _, item := range op.Items {
+		if item.Status == models.BulkItemError {
+			failures = append(failures, item)
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"operation": op, "failures": failures})
+}
+
+type ApplicationFilterInput struct {
+	Name       string `json:"name" binding:"required"`
+	JobID      *uint  `json:"job_id"`
+	Stage      string `json:"stage"`
+	Tag        string `json:"tag"`
+	ReviewerID *uint  `json:"reviewer_id"`
+}
+
+func (bc *BulkController) CreateFilter(c *gin.Context) {
+	var input ApplicationFilterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	stage := models.ApplicationStage(input.Stage)
+	if stage != "" && !stage.IsValid() {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid stage")
+		return
+	}
+
+	filter := models.ApplicationFilter{
+		Name:       input.Name,
+		OwnerID:    userID.(uint),
+		JobID:      input.JobID,
+		Stage:      stage,
+		Tag:        input.Tag,
+		ReviewerID: input.ReviewerID,
+	}
+	if err := bc.DB.Create(&filter).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to save filter")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"filter": filter})
+}
+
+func (bc *BulkController) GetFilters(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	var filters []models.ApplicationFilter
+	if err := bc.DB.Where("owner_id = ?", userID).Find(&filters).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch filters")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"filters": filters})
+}
+
+type MessageTemplateInput struct {
+	Name    string `json:"name" binding:"required"`
+	Subject string `json:"subject" binding:"required"`
+	Body    string `json:"body" binding:"required"`
+}
+
+func (bc *BulkController) CreateMessageTemplate(c *gin.Context) {
+	var input MessageTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	tmpl := models.MessageTemplate{
+		Name:        input.Name,
+		Subject:     input.Subject,
+		Body:        input.Body,
+		CreatedByID: userID.(uint),
+	}
+
+	// Render against placeholder data so broken templates are rejected up front.
+	sample := models.Application{Applicant: models.User{Name: "Jane Doe"}, Job: models.Job{Title: "Engineer", CompanyName: "Acme"}}
+	if _, _, err := services.RenderMessageTemplate(&tmpl, &sample); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := bc.DB.Create(&tmpl).Error; err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Template name already exists")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"template": tmpl})
+}
+
+func (bc *BulkController) GetMessageTemplates(c *gin.Context) {
+	var templates []models.MessageTemplate
+	if err := bc.DB.Find(&templates).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch templates")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"templates": templates})
+}
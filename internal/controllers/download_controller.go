@@ -0,0 +1,344 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DownloadController serves stored resume files to authorized users and
+// through signed, expiring links.
+type DownloadController struct {
+	DB       *gorm.DB
+	Store    services.BlobStore
+	Signer   *services.URLSigner
+	Identity *services.IdentityGuard
+}
+
+// NewDownloadController creates a new instance of DownloadController.
+func NewDownloadController(db *gorm.DB, store services.BlobStore, signer *services.URLSigner, identity *services.IdentityGuard) *DownloadController {
+	return &DownloadController{DB: db, Store: store, Signer: signer, Identity: identity}
+}
+
+// signedResumePath is the public path served for signed resume links.
+func signedResumePath(versionID uint) string {
+	return fmt.Sprintf("/files/resumes/%d", versionID)
+}
+
+// authorizedVersion loads a resume version the current user may download:
+// admins may download any resume, applicants only their own.
+func (dc *DownloadController) authorizedVersion(c *gin.Context) (*models.ResumeVersion, uint, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return nil, 0, false
+	}
+
+	var version models.ResumeVersion
+	if err := dc.DB.Omit("extracted_text", "document", "parsed_data").First(&version, c.Param("version_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Resume not found")
+		return nil, 0, false
+	}
+
+	userType, _ := c.Get("userType")
+	if userType != string(models.Admin) && version.UserID != userID.(uint) {
+		// Don't reveal that the resume exists
+		utils.RespondWithError(c, http.StatusNotFound, "Resume not found")
+		return nil, 0, false
+	}
+	return &version, userID.(uint), true
+}
+
+// hiddenFrom reports whether blind hiring hides the owner of a resume
+// version from a user, who then gets the redacted rendition.
+func (dc *DownloadController) hiddenFrom(version *models.ResumeVersion, userID uint) (bool, error) {
+	if version.UserID == userID {
+		return false, nil
+	}
+	hidden, err := dc.Identity.HiddenApplicants(userID, []uint{version.UserID})
+	return hidden[version.UserID], err
+}
+
+// DownloadResume streams a resume version to an admin or its owner.
+func (dc *DownloadController) DownloadResume(c *gin.Context) {
+	version, userID, ok := dc.authorizedVersion(c)
+	if !ok {
+		return
+	}
+	redact, err := dc.hiddenFrom(version, userID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identity")
+		return
+	}
+	dc.serve(c, version, nil, userID, models.DownloadDirect, redact)
+}
+
+// CreateDownloadURL issues a signed, short-lived link to a resume version
+// that can be embedded in emails or the UI without a bearer token.
+func (dc *DownloadController) CreateDownloadURL(c *gin.Context) {
+	version, userID, ok := dc.authorizedVersion(c)
+	if !ok {
+		return
+	}
+
+	link, expires := dc.Signer.Sign(signedResumePath(version.ID), userID, time.Now())
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"url": link, "expires_at": expires})
+}
+
+// DownloadApplicationResume streams the resume an application was submitted
+// with, falling back to the applicant's primary resume for applications made
+// before resume versions were kept.
+func (dc *DownloadController) DownloadApplicationResume(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var application models.Application
+	if err := dc.DB.Preload("Job").First(&application, c.Param("application_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Application not found")
+		return
+	}
+
+	versionID := application.ResumeVersionID
+	if versionID == nil {
+		versionID = services.PrimaryResumeVersionID(dc.DB, application.ApplicantID)
+	}
+	if versionID == nil {
+		utils.RespondWithError(c, http.StatusNotFound, "No resume attached to this application")
+		return
+	}
+
+	var version models.ResumeVersion
+	if err := dc.DB.Omit("extracted_text", "document", "parsed_data").First(&version, *versionID).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Resume not found")
+		return
+	}
+
+	hidden, err := dc.Identity.HiddenForJob(userID.(uint), &application.Job, []uint{application.ApplicantID})
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identity")
+		return
+	}
+	dc.serve(c, &version, &application.ID, userID.(uint), models.DownloadDirect, hidden[application.ApplicantID])
+}
+
+// DownloadSigned streams a resume through a signed link. It is a public
+// route: the signature is the authorization.
+func (dc *DownloadController) DownloadSigned(c *gin.Context) {
+	versionID, err := strconv.ParseUint(c.Param("version_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Resume not found")
+		return
+	}
+
+	issuerID, err := dc.Signer.Verify(signedResumePath(uint(versionID)), c.Request.URL.Query(), time.Now())
+	switch {
+	case errors.Is(err, services.ErrLinkExpired):
+		utils.RespondWithError(c, http.StatusGone, "Download link has expired")
+		return
+	case err != nil:
+		utils.RespondWithError(c, http.StatusForbidden, "Invalid download link")
+		return
+	}
+
+	var version models.ResumeVersion
+	if err := dc.DB.Omit("extracted_text", "document", "parsed_data").First(&version, versionID).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Resume not found")
+		return
+	}
+
+	// The issuer's access is checked again, in case blind hiring started
+	// after the link was issued
+	redact, err := dc.hiddenFrom(&version, issuerID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check applicant identity")
+		return
+	}
+	dc.serve(c, &version, nil, issuerID, models.DownloadSignedURL, redact)
+}
+
+// serve writes the resume file with download headers and records the
+// download in the audit log. With redact it writes the redacted rendition
+// instead, as a PDF or, with format=text, as plain text.
+func (dc *DownloadController) serve(c *gin.Context, version *models.ResumeVersion, applicationID *uint, userID uint, method models.DownloadMethod, redact bool) {
+	if redact {
+		dc.serveRedacted(c, version, applicationID, userID, method)
+		return
+	}
+
+	file, err := services.OpenResume(c.Request.Context(), dc.Store, version.FilePath)
+	if err != nil {
+		log.Printf("Error opening resume version %d: %v", version.ID, err)
+		utils.RespondWithError(c, http.StatusNotFound, "Resume file not available")
+		return
+	}
+	defer file.Close()
+
+	if !dc.audit(c, version, applicationID, userID, method, false) {
+		return
+	}
+
+	contentType := version.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(version.FileName))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", contentDisposition(version.FileName))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+	if version.Size > 0 {
+		c.Header("Content-Length", strconv.FormatInt(version.Size, 10))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		log.Printf("Error streaming resume version %d: %v", version.ID, err)
+	}
+}
+
+// serveRedacted writes the text of a resume with the candidate's identity
+// redacted. Photos and layout do not survive the rendition.
+func (dc *DownloadController) serveRedacted(c *gin.Context, version *models.ResumeVersion, applicationID *uint, userID uint, method models.DownloadMethod) {
+	format := c.DefaultQuery("format", "pdf")
+	if format != "pdf" && format != "text" {
+		utils.RespondWithError(c, http.StatusBadRequest, "format must be pdf or text")
+		return
+	}
+
+	var text string
+	if err := dc.DB.Model(&models.ResumeVersion{}).Where("id = ?", version.ID).Select("extracted_text").Scan(&text).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to load resume text")
+		return
+	}
+	identity, err := dc.Identity.Identity(version.UserID)
+	if err != nil {
+		log.Printf("Error loading identity for resume version %d: %v", version.ID, err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to redact resume")
+		return
+	}
+	text = identity.Redact(text)
+
+	if !dc.audit(c, version, applicationID, userID, method, true) {
+		return
+	}
+
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+	if format == "text" {
+		c.Header("Content-Disposition", contentDisposition("resume.txt"))
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(text))
+		return
+	}
+	c.Header("Content-Disposition", contentDisposition("resume.pdf"))
+	c.Data(http.StatusOK, "application/pdf", services.RenderTextPDF(text))
+}
+
+// audit records a download, responding with an error if it cannot.
+// Downloads must not happen without an audit record.
+func (dc *DownloadController) audit(c *gin.Context, version *models.ResumeVersion, applicationID *uint, userID uint, method models.DownloadMethod, redacted bool) bool {
+	audit := models.DownloadAudit{
+		ResumeVersionID: version.ID,
+		ApplicantID:     version.UserID,
+		ApplicationID:   applicationID,
+		UserID:          userID,
+		Method:          method,
+		IPAddress:       c.ClientIP(),
+		UserAgent:       c.Request.UserAgent(),
+		Redacted:        redacted,
+	}
+	if err := dc.DB.Create(&audit).Error; err != nil {
+		log.Printf("Error recording download of resume version %d: %v", version.ID, err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to download resume")
+		return false
+	}
+	return true
+}
+
+// contentDisposition builds an attachment header for fileName. Non-ASCII
+// names are sent as an RFC 5987 filename* parameter, with an ASCII fallback
+// for older clients.
+func contentDisposition(fileName string) string {
+	name := filepath.Base(fileName)
+	if name == "." || name == "/" {
+		name = "resume"
+	}
+
+	var fallback strings.Builder
+	ascii := true
+	for _, r := range name {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			// Control characters could split the header
+			ascii = false
+		case r == '"' || r == '\\':
+			fallback.WriteByte('_')
+		case r > 0x7e:
+			ascii = false
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	header := `attachment; filename="` + fallback.String() + `"`
+	if !ascii {
+		header += "; filename*=UTF-8''" + encodeRFC5987(name)
+	}
+	return header
+}
+
+// encodeRFC5987 percent-encodes every byte of value that is not an RFC 5987
+// attr-char, dropping control characters.
+func encodeRFC5987(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c < 0x20 || c == 0x7f:
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// GetDownloadAudits lists resume downloads, newest first, optionally
+// filtered by applicant_id or resume_version_id.
+func (dc *DownloadController) GetDownloadAudits(c *gin.Context) {
+	query := dc.DB.Model(&models.DownloadAudit{})
+	if applicantID := c.Query("applicant_id"); applicantID != "" {
+		query = query.Where("applicant_id = ?", applicantID)
+	}
+	if versionID := c.Query("resume_version_id"); versionID != "" {
+		query = query.Where("resume_version_id = ?", versionID)
+	}
+
+	var audits []models.DownloadAudit
+	if err := query.Order("id DESC").Limit(500).Find(&audits).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch download audit log")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"downloads": audits})
+}
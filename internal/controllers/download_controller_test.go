@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"resume.pdf", `attachment; filename="resume.pdf"`},
+		{"../../etc/passwd", `attachment; filename="passwd"`},
+		{"", `attachment; filename="resume"`},
+		{"/", `attachment; filename="resume"`},
+		{`bad"name\.pdf`, `attachment; filename="bad_name_.pdf"`},
+		{"Résumé.pdf", `attachment; filename="R_sum_.pdf"; filename*=UTF-8''R%C3%A9sum%C3%A9.pdf`},
+		{"cv\r\nSet-Cookie: a=b.pdf", `attachment; filename="cvSet-Cookie: a=b.pdf"; filename*=UTF-8''cvSet-Cookie%3A%20a%3Db.pdf`},
+		{"tab\there\x7f.pdf", `attachment; filename="tabhere.pdf"; filename*=UTF-8''tabhere.pdf`},
+		{`quote"s résumé.pdf`, `attachment; filename="quote_s r_sum_.pdf"; filename*=UTF-8''quote%22s%20r%C3%A9sum%C3%A9.pdf`},
+	}
+	for _, tt := range tests {
+		got := contentDisposition(tt.name)
+		if got != tt.want {
+			t.Errorf("contentDisposition(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+		if strings.ContainsAny(got, "\r\n\t\x00\x7f") {
+			t.Errorf("contentDisposition(%q) = %q has control characters", tt.name, got)
+		}
+	}
+}
+
+func TestEncodeRFC5987(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain-name_1.pdf", "plain-name_1.pdf"},
+		{"a b", "a%20b"},
+		{`"';%`, "%22%27%3B%25"},
+		{"new\nline", "newline"},
+		{"日本", "%E6%97%A5%E6%9C%AC"},
+	}
+	for _, tt := range tests {
+		if got := encodeRFC5987(tt.in); got != tt.want {
+			t.Errorf("encodeRFC5987(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
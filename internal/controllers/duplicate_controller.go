@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DuplicateController handles the duplicate candidate review queue and merges.
+type DuplicateController struct {
+	DB       *gorm.DB
+	Detector *services.DuplicateDetector
+	Merger   *services.MergeService
+	Identity *services.IdentityGuard
+}
+
+// NewDuplicateController creates a new instance of DuplicateController.
+func NewDuplicateController(db *gorm.DB, detector *services.DuplicateDetector, merger *services.MergeService, identity *services.IdentityGuard) *DuplicateController {
+	return &DuplicateController{DB: db, Detector: detector, Merger: merger, Identity: identity}
+}
+
+// GetDuplicates lists suspected duplicate pairs, highest score first.
+// Candidates the caller may not identify because of blind hiring are
+// redacted.
+func (dc *DuplicateController) GetDuplicates(c *gin.Context) {
+	status := c.DefaultQuery("status", string(models.DuplicatePending))
+
+	var duplicates []models.DuplicateCandidate
+	if err := dc.DB.Preload("UserA.Profile").Preload("UserB.Profile").
+		Where("status = ?", status).Order("score DESC").Find(&duplicates).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch duplicates")
+		return
+	}
+
+	var ids []uint
+	for _, duplicate := range duplicates {
+		ids = append(ids, duplicate.UserAID, duplicate.UserBID)
+	}
+	hidden, ok := hiddenApplicants(c, dc.Identity, ids)
+	if !ok {
+		return
+	}
+	for i := range duplicates {
+		if hidden[duplicates[i].UserAID] {
+			services.RedactUser(&duplicates[i].UserA)
+		}
+		if hidden[duplicates[i].UserBID] {
+			services.RedactUser(&duplicates[i].UserB)
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"duplicates": duplicates})
+}
+
+// ScanDuplicates runs the detector immediately instead of waiting for the
+// next scheduled scan.
+func (dc *DuplicateController) ScanDuplicates(c *gin.Context) {
+	created, err := dc.Detector.Scan()
+	if err != nil {
+		log.Printf("Error scanning for duplicates: %v", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to scan for duplicates")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"new_pairs": created})
+}
+
+func (dc *DuplicateController) DismissDuplicate(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	result := dc.DB.Model(&models.DuplicateCandidate{}).
+		Where("id = ? AND status = ?", c.Param("duplicate_id"), models.DuplicatePending).
+		Updates(map[string]interface{}{"status": models.DuplicateDismissed, "reviewed_by_id": userID})
+	if result.Error != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to dismiss duplicate")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.RespondWithError(c, http.StatusNotFound, "Pending duplicate not found")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Duplicate dismissed"})
+}
+
+type MergeInput struct {
+	SurvivorID uint `json:"survivor_id" binding:"required"`
+}
+
+// MergeDuplicate merges the pair into the chosen survivor.
+func (dc *DuplicateController) MergeDuplicate(c *gin.Context) {
+	var input MergeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var duplicate models.DuplicateCandidate
+	if err := dc.DB.Where("status = ?", models.DuplicatePending).First(&duplicate, c.Param("duplicate_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Pending duplicate not found")
+		return
+	}
+
+	var mergedID uint
+	switch input.SurvivorID {
+	case duplicate.UserAID:
+		mergedID = duplicate.UserBID
+	case duplicate.UserBID:
+		mergedID = duplicate.UserAID
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "Survivor must be one of the duplicate pair")
+		return
+	}
+
+	record, err := dc.Merger.Merge(input.SurvivorID, mergedID, userID.(uint), &duplicate.ID)
+	if err != nil {
+		log.Printf("Error merging user %d into %d: %v", mergedID, input.SurvivorID, err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to merge candidates")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"merge_id":      record.ID,
+		"undo_deadline": record.UndoDeadline,
+	})
+}
+
+func (dc *DuplicateController) UndoMerge(c *gin.Context) {
+	var record models.CandidateMerge
+	if err := dc.DB.First(&record, c.Param("merge_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Merge not found")
+		return
+	}
+
+	if err := dc.Merger.Undo(record.ID); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Merge undone"})
+}
@@ -1,25 +1,48 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
 	"github.com/GolangAssignment/internal/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type JobController struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Skills *services.SkillTaxonomy
 }
 
-func NewJobController(db *gorm.DB) *JobController {
-	return &JobController{DB: db}
+func NewJobController(db *gorm.DB, skills *services.SkillTaxonomy) *JobController {
+	return &JobController{DB: db, Skills: skills}
 }
 
+// GetJobs lists jobs, optionally filtered by keywords (q), company and
+// comma separated skills, or by one of the caller's saved searches.
 func (jc *JobController) GetJobs(c *gin.Context) {
+	filter := services.JobSearchFilter{Keywords: c.Query("q"), Company: c.Query("company")}
+	for _, skill := range strings.Split(c.Query("skill"), ",") {
+		if skill = strings.TrimSpace(skill); skill != "" {
+			filter.Skills = append(filter.Skills, skill)
+		}
+	}
+	if searchID := c.Query("saved_search"); searchID != "" {
+		userID, _ := c.Get("userID")
+		var search models.SavedSearch
+		if err := jc.DB.Where("user_id = ?", userID).First(&search, searchID).Error; err != nil {
+			utils.RespondWithError(c, http.StatusNotFound, "Saved search not found")
+			return
+		}
+		filter = services.SavedSearchFilter(&search)
+	}
+
 	var jobs []models.Job
-	if err := jc.DB.Find(&jobs).Error; err != nil {
+	if err := filter.Apply(jc.DB, jc.Skills).Find(&jobs).Error; err != nil {
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch jobs")
 		return
 	}
@@ -47,6 +70,40 @@ func (jc *JobController) ApplyJob(c *gin.Context) {
 		return
 	}
 
+	// Validate invitation code, if the candidate followed one
+	var invitation models.JobInvitation
+	invited := false
+	if token := c.Query("invitation"); token != "" {
+		if err := jc.DB.Where("token = ? AND job_id = ? AND user_id = ?", token, job.ID, userID.(uint)).First(&invitation).Error; err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid invitation")
+			return
+		}
+		invited = true
+	} else if err := jc.DB.Where("job_id = ? AND user_id = ?", job.ID, userID.(uint)).First(&invitation).Error; err == nil {
+		invited = true
+	}
+
+	// Validate referral code, if the candidate followed a referral link
+	var referral models.Referral
+	referred := false
+	if token := c.Query("referral"); token != "" {
+		if err := jc.DB.Where("token = ? AND job_id = ? AND status = ?", token, job.ID, models.ReferralPending).First(&referral).Error; err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid referral")
+			return
+		}
+		// Referral links are personal, so only the referred candidate is credited
+		var applicant models.User
+		if err := jc.DB.First(&applicant, userID.(uint)).Error; err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch applicant")
+			return
+		}
+		if !strings.EqualFold(strings.TrimSpace(applicant.Email), referral.CandidateEmail) {
+			utils.RespondWithError(c, http.StatusForbidden, "Referral was issued to a different email address")
+			return
+		}
+		referred = true
+	}
+
 	// Check if already applied
 	var application models.Application
 	if err := jc.DB.Where("job_id = ? AND applicant_id = ?", job.ID, userID.(uint)).First(&application).Error; err == nil {
@@ -58,9 +115,83 @@ func (jc *JobController) ApplyJob(c *gin.Context) {
 	application = models.Application{
 		JobID:       job.ID,
 		ApplicantID: userID.(uint),
+		Stage:       models.StageApplied,
+		Source:      models.SourceDirect,
+		UTMSource:   c.Query("utm_source"),
+		UTMMedium:   c.Query("utm_medium"),
+		UTMCampaign: c.Query("utm_campaign"),
+		UTMTerm:     c.Query("utm_term"),
+		UTMContent:  c.Query("utm_content"),
+		ReferrerURL: c.Request.Referer(),
+		// Snapshot the resume the candidate is applying with
+		ResumeVersionID: services.PrimaryResumeVersionID(jc.DB, userID.(uint)),
+	}
+	if source := models.ApplicationSource(strings.ToLower(strings.TrimSpace(c.Query("source")))); source != "" {
+		if !source.IsValid() || source.IsReserved() {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid source")
+			return
+		}
+		application.Source = source
+	}
+
+	// Tracked links override anything passed loosely on the query string
+	if code := c.Query("src"); code != "" {
+		var link models.TrackedLink
+		if err := jc.DB.Where("code = ? AND job_id = ?", code, job.ID).First(&link).Error; err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid tracking link")
+			return
+		}
+		application.TrackedLinkID = &link.ID
+		application.Source = link.Source
+		application.UTMSource = link.UTMSource
+		application.UTMMedium = link.UTMMedium
+		application.UTMCampaign = link.UTMCampaign
+		application.UTMTerm = link.UTMTerm
+		application.UTMContent = link.UTMContent
 	}
 
-	if err := jc.DB.Create(&application).Error; err != nil {
+	if referred {
+		application.Source = models.SourceReferral
+		application.ReferrerID = &referral.ReferrerID
+	} else if invited && application.Source == models.SourceDirect {
+		application.Source = models.SourceTalentPool
+	}
+
+	err := jc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&application).Error; err != nil {
+			return err
+		}
+		// Attribute the application to the invitation for re-engagement tracking
+		if invited {
+			if err := tx.Model(&invitation).Updates(map[string]interface{}{
+				"status":         models.InvitationApplied,
+				"applied_at":     time.Now(),
+				"application_id": application.ID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		// Use up the referral, unless a concurrent application already did
+		if referred {
+			result := tx.Model(&referral).Where("status = ?", models.ReferralPending).Updates(map[string]interface{}{
+				"status":         models.ReferralApplied,
+				"candidate_id":   userID.(uint),
+				"application_id": application.ID,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errReferralUsed
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errReferralUsed) {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid referral")
+		return
+	}
+	if err != nil {
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to apply for job")
 		return
 	}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// newApplyFixture returns a database with a job and an applicant.
+func newApplyFixture(t *testing.T) (*gorm.DB, models.Job, models.User) {
+	t.Helper()
+	db := testdb.Open(t, &models.User{}, &models.Job{}, &models.Application{}, &models.JobInvitation{},
+		&models.Referral{}, &models.TrackedLink{}, &models.ResumeVersion{})
+	admin := models.User{Name: "Admin", Email: "admin@example.com", UserType: models.Admin}
+	mustCreate(t, db, &admin)
+	job := models.Job{Title: "Go Developer", Description: "Go", CompanyName: "Acme", PostedByID: admin.ID}
+	mustCreate(t, db, &job)
+	applicant := models.User{Name: "Jane Doe", Email: "jane@example.com", UserType: models.Applicant}
+	mustCreate(t, db, &applicant)
+	return db, job, applicant
+}
+
+func TestApplyJobInvited(t *testing.T) {
+	db, job, applicant := newApplyFixture(t)
+	invitation := models.JobInvitation{JobID: job.ID, UserID: applicant.ID, InvitedByID: 1, Token: "invite", Status: models.InvitationViewed}
+	mustCreate(t, db, &invitation)
+
+	target := "/jobs/apply?job_id=" + itoa(job.ID) + "&invitation=invite"
+	if code := serve(t, NewJobController(db, nil).ApplyJob, http.MethodPost, target, applicant.ID, nil, nil, nil); code != http.StatusOK {
+		t.Fatalf("ApplyJob = %d, want 200", code)
+	}
+
+	var application models.Application
+	if err := db.Where("job_id = ? AND applicant_id = ?", job.ID, applicant.ID).First(&application).Error; err != nil {
+		t.Fatal(err)
+	}
+	if application.Source != models.SourceTalentPool {
+		t.Errorf("Source = %s, want %s", application.Source, models.SourceTalentPool)
+	}
+	db.First(&invitation, invitation.ID)
+	if invitation.Status != models.InvitationApplied || invitation.ApplicationID == nil || *invitation.ApplicationID != application.ID {
+		t.Errorf("invitation = %s for application %v, want applied for %d", invitation.Status, invitation.ApplicationID, application.ID)
+	}
+}
+
+func TestApplyJobReferral(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		wantCode int
+	}{
+		{"referred candidate", "Jane@Example.com", http.StatusOK},
+		{"someone else", "john@example.com", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, job, applicant := newApplyFixture(t)
+			db.Model(&applicant).Update("email", tt.email)
+			referral := models.Referral{JobID: job.ID, ReferrerID: 1, CandidateName: "Jane Doe", CandidateEmail: "jane@example.com",
+				Method: models.ReferralInvite, Token: "refer", Status: models.ReferralPending}
+			mustCreate(t, db, &referral)
+
+			target := "/jobs/apply?job_id=" + itoa(job.ID) + "&referral=refer"
+			if code := serve(t, NewJobController(db, nil).ApplyJob, http.MethodGet, target, applicant.ID, nil, nil, nil); code != tt.wantCode {
+				t.Fatalf("ApplyJob = %d, want %d", code, tt.wantCode)
+			}
+
+			var applications []models.Application
+			db.Where("job_id = ?", job.ID).Find(&applications)
+			db.First(&referral, referral.ID)
+			if tt.wantCode != http.StatusOK {
+				if len(applications) != 0 || referral.Status != models.ReferralPending {
+					t.Errorf("got %d applications and referral %s, want none and pending", len(applications), referral.Status)
+				}
+				return
+			}
+			if len(applications) != 1 || applications[0].Source != models.SourceReferral {
+				t.Fatalf("applications = %+v, want one referral", applications)
+			}
+			if referral.Status != models.ReferralApplied || referral.ApplicationID == nil || *referral.ApplicationID != applications[0].ID {
+				t.Errorf("referral = %s for application %v, want applied for %d", referral.Status, referral.ApplicationID, applications[0].ID)
+			}
+		})
+	}
+}
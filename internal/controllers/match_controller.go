@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MatchController ranks candidates against job requirements.
+type MatchController struct {
+	DB       *gorm.DB
+	Matcher  *services.CandidateMatcher
+	Identity *services.IdentityGuard
+}
+
+// NewMatchController creates a new instance of MatchController.
+func NewMatchController(db *gorm.DB, matcher *services.CandidateMatcher, identity *services.IdentityGuard) *MatchController {
+	return &MatchController{DB: db, Matcher: matcher, Identity: identity}
+}
+
+// GetRankedApplicants lists the applicants to a job sorted by match score,
+// each with the matched and missing skills behind the score. min_score
+// drops weaker applicants.
+func (mc *MatchController) GetRankedApplicants(c *gin.Context) {
+	minScore, ok := scoreQuery(c, "min_score", 0)
+	if !ok {
+		return
+	}
+	job, err := mc.Matcher.LoadJob(c.Param("job_id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	ranked, err := mc.Matcher.RankApplicants(job)
+	if err != nil {
+		log.Printf("Error ranking applicants for job %d: %v", job.ID, err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to rank applicants")
+		return
+	}
+	applicants := ranked[:0]
+	for _, candidate := range ranked {
+		if candidate.Match.Score >= minScore {
+			applicants = append(applicants, candidate)
+		}
+	}
+	if !mc.redactCandidates(c, job, applicants) {
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"job": job, "applicants": applicants})
+}
+
+// GetSuggestions proposes existing candidates who have not applied to a job,
+// best match first. limit defaults to 20 and min_score to 50.
+func (mc *MatchController) GetSuggestions(c *gin.Context) {
+	minScore, ok := scoreQuery(c, "min_score", 50)
+	if !ok {
+		return
+	}
+	limit, ok := limitQuery(c, 20)
+	if !ok {
+		return
+	}
+	job, err := mc.Matcher.LoadJob(c.Param("job_id"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+	if len(job.Skills) == 0 && job.MinExperienceYears == 0 && job.EducationLevel == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "Job has no requirements to match candidates against")
+		return
+	}
+
+	suggestions, err := mc.Matcher.SuggestCandidates(job, minScore, limit)
+	if err != nil {
+		log.Printf("Error suggesting candidates for job %d: %v", job.ID, err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to suggest candidates")
+		return
+	}
+	if !mc.redactCandidates(c, job, suggestions) {
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"job": job, "suggestions": suggestions})
+}
+
+// redactCandidates hides the names and emails of the candidates the caller
+// may not identify on a blind hiring job.
+func (mc *MatchController) redactCandidates(c *gin.Context, job *models.Job, candidates []services.RankedCandidate) bool {
+	ids := make([]uint, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.ApplicantID
+	}
+	userID, _ := c.Get("userID")
+	hidden, err := mc.Identity.HiddenForJob(userID.(uint), job, ids)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check candidate identities")
+		return false
+	}
+	for i := range candidates {
+		if hidden[candidates[i].ApplicantID] {
+			candidates[i].Name = services.Pseudonym(candidates[i].ApplicantID)
+			candidates[i].Email = ""
+		}
+	}
+	return true
+}
+
+// scoreQuery reads a score between 0 and 100 from the query, responding
+// with an error when it is invalid.
+func scoreQuery(c *gin.Context, name string, def float64) (float64, bool) {
+	s := c.Query(name)
+	if s == "" {
+		return def, true
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 || value > 100 {
+		utils.RespondWithError(c, http.StatusBadRequest, name+" must be a number between 0 and 100")
+		return 0, false
+	}
+	return value, true
+}
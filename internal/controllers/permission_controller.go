@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PermissionController grants admins permissions beyond their user type,
+// such as seeing who candidates to blind hiring jobs are.
+type PermissionController struct {
+	DB       *gorm.DB
+	Identity *services.IdentityGuard
+}
+
+// NewPermissionController creates a new instance of PermissionController.
+func NewPermissionController(db *gorm.DB, identity *services.IdentityGuard) *PermissionController {
+	return &PermissionController{DB: db, Identity: identity}
+}
+
+type PermissionInput struct {
+	UserID     uint   `json:"user_id" binding:"required"`
+	Permission string `json:"permission" binding:"required"`
+}
+
+func (pc *PermissionController) GetPermissions(c *gin.Context) {
+	var grants []models.UserPermission
+	if err := pc.DB.Preload("User").Order("id").Find(&grants).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch permissions")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"permissions": grants})
+}
+
+// GrantPermission gives an admin a permission. Only admins who hold the
+// permission themselves may grant it.
+func (pc *PermissionController) GrantPermission(c *gin.Context) {
+	var input PermissionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	permission := models.Permission(input.Permission)
+	if !permission.IsValid() {
+		utils.RespondWithError(c, http.StatusBadRequest, "permission must be reveal_identity")
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+	if !pc.holds(c, userID.(uint), permission) {
+		return
+	}
+
+	var user models.User
+	if err := pc.DB.Where("user_type = ?", models.Admin).First(&user, input.UserID).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Admin not found")
+		return
+	}
+
+	grant := models.UserPermission{UserID: user.ID, Permission: permission, GrantedByID: userID.(uint)}
+	if err := pc.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&grant).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to grant permission")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Permission granted"})
+}
+
+// RevokePermission takes a permission away. Only admins who hold the
+// permission may revoke it.
+func (pc *PermissionController) RevokePermission(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var grant models.UserPermission
+	if err := pc.DB.First(&grant, c.Param("permission_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Permission not found")
+		return
+	}
+	if !pc.holds(c, userID.(uint), grant.Permission) {
+		return
+	}
+	// Grants are unique per user, so revoked rows must not linger
+	if err := pc.DB.Unscoped().Delete(&grant).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to revoke permission")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Permission revoked"})
+}
+
+// holds checks that a user holds a permission, responding with an error if
+// they do not.
+func (pc *PermissionController) holds(c *gin.Context, userID uint, permission models.Permission) bool {
+	ok, err := pc.Identity.HasPermission(userID, permission)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to check permissions")
+		return false
+	}
+	if !ok {
+		utils.RespondWithError(c, http.StatusForbidden, "Only holders of a permission may grant or revoke it")
+		return false
+	}
+	return true
+}
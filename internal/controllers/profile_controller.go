@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ProfileController lets applicants view and correct their own profile.
+type ProfileController struct {
+	DB     *gorm.DB
+	Skills *services.SkillTaxonomy
+}
+
+// NewProfileController creates a new instance of ProfileController.
+func NewProfileController(db *gorm.DB, skills *services.SkillTaxonomy) *ProfileController {
+	return &ProfileController{DB: db, Skills: skills}
+}
+
+// GetProfile returns the caller's profile with the source of each field.
+func (pc *ProfileController) GetProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	pc.respondWithProfile(c, userID.(uint))
+}
+
+// UpdateProfile applies the caller's corrections. Edited fields are kept
+// when a resume is parsed again.
+func (pc *ProfileController) UpdateProfile(c *gin.Context) {
+	var update services.ProfileUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	if update.Skills != nil {
+		data := services.ResumeData{SkillEntries: *update.Skills}
+		pc.Skills.NormalizeResume(&data)
+		update.Skills = &data.SkillEntries
+	}
+	if _, err := services.UpdateProfile(pc.DB, userID.(uint), &update); errors.Is(err, services.ErrInvalidProfile) {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	} else if err != nil {
+		log.Printf("Error updating profile of user %d: %v", userID.(uint), err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	pc.respondWithProfile(c, userID.(uint))
+}
+
+// respondWithProfile writes a user's profile, with its records and field
+// sources.
+func (pc *ProfileController) respondWithProfile(c *gin.Context, userID uint) {
+	var profile models.Profile
+	if err := pc.DB.Preload("Educations").Preload("Experiences").Preload("ProfileSkills").
+		Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Profile not found")
+		return
+	}
+	sources, err := services.ProfileFieldSources(pc.DB, profile.ID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch profile field sources")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"profile": profile, "sources": sources})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/testdb"
+)
+
+func TestUpdateProfileSurvivesReparse(t *testing.T) {
+	db := testdb.Open(t, &models.Profile{}, &models.EducationEntry{}, &models.ExperienceEntry{}, &models.ProfileSkill{},
+		&models.ProfileFieldSource{})
+	pc := NewProfileController(db, nil)
+	parse := func(data services.ResumeData) {
+		t.Helper()
+		if _, err := services.SaveParsedProfile(db, 7, "resume.pdf", "hash", &data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parse(services.ResumeData{Name: "J. Doe", Phone: "555 0100",
+		SkillEntries:      []services.SkillRecord{{Name: "Go"}},
+		ExperienceEntries: []services.ExperienceRecord{{Company: "Acme", StartDate: "2019"}}})
+
+	edit := map[string]interface{}{
+		"name":       "Jane Doe",
+		"github_url": "https://github.com/janedoe",
+		"skills":     []map[string]interface{}{{"name": "Rust", "years": 2}},
+	}
+	if code := serve(t, pc.UpdateProfile, http.MethodPut, "/me/profile", 7, nil, edit, nil); code != http.StatusOK {
+		t.Fatalf("UpdateProfile = %d, want 200", code)
+	}
+
+	// A new resume refreshes the fields the applicant did not edit
+	parse(services.ResumeData{Name: "Janet Doe", Phone: "555 0199",
+		SkillEntries:      []services.SkillRecord{{Name: "Python"}},
+		ExperienceEntries: []services.ExperienceRecord{{Company: "Initech", StartDate: "2021"}}})
+
+	var response struct {
+		Profile models.Profile                `json:"profile"`
+		Sources map[string]models.FieldSource `json:"sources"`
+	}
+	if code := serve(t, pc.GetProfile, http.MethodGet, "/me/profile", 7, nil, nil, &response); code != http.StatusOK {
+		t.Fatalf("GetProfile = %d, want 200", code)
+	}
+	profile := response.Profile
+	if profile.Name != "Jane Doe" || profile.GitHubURL != "https://github.com/janedoe" || profile.Phone != "555 0199" {
+		t.Errorf("profile = %q %q %q, want the edited name and link and the new phone", profile.Name, profile.GitHubURL, profile.Phone)
+	}
+	if len(profile.ProfileSkills) != 1 || profile.ProfileSkills[0].Name != "Rust" || profile.ProfileSkills[0].Years != 2 {
+		t.Errorf("skills = %+v, want the edited Rust", profile.ProfileSkills)
+	}
+	if len(profile.Experiences) != 1 || profile.Experiences[0].Company != "Initech" {
+		t.Errorf("experiences = %+v, want the new Initech", profile.Experiences)
+	}
+	want := map[string]models.FieldSource{
+		models.ProfileFieldName:        models.FieldSourceUser,
+		models.ProfileFieldGitHubURL:   models.FieldSourceUser,
+		models.ProfileFieldSkills:      models.FieldSourceUser,
+		models.ProfileFieldEmail:       models.FieldSourceParsed,
+		models.ProfileFieldPhone:       models.FieldSourceParsed,
+		models.ProfileFieldEducations:  models.FieldSourceParsed,
+		models.ProfileFieldExperiences: models.FieldSourceParsed,
+	}
+	if !reflect.DeepEqual(response.Sources, want) {
+		t.Errorf("sources = %v, want %v", response.Sources, want)
+	}
+}
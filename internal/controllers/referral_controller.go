@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReferralController handles employee referrals and referral reporting.
+type ReferralController struct {
+	DB        *gorm.DB
+	Store     services.BlobStore
+	Validator *services.UploadValidator
+	Pipeline  *services.ResumePipeline
+}
+
+// NewReferralController creates a new instance of ReferralController.
+func NewReferralController(db *gorm.DB, store services.BlobStore, validator *services.UploadValidator, pipeline *services.ResumePipeline) *ReferralController {
+	return &ReferralController{DB: db, Store: store, Validator: validator, Pipeline: pipeline}
+}
+
+// CreateReferral refers a candidate for a job. It accepts multipart form data
+// with job_id, candidate_name and candidate_email. When a resume file is
+// attached the application is submitted on the candidate's behalf; otherwise
+// an invite link is returned for the employee to share.
+func (rc *ReferralController) CreateReferral(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+	referrerID := userID.(uint)
+
+	limitUploadSize(c, rc.Validator.MaxBytes)
+	jobID, err := strconv.ParseUint(c.PostForm("job_id"), 10, 64)
+	candidateName := strings.TrimSpace(c.PostForm("candidate_name"))
+	candidateEmail := strings.ToLower(strings.TrimSpace(c.PostForm("candidate_email")))
+	if err != nil || candidateName == "" || candidateEmail == "" {
+		utils.RespondWithError(c, http.StatusBadRequest, "job_id, candidate_name and candidate_email are required")
+		return
+	}
+
+	var job models.Job
+	if err := rc.DB.First(&job, jobID).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	token, err := utils.RandomToken(16)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to generate referral token")
+		return
+	}
+
+	referral := models.Referral{
+		JobID:          job.ID,
+		ReferrerID:     referrerID,
+		CandidateName:  candidateName,
+		CandidateEmail: candidateEmail,
+		Method:         models.ReferralInvite,
+		Token:          token,
+		Status:         models.ReferralPending,
+	}
+
+	header, err := c.FormFile("resume")
+	if err != nil {
+		if err := rc.DB.Create(&referral).Error; err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create referral")
+			return
+		}
+		utils.RespondWithSuccess(c, http.StatusCreated, gin.H{
+			"referral_id": referral.ID,
+			"invite_link": fmt.Sprintf("/jobs/apply?job_id=%d&referral=%s", job.ID, token),
+		})
+		return
+	}
+
+	referral.Method = models.ReferralUpload
+	var version *models.ResumeVersion
+	err = rc.DB.Transaction(func(tx *gorm.DB) error {
+		candidate, err := findOrCreateCandidate(tx, candidateName, candidateEmail)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		tx.Model(&models.Application{}).Where("job_id = ? AND applicant_id = ?", job.ID, candidate.ID).Count(&count)
+		if count > 0 {
+			return errAlreadyApplied
+		}
+
+		version, err = services.StoreResume(c.Request.Context(), rc.Store, rc.Validator, tx, candidate.ID, true, header)
+		if err != nil {
+			return err
+		}
+
+		application := models.Application{
+			JobID:           job.ID,
+			ApplicantID:     candidate.ID,
+			Stage:           models.StageApplied,
+			Source:          models.SourceReferral,
+			ReferrerID:      &referrerID,
+			ResumeVersionID: &version.ID,
+		}
+		if err := tx.Create(&application).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&job).Update("total_applications", gorm.Expr("total_applications + 1")).Error; err != nil {
+			return err
+		}
+
+		referral.Status = models.ReferralApplied
+		referral.CandidateID = &candidate.ID
+		referral.ApplicationID = &application.ID
+		return tx.Create(&referral).Error
+	})
+
+	var uploadErr *services.UploadError
+	switch {
+	case errors.As(err, &uploadErr):
+		utils.RespondWithError(c, http.StatusBadRequest, uploadErr.Message)
+		return
+	case errors.Is(err, errAlreadyApplied):
+		utils.RespondWithError(c, http.StatusBadRequest, "Candidate has already applied to this job")
+		return
+	case errors.Is(err, errNotApplicant):
+		utils.RespondWithError(c, http.StatusBadRequest, "Email belongs to a non-applicant account")
+		return
+	case err != nil:
+		log.Printf("Error creating referral by user %d: %v", referrerID, err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create referral")
+		return
+	}
+
+	// Parse the uploaded resume in the background
+	if _, err := rc.Pipeline.Enqueue(version, job.CompanyName); err != nil {
+		log.Printf("Error queueing referred resume %d: %v", version.ID, err)
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"referral_id": referral.ID, "application_id": referral.ApplicationID})
+}
+
+var (
+	errAlreadyApplied = errors.New("already applied")
+	errNotApplicant   = errors.New("not an applicant")
+	errReferralUsed   = errors.New("referral already used")
+)
+
+// findOrCreateCandidate returns the applicant with the given email, creating
+// an unclaimed account with a random password if none exists. The candidate
+// claims the account by signing up with the same email.
+func findOrCreateCandidate(tx *gorm.DB, name, email string) (*models.User, error) {
+	var user models.User
+	err := tx.Where("LOWER(email) = ?", email).First(&user).Error
+	if err == nil {
+		if user.UserType != models.Applicant {
+			return nil, errNotApplicant
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	password, err := utils.RandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user = models.User{
+		Name:         name,
+		Email:        email,
+		UserType:     models.Applicant,
+		PasswordHash: hash,
+		Unclaimed:    true,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// referralStatus maps an application stage to the coarse status a referrer
+// is allowed to see.
+func referralStatus(referral models.Referral) string {
+	if referral.Application == nil {
+		return "invited"
+	}
+	switch referral.Application.Stage {
+	case models.StageHired:
+		return "hired"
+	case models.StageRejected:
+		return "not selected"
+	case models.StageInterview, models.StageOffer:
+		return "interviewing"
+	default:
+		return "in review"
+	}
+}
+
+// GetMyReferrals lists the current employee's referrals with a limited status.
+func (rc *ReferralController) GetMyReferrals(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var referrals []models.Referral
+	if err := rc.DB.Preload("Job").Preload("Application").Where("referrer_id = ?", userID).Find(&referrals).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch referrals")
+		return
+	}
+
+	result := make([]gin.H, 0, len(referrals))
+	for _, referral := range referrals {
+		result = append(result, gin.H{
+			"referral_id":    referral.ID,
+			"job_id":         referral.JobID,
+			"job_title":      referral.Job.Title,
+			"candidate_name": referral.CandidateName,
+			"referred_on":    referral.CreatedAt,
+			"status":         referralStatus(referral),
+		})
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"referrals": result})
+}
+
+type referralReportRow struct {
+	ReferrerID   uint    `json:"referrer_id"`
+	ReferrerName string  `json:"referrer_name"`
+	Referrals    int     `json:"referrals"`
+	Applications int     `json:"applications"`
+	Hires        int     `json:"hires"`
+	Conversion   float64 `json:"conversion"`
+}
+
+// GetReferralReport shows referral-to-hire conversion per referrer.
+func (rc *ReferralController) GetReferralReport(c *gin.Context) {
+	query := rc.DB.Preload("Referrer").Preload("Application")
+	if jobID := c.Query("job_id"); jobID != "" {
+		query = query.Where("job_id = ?", jobID)
+	}
+
+	var referrals []models.Referral
+	if err := query.Find(&referrals).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch referrals")
+		return
+	}
+
+	rows := map[uint]*referralReportRow{}
+	var order []uint
+	total := referralReportRow{ReferrerName: "All referrers"}
+	for _, referral := range referrals {
+		row, ok := rows[referral.ReferrerID]
+		if !ok {
+			row = &referralReportRow{ReferrerID: referral.ReferrerID, ReferrerName: referral.Referrer.Name}
+			rows[referral.ReferrerID] = row
+			order = append(order, referral.ReferrerID)
+		}
+		for _, r := range []*referralReportRow{row, &total} {
+			r.Referrals++
+			if referral.Application != nil {
+				r.Applications++
+				if referral.Application.Stage == models.StageHired {
+					r.Hires++
+				}
+			}
+		}
+	}
+
+	report := make([]referralReportRow, 0, len(order))
+	for _, id := range order {
+		row := rows[id]
+		if row.Referrals > 0 {
+			row.Conversion = float64(row.Hires) / float64(row.Referrals)
+		}
+		report = append(report, *row)
+	}
+	if total.Referrals > 0 {
+		total.Conversion = float64(total.Hires) / float64(total.Referrals)
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"referrers": report, "total": total})
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReportController serves recruiting analytics.
+type ReportController struct {
+	DB *gorm.DB
+}
+
+// NewReportController creates a new instance of ReportController.
+func NewReportController(db *gorm.DB) *ReportController {
+	return &ReportController{DB: db}
+}
+
+type sourceReportRow struct {
+	Source       string  `json:"source"`
+	Applications int     `json:"applications"`
+	Interviews   int     `json:"interviews"`
+	Hires        int     `json:"hires"`
+	HireRate     float64 `json:"hire_rate"`
+}
+
+// sourceGroupColumns maps the group_by query parameter to a column.
+var sourceGroupColumns = map[string]string{
+	"source":       "a.source",
+	"utm_source":   "a.utm_source",
+	"utm_medium":   "a.utm_medium",
+	"utm_campaign": "a.utm_campaign",
+}
+
+// GetSourceReport shows applications, interviews and hires per source. An
+// application counts as interviewed if it ever reached the Interview stage,
+// even if it was rejected afterwards.
+func (rc *ReportController) GetSourceReport(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", "source")
+	column, ok := sourceGroupColumns[groupBy]
+	if !ok {
+		utils.RespondWithError(c, http.StatusBadRequest, "group_by must be one of source, utm_source, utm_medium, utm_campaign")
+		return
+	}
+
+	interviewStages := []models.ApplicationStage{models.StageInterview, models.StageOffer, models.StageHired}
+	query := fmt.Sprintf(`
+SELECT COALESCE(NULLIF(%s, ''), '(none)') AS source,
+	COUNT(*) AS applications,
+	COUNT(*) FILTER (WHERE a.stage IN @stages OR EXISTS (
+		SELECT 1 FROM application_stage_events e
+		WHERE e.application_id = a.id AND e.to_stage IN @stages AND e.deleted_at IS NULL)) AS interviews,
+	COUNT(*) FILTER (WHERE a.stage = @hired) AS hires
+FROM applications a
+WHERE a.deleted_at IS NULL AND (@job_id = 0 OR a.job_id = @job_id)
+GROUP BY 1
+ORDER BY applications DESC`, column)
+
+	var jobID uint
+	if c.Query("job_id") != "" {
+		if _, err := fmt.Sscan(c.Query("job_id"), &jobID); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid job_id")
+			return
+		}
+	}
+
+	var rows []sourceReportRow
+	err := rc.DB.Raw(query, map[string]interface{}{
+		"stages": interviewStages,
+		"hired":  models.StageHired,
+		"job_id": jobID,
+	}).Scan(&rows).Error
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to build source report")
+		return
+	}
+
+	for i := range rows {
+		if rows[i].Applications > 0 {
+			rows[i].HireRate = float64(rows[i].Hires) / float64(rows[i].Applications)
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"group_by": groupBy, "sources": rows})
+}
@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SimilarityController finds similar candidates and jobs by comparing
+// embeddings of their text.
+type SimilarityController struct {
+	DB         *gorm.DB
+	Embeddings *services.EmbeddingService
+	Identity   *services.IdentityGuard
+}
+
+// NewSimilarityController creates a new instance of SimilarityController.
+func NewSimilarityController(db *gorm.DB, embeddings *services.EmbeddingService, identity *services.IdentityGuard) *SimilarityController {
+	return &SimilarityController{DB: db, Embeddings: embeddings, Identity: identity}
+}
+
+type similarCandidate struct {
+	ApplicantID uint    `json:"applicant_id"`
+	Name        string  `json:"name"`
+	Email       string  `json:"email"`
+	Similarity  float64 `json:"similarity"`
+}
+
+type similarJob struct {
+	Job        models.Job `json:"job"`
+	Similarity float64    `json:"similarity"`
+}
+
+// GetSimilarCandidates lists the candidates whose profiles read most like
+// the given applicant's.
+func (sc *SimilarityController) GetSimilarCandidates(c *gin.Context) {
+	limit, ok := limitQuery(c, 10)
+	if !ok {
+		return
+	}
+	applicantID, err := strconv.ParseUint(c.Param("applicant_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid applicant ID")
+		return
+	}
+
+	vector, err := sc.Embeddings.CandidateVector(c.Request.Context(), uint(applicantID))
+	if err != nil {
+		sc.respondWithEmbeddingError(c, "Applicant profile", err)
+		return
+	}
+	similar := sc.Embeddings.Nearest(models.EmbeddingOwnerCandidate, vector, limit, func(id uint) bool {
+		return id == uint(applicantID)
+	})
+
+	var users []models.User
+	if err := sc.DB.Where("id IN ?", similarIDs(similar)).Find(&users).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch candidates")
+		return
+	}
+	if !redactApplicants(c, sc.Identity, users) {
+		return
+	}
+	byID := map[uint]models.User{}
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+	candidates := []similarCandidate{}
+	for _, s := range similar {
+		if u, ok := byID[s.ID]; ok {
+			candidates = append(candidates, similarCandidate{ApplicantID: u.ID, Name: u.Name, Email: u.Email, Similarity: s.Similarity})
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"candidates": candidates})
+}
+
+// GetSimilarJobs lists the jobs that read most like the given one.
+func (sc *SimilarityController) GetSimilarJobs(c *gin.Context) {
+	limit, ok := limitQuery(c, 10)
+	if !ok {
+		return
+	}
+	jobID, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	vector, err := sc.Embeddings.JobVector(c.Request.Context(), uint(jobID))
+	if err != nil {
+		sc.respondWithEmbeddingError(c, "Job", err)
+		return
+	}
+	similar := sc.Embeddings.Nearest(models.EmbeddingOwnerJob, vector, limit, func(id uint) bool {
+		return id == uint(jobID)
+	})
+
+	jobs, err := sc.loadJobs(similar)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch jobs")
+		return
+	}
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// GetRecommendedJobs lists the jobs that best fit the current applicant's
+// profile, leaving out jobs they already applied to.
+func (sc *SimilarityController) GetRecommendedJobs(c *gin.Context) {
+	limit, ok := limitQuery(c, 10)
+	if !ok {
+		return
+	}
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	vector, err := sc.Embeddings.CandidateVector(c.Request.Context(), userID.(uint))
+	if err != nil {
+		sc.respondWithEmbeddingError(c, "Profile", err)
+		return
+	}
+
+	var appliedIDs []uint
+	if err := sc.DB.Model(&models.Application{}).Where("applicant_id = ?", userID).
+		Pluck("job_id", &appliedIDs).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch applications")
+		return
+	}
+	applied := map[uint]bool{}
+	for _, id := range appliedIDs {
+		applied[id] = true
+	}
+	similar := sc.Embeddings.Nearest(models.EmbeddingOwnerJob, vector, limit, func(id uint) bool {
+		return applied[id]
+	})
+
+	jobs, err := sc.loadJobs(similar)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch jobs")
+		return
+	}
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// loadJobs fetches the jobs of a similarity result, keeping its order.
+func (sc *SimilarityController) loadJobs(similar []services.Similar) ([]similarJob, error) {
+	var jobs []models.Job
+	if err := sc.DB.Preload("Skills").Where("id IN ?", similarIDs(similar)).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	byID := map[uint]models.Job{}
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+	results := []similarJob{}
+	for _, s := range similar {
+		if job, ok := byID[s.ID]; ok {
+			results = append(results, similarJob{Job: job, Similarity: s.Similarity})
+		}
+	}
+	return results, nil
+}
+
+func (sc *SimilarityController) respondWithEmbeddingError(c *gin.Context, subject string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondWithError(c, http.StatusNotFound, subject+" not found")
+	case errors.Is(err, services.ErrNothingToEmbed):
+		utils.RespondWithError(c, http.StatusUnprocessableEntity, subject+" has no text to compare")
+	default:
+		log.Printf("Error computing embedding: %v", err)
+		utils.RespondWithError(c, http.StatusBadGateway, "Failed to compute embedding")
+	}
+}
+
+func similarIDs(similar []services.Similar) []uint {
+	ids := make([]uint, len(similar))
+	for i, s := range similar {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// limitQuery reads the number of results to return, between 1 and 100.
+func limitQuery(c *gin.Context, def int) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(def)))
+	if err != nil || limit < 1 || limit > 100 {
+		utils.RespondWithError(c, http.StatusBadRequest, "limit must be between 1 and 100")
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxSkillsFileBytes bounds the size of an uploaded taxonomy file.
+const maxSkillsFileBytes = 5 << 20
+
+// SkillController lets admins curate the skills taxonomy.
+type SkillController struct {
+	DB     *gorm.DB
+	Skills *services.SkillTaxonomy
+}
+
+// NewSkillController creates a new instance of SkillController.
+func NewSkillController(db *gorm.DB, skills *services.SkillTaxonomy) *SkillController {
+	return &SkillController{DB: db, Skills: skills}
+}
+
+// GetSkills lists the taxonomy, optionally filtered by category or by a
+// search on names and synonyms.
+func (sc *SkillController) GetSkills(c *gin.Context) {
+	query := sc.DB.Preload("Synonyms").Order("name")
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		pattern := utils.ContainsPattern(q)
+		query = query.Where("LOWER(name) LIKE ? OR id IN (?)", pattern,
+			sc.DB.Model(&models.SkillSynonym{}).Select("skill_id").Where("name LIKE ?", pattern))
+	}
+
+	var skills []models.Skill
+	if err := query.Find(&skills).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch skills")
+		return
+	}
+	categories, err := sc.Skills.Categories()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch skill categories")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"skills": skills, "categories": categories})
+}
+
+func (sc *SkillController) GetSkill(c *gin.Context) {
+	var skill models.Skill
+	if err := sc.DB.Preload("Synonyms").Preload("Parent").Preload("Children").
+		First(&skill, c.Param("skill_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Skill not found")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"skill": skill})
+}
+
+func (sc *SkillController) CreateSkill(c *gin.Context) {
+	var input services.SkillSeed
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	skill, err := sc.Skills.CreateSkill(input)
+	if err != nil {
+		respondWithSkillError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"skill": skill})
+}
+
+func (sc *SkillController) UpdateSkill(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("skill_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid skill ID")
+		return
+	}
+	var input services.SkillUpdate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	skill, err := sc.Skills.UpdateSkill(uint(id), input)
+	if err != nil {
+		respondWithSkillError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"skill": skill})
+}
+
+// DeleteSkill removes a skill from the taxonomy. Profiles and jobs keep the
+// name; it just stops being normalized.
+func (sc *SkillController) DeleteSkill(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("skill_id"), 10, 64)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid skill ID")
+		return
+	}
+	if err := sc.Skills.DeleteSkill(uint(id)); err != nil {
+		respondWithSkillError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Skill deleted"})
+}
+
+// ImportSkills merges an uploaded CSV or JSON taxonomy file into the
+// taxonomy.
+func (sc *SkillController) ImportSkills(c *gin.Context) {
+	limitUploadSize(c, maxSkillsFileBytes)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "A skills file is required")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Failed to read skills file")
+		return
+	}
+
+	seeds, err := services.ParseSkillSeeds(header.Filename, content)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := sc.Skills.Import(seeds)
+	if err != nil {
+		respondWithSkillError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"import": result})
+}
+
+// NormalizeSkills shows the canonical names the taxonomy gives to the
+// comma separated names in the query.
+func (sc *SkillController) NormalizeSkills(c *gin.Context) {
+	names := strings.Split(c.Query("names"), ",")
+	normalized := map[string]string{}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			normalized[name] = sc.Skills.Canonical(name)
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"skills": normalized})
+}
+
+func respondWithSkillError(c *gin.Context, err error) {
+	var conflict *services.SkillConflictError
+	switch {
+	case errors.As(err, &conflict):
+		utils.RespondWithError(c, http.StatusConflict, conflict.Error())
+	case errors.Is(err, services.ErrInvalidSkill):
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.RespondWithError(c, http.StatusNotFound, "Skill not found")
+	default:
+		log.Printf("Error updating skills taxonomy: %v", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update skills taxonomy")
+	}
+}
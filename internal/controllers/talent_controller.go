@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TalentController handles talent pools, applicant tags and job invitations.
+type TalentController struct {
+	DB       *gorm.DB
+	Skills   *services.SkillTaxonomy
+	Identity *services.IdentityGuard
+}
+
+// NewTalentController creates a new instance of TalentController.
+func NewTalentController(db *gorm.DB, skills *services.SkillTaxonomy, identity *services.IdentityGuard) *TalentController {
+	return &TalentController{DB: db, Skills: skills, Identity: identity}
+}
+
+type TalentPoolInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+func (tc *TalentController) CreatePool(c *gin.Context) {
+	var input TalentPoolInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	pool := models.TalentPool{
+		Name:        input.Name,
+		Description: input.Description,
+		CreatedByID: userID.(uint),
+	}
+	if err := tc.DB.Create(&pool).Error; err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Talent pool name already exists")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"pool": pool})
+}
+
+func (tc *TalentController) GetPools(c *gin.Context) {
+	var pools []models.TalentPool
+	if err := tc.DB.Find(&pools).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch talent pools")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"pools": pools})
+}
+
+// GetPool returns a talent pool with its members. Members the caller may
+// not identify because of blind hiring are redacted.
+func (tc *TalentController) GetPool(c *gin.Context) {
+	var pool models.TalentPool
+	if err := tc.DB.Preload("Members.User").First(&pool, c.Param("pool_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Talent pool not found")
+		return
+	}
+
+	ids := make([]uint, len(pool.Members))
+	for i, member := range pool.Members {
+		ids[i] = member.UserID
+	}
+	hidden, ok := hiddenApplicants(c, tc.Identity, ids)
+	if !ok {
+		return
+	}
+	for i := range pool.Members {
+		if hidden[pool.Members[i].UserID] {
+			services.RedactUser(&pool.Members[i].User)
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"pool": pool})
+}
+
+type PoolMembersInput struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1"`
+}
+
+func (tc *TalentController) AddPoolMembers(c *gin.Context) {
+	var input PoolMembersInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var pool models.TalentPool
+	if err := tc.DB.First(&pool, c.Param("pool_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Talent pool not found")
+		return
+	}
+
+	var applicantIDs []uint
+	if err := tc.DB.Model(&models.User{}).
+		Where("id IN ? AND user_type = ?", input.UserIDs, models.Applicant).
+		Pluck("id", &applicantIDs).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to look up applicants")
+		return
+	}
+
+	for _, id := range applicantIDs {
+		member := models.TalentPoolMember{PoolID: pool.ID, UserID: id}
+		if err := tc.DB.Where(member).Attrs(models.TalentPoolMember{AddedByID: userID.(uint)}).FirstOrCreate(&member).Error; err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to add pool member")
+			return
+		}
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Members added", "added": applicantIDs})
+}
+
+// RemovePoolMember takes an applicant out of a pool. Members are unique per
+// pool, so the row is deleted for good and the applicant can be added back.
+func (tc *TalentController) RemovePoolMember(c *gin.Context) {
+	result := tc.DB.Unscoped().Where("pool_id = ? AND user_id = ?", c.Param("pool_id"), c.Param("user_id")).Delete(&models.TalentPoolMember{})
+	if result.Error != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to remove pool member")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.RespondWithError(c, http.StatusNotFound, "Pool member not found")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+type TagsInput struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+func (tc *TalentController) AddApplicantTags(c *gin.Context) {
+	var input TagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var applicant models.User
+	if err := tc.DB.Where("user_type = ?", models.Applicant).First(&applicant, c.Param("applicant_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Applicant not found")
+		return
+	}
+
+	for _, raw := range input.Tags {
+		tag := normalizeTag(raw)
+		if tag == "" {
+			continue
+		}
+		userTag := models.UserTag{UserID: applicant.ID, Tag: tag}
+		if err := tc.DB.Where(userTag).FirstOrCreate(&userTag).Error; err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to tag applicant")
+			return
+		}
+	}
+
+	var tags []models.UserTag
+	tc.DB.Where("user_id = ?", applicant.ID).Find(&tags)
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"tags": tags})
+}
+
+// RemoveApplicantTag removes a tag from an applicant. Tags are unique per
+// applicant, so the row is deleted for good and the tag can be added again.
+func (tc *TalentController) RemoveApplicantTag(c *gin.Context) {
+	result := tc.DB.Unscoped().Where("user_id = ? AND tag = ?", c.Param("applicant_id"), normalizeTag(c.Param("tag"))).Delete(&models.UserTag{})
+	if result.Error != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to remove tag")
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.RespondWithError(c, http.StatusNotFound, "Tag not found")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Tag removed"})
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// SearchTalent lists applicants filtered by any combination of tags, skills
+// and pool. Multiple tags or skills are comma separated and must all match.
+// Skills match through the taxonomy, so "js" finds JavaScript and its
+// frameworks.
+func (tc *TalentController) SearchTalent(c *gin.Context) {
+	query := tc.DB.Model(&models.User{}).Where("user_type = ?", models.Applicant)
+
+	if poolID := c.Query("pool_id"); poolID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM talent_pool_members m WHERE m.user_id = users.id AND m.pool_id = ? AND m.deleted_at IS NULL)", poolID)
+	}
+	if tags := c.Query("tag"); tags != "" {
+		for _, tag := range strings.Split(tags, ",") {
+			query = query.Where("EXISTS (SELECT 1 FROM user_tags t WHERE t.user_id = users.id AND t.tag = ? AND t.deleted_at IS NULL)", normalizeTag(tag))
+		}
+	}
+	if skills := c.Query("skill"); skills != "" {
+		for _, skill := range strings.Split(skills, ",") {
+			query = query.Where(`EXISTS (SELECT 1 FROM profiles p JOIN profile_skills s ON s.profile_id = p.id
+				WHERE p.user_id = users.id AND LOWER(s.name) IN ? AND p.deleted_at IS NULL AND s.deleted_at IS NULL)`,
+				tc.Skills.Variants(skill, true))
+		}
+	}
+
+	var applicants []models.User
+	if err := query.Preload("Tags").Find(&applicants).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to search applicants")
+		return
+	}
+	if !redactApplicants(c, tc.Identity, applicants) {
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"applicants": applicants})
+}
+
+type InviteInput struct {
+	JobID   uint   `json:"job_id" binding:"required"`
+	Message string `json:"message"`
+}
+
+// InvitePool invites every member of a pool to apply to a job. Members who
+// were already invited to, or already applied for, the job are skipped.
+func (tc *TalentController) InvitePool(c *gin.Context) {
+	var input InviteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var pool models.TalentPool
+	if err := tc.DB.Preload("Members").First(&pool, c.Param("pool_id")).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Talent pool not found")
+		return
+	}
+
+	var job models.Job
+	if err := tc.DB.First(&job, input.JobID).Error; err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	invited, skipped := 0, 0
+	for _, member := range pool.Members {
+		var count int64
+		tc.DB.Model(&models.Application{}).Where("job_id = ? AND applicant_id = ?", job.ID, member.UserID).Count(&count)
+		if count == 0 {
+			tc.DB.Model(&models.JobInvitation{}).Where("job_id = ? AND user_id = ?", job.ID, member.UserID).Count(&count)
+		}
+		if count > 0 {
+			skipped++
+			continue
+		}
+
+		token, err := utils.RandomToken(16)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to generate invitation token")
+			return
+		}
+
+		invitation := models.JobInvitation{
+			JobID:       job.ID,
+			UserID:      member.UserID,
+			PoolID:      &pool.ID,
+			InvitedByID: userID.(uint),
+			Token:       token,
+			Status:      models.InvitationSent,
+		}
+		err = tc.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&invitation).Error; err != nil {
+				return err
+			}
+			body := fmt.Sprintf("You are invited to apply for %s at %s.\nApply with invitation code: %s", job.Title, job.CompanyName, token)
+			if input.Message != "" {
+				body = input.Message + "\n\n" + body
+			}
+			message := models.Message{
+				RecipientID: member.UserID,
+				SenderID:    userID.(uint),
+				Subject:     fmt.Sprintf("Invitation to apply: %s", job.Title),
+				Body:        body,
+			}
+			return tx.Create(&message).Error
+		})
+		if err != nil {
+			log.Printf("Error inviting user %d to job %d: %v", member.UserID, job.ID, err)
+			skipped++
+			continue
+		}
+		invited++
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"invited": invited, "skipped": skipped})
+}
+
+// GetInvitationStats reports how many invited candidates viewed and applied
+// to a job.
+func (tc *TalentController) GetInvitationStats(c *gin.Context) {
+	var invitations []models.JobInvitation
+	if err := tc.DB.Where("job_id = ?", c.Param("job_id")).Find(&invitations).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+
+	viewed, applied := 0, 0
+	for _, inv := range invitations {
+		if inv.ViewedAt != nil {
+			viewed++
+		}
+		if inv.Status == models.InvitationApplied {
+			applied++
+		}
+	}
+
+	rate := 0.0
+	if len(invitations) > 0 {
+		rate = float64(applied) / float64(len(invitations))
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"sent":               len(invitations),
+		"viewed":             viewed,
+		"applied":            applied,
+		"re_engagement_rate": rate,
+		"invitations":        invitations,
+	})
+}
+
+// GetMyInvitations lists the invitations of the current applicant and marks
+// unseen ones as viewed.
+func (tc *TalentController) GetMyInvitations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	err := tc.DB.Model(&models.JobInvitation{}).
+		Where("user_id = ? AND status = ?", userID, models.InvitationSent).
+		Updates(map[string]interface{}{"status": models.InvitationViewed, "viewed_at": time.Now()}).Error
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to mark invitations as viewed")
+		return
+	}
+
+	var invitations []models.JobInvitation
+	if err := tc.DB.Where("user_id = ?", userID).Find(&invitations).Error; err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch invitations")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"invitations": invitations})
+}
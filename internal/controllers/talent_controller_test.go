@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+)
+
+func TestGetMyInvitationsMarksViewed(t *testing.T) {
+	db := testdb.Open(t, &models.JobInvitation{})
+	mustCreate(t, db, &models.JobInvitation{JobID: 1, UserID: 7, InvitedByID: 1, Token: "a", Status: models.InvitationSent})
+	mustCreate(t, db, &models.JobInvitation{JobID: 2, UserID: 8, InvitedByID: 1, Token: "b", Status: models.InvitationSent})
+
+	var response struct {
+		Invitations []models.JobInvitation `json:"invitations"`
+	}
+	code := serve(t, NewTalentController(db, nil, nil).GetMyInvitations, http.MethodGet, "/me/invitations", 7, nil, nil, &response)
+	if code != http.StatusOK || len(response.Invitations) != 1 {
+		t.Fatalf("GetMyInvitations = %d with %d invitations, want 200 with 1", code, len(response.Invitations))
+	}
+	if got := response.Invitations[0]; got.Status != models.InvitationViewed || got.ViewedAt == nil {
+		t.Errorf("invitation returned as %s, viewed at %v; want it shown as viewed", got.Status, got.ViewedAt)
+	}
+
+	var other models.JobInvitation
+	db.Where("user_id = ?", 8).First(&other)
+	if other.Status != models.InvitationSent {
+		t.Errorf("another applicant's invitation marked %s", other.Status)
+	}
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/services"
+	"github.com/GolangAssignment/internal/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UsageController reports LLM spend and budgets.
+type UsageController struct {
+	DB    *gorm.DB
+	Meter *services.UsageMeter
+}
+
+// NewUsageController creates a new instance of UsageController.
+func NewUsageController(db *gorm.DB, meter *services.UsageMeter) *UsageController {
+	return &UsageController{DB: db, Meter: meter}
+}
+
+type spendRow struct {
+	Day          *time.Time `json:"day,omitempty"`
+	Organization *string    `json:"organization,omitempty"`
+	Provider     *string    `json:"provider,omitempty"`
+	Model        *string    `json:"model,omitempty"`
+	Calls        int        `json:"calls"`
+	PromptTokens int        `json:"prompt_tokens"`
+	OutputTokens int        `json:"output_tokens"`
+	CostUSD      float64    `json:"cost_usd"`
+}
+
+// spendGroupColumns maps the group_by query parameter to columns.
+var spendGroupColumns = map[string][]string{
+	"day":          {"day"},
+	"organization": {"organization"},
+	"model":        {"provider", "model"},
+}
+
+// GetSpend shows LLM calls, tokens and cost between from and to (inclusive
+// dates, default the last 30 days), grouped by any of day, organization and
+// model, along with the budgets and the parse cache hit count.
+func (uc *UsageController) GetSpend(c *gin.Context) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -29)
+	for param, value := range map[string]*time.Time{"from": &from, "to": &to} {
+		if s := c.Query(param); s != "" {
+			date, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				utils.RespondWithError(c, http.StatusBadRequest, fmt.Sprintf("Invalid %s date, use YYYY-MM-DD", param))
+				return
+			}
+			*value = date
+		}
+	}
+
+	var columns []string
+	groupBy := strings.Split(c.DefaultQuery("group_by", "day"), ",")
+	for _, group := range groupBy {
+		cols, ok := spendGroupColumns[strings.TrimSpace(group)]
+		if !ok {
+			utils.RespondWithError(c, http.StatusBadRequest, "group_by must be a list of day, organization, model")
+			return
+		}
+		columns = append(columns, cols...)
+	}
+
+	selects := append(append([]string{}, columns...),
+		"COUNT(*) AS calls",
+		"SUM(prompt_tokens) AS prompt_tokens",
+		"SUM(output_tokens) AS output_tokens",
+		"SUM(cost_usd) AS cost_usd")
+	var rows []spendRow
+	err := uc.DB.Model(&models.LLMUsage{}).
+		Select(strings.Join(selects, ", ")).
+		Where("day BETWEEN ? AND ?", from, to).
+		Group(strings.Join(columns, ", ")).
+		Order(strings.Join(columns, ", ")).
+		Scan(&rows).Error
+	if err != nil {
+		log.Printf("Error building LLM spend report: %v", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to build spend report")
+		return
+	}
+
+	var total spendRow
+	for _, row := range rows {
+		total.Calls += row.Calls
+		total.PromptTokens += row.PromptTokens
+		total.OutputTokens += row.OutputTokens
+		total.CostUSD += row.CostUSD
+	}
+
+	budgets, err := uc.Meter.Budgets()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to fetch budgets")
+		return
+	}
+
+	var cacheHits int64
+	uc.DB.Model(&models.ParseCacheEntry{}).Select("COALESCE(SUM(hits), 0)").Scan(&cacheHits)
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"group_by":   groupBy,
+		"spend":      rows,
+		"total":      total,
+		"budgets":    budgets,
+		"cache_hits": cacheHits,
+	})
+}
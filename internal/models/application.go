@@ -1,13 +1,109 @@
 package models
 
 import (
+	"regexp"
+
 	"gorm.io/gorm"
 )
 
+type ApplicationStage string
+
+const (
+	StageApplied   ApplicationStage = "Applied"
+	StageScreening ApplicationStage = "Screening"
+	StageInterview ApplicationStage = "Interview"
+	StageOffer     ApplicationStage = "Offer"
+	StageHired     ApplicationStage = "Hired"
+	StageRejected  ApplicationStage = "Rejected"
+)
+
+// IsValid reports whether s is one of the known application stages.
+func (s ApplicationStage) IsValid() bool {
+	switch s {
+	case StageApplied, StageScreening, StageInterview, StageOffer, StageHired, StageRejected:
+		return true
+	}
+	return false
+}
+
+// funnelStages are the stages an application moves through, in order.
+// Rejected can follow any of them.
+var funnelStages = []ApplicationStage{StageApplied, StageScreening, StageInterview, StageOffer, StageHired}
+
+// StagesFrom returns s and the funnel stages after it, or nil if s is not
+// a funnel stage.
+func StagesFrom(s ApplicationStage) []ApplicationStage {
+	for i, stage := range funnelStages {
+		if stage == s {
+			return funnelStages[i:]
+		}
+	}
+	return nil
+}
+
+// ApplicationSource is the channel an application came through. Besides the
+// built-in values it holds the source name of the TrackedLink used to apply,
+// e.g. "linkedin" or "indeed".
+type ApplicationSource string
+
+const (
+	SourceDirect     ApplicationSource = "direct"
+	SourceReferral   ApplicationSource = "referral"
+	SourceTalentPool ApplicationSource = "talent_pool"
+)
+
+var sourcePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,49}$`)
+
+// IsValid reports whether s can name a channel: up to 50 lowercase letters,
+// digits, dots, dashes and underscores.
+func (s ApplicationSource) IsValid() bool {
+	return sourcePattern.MatchString(string(s))
+}
+
+// IsReserved reports whether s is only set by the system, from a referral or
+// an invitation, and may not be claimed by links or the query string.
+func (s ApplicationSource) IsReserved() bool {
+	return s == SourceReferral || s == SourceTalentPool
+}
+
 type Application struct {
 	gorm.Model
-	ApplicantID uint `gorm:"not null"`
-	Applicant   User `gorm:"foreignKey:ApplicantID"`
-	JobID       uint `gorm:"not null"`
-	Job         Job  `gorm:"foreignKey:JobID"`
+	ApplicantID     uint             `gorm:"not null"`
+	Applicant       User             `gorm:"foreignKey:ApplicantID"`
+	JobID           uint             `gorm:"not null"`
+	Job             Job              `gorm:"foreignKey:JobID"`
+	Stage           ApplicationStage `gorm:"type:varchar(20);not null;default:'Applied'"`
+	RejectionReason string
+	ReviewerID      *uint
+	Reviewer        *User             `gorm:"foreignKey:ReviewerID"`
+	Tags            []ApplicationTag  `gorm:"foreignKey:ApplicationID"`
+	Source          ApplicationSource `gorm:"type:varchar(50);not null;default:'direct'"`
+	ReferrerID      *uint             `gorm:"index"`
+	TrackedLinkID   *uint             `gorm:"index"`
+	UTMSource       string
+	UTMMedium       string
+	UTMCampaign     string
+	UTMTerm         string
+	UTMContent      string
+	ReferrerURL     string
+	// ResumeVersionID snapshots the applicant's primary resume at apply time.
+	ResumeVersionID *uint
+	ResumeVersion   *ResumeVersion `gorm:"foreignKey:ResumeVersionID"`
+}
+
+// ApplicationTag is a free-form label a recruiter attaches to an application.
+type ApplicationTag struct {
+	gorm.Model
+	ApplicationID uint   `gorm:"uniqueIndex:idx_application_tag;not null"`
+	Tag           string `gorm:"uniqueIndex:idx_application_tag;not null"`
+}
+
+// ApplicationStageEvent records every stage transition so reports can tell
+// how far an application got even after it was later rejected.
+type ApplicationStageEvent struct {
+	gorm.Model
+	ApplicationID uint             `gorm:"index;not null"`
+	FromStage     ApplicationStage `gorm:"type:varchar(20)"`
+	ToStage       ApplicationStage `gorm:"type:varchar(20);not null"`
+	ChangedByID   uint             `gorm:"not null"`
 }
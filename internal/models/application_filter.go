@@ -0,0 +1,34 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ApplicationFilter is a saved recruiter query over the applications of a job.
+// Empty fields are ignored when the filter is applied.
+type ApplicationFilter struct {
+	gorm.Model
+	Name       string `gorm:"not null"`
+	OwnerID    uint   `gorm:"index;not null"`
+	JobID      *uint
+	Stage      ApplicationStage `gorm:"type:varchar(20)"`
+	Tag        string
+	ReviewerID *uint
+}
+
+// Apply narrows an application query according to the filter.
+func (f ApplicationFilter) Apply(db *gorm.DB) *gorm.DB {
+	if f.JobID != nil {
+		db = db.Where("applications.job_id = ?", *f.JobID)
+	}
+	if f.Stage != "" {
+		db = db.Where("applications.stage = ?", f.Stage)
+	}
+	if f.ReviewerID != nil {
+		db = db.Where("applications.reviewer_id = ?", *f.ReviewerID)
+	}
+	if f.Tag != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM application_tags t WHERE t.application_id = applications.id AND t.tag = ? AND t.deleted_at IS NULL)", f.Tag)
+	}
+	return db
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BulkAction string
+
+const (
+	BulkMoveStage   BulkAction = "move_stage"
+	BulkReject      BulkAction = "reject"
+	BulkTag         BulkAction = "tag"
+	BulkAssign      BulkAction = "assign"
+	BulkSendMessage BulkAction = "send_message"
+)
+
+type BulkOperationStatus string
+
+const (
+	BulkPending             BulkOperationStatus = "pending"
+	BulkRunning             BulkOperationStatus = "running"
+	BulkCompleted           BulkOperationStatus = "completed"
+	BulkCompletedWithErrors BulkOperationStatus = "completed_with_errors"
+	BulkFailed              BulkOperationStatus = "failed"
+)
+
+type BulkItemStatus string
+
+const (
+	BulkItemPending BulkItemStatus = "pending"
+	BulkItemOK      BulkItemStatus = "ok"
+	BulkItemError   BulkItemStatus = "error"
+)
+
+// BulkOperation tracks a recruiter action applied to many applications of a
+// job in the background. LockedAt is refreshed while a worker runs it, so an
+// operation left running by a stopped worker can be picked up again.
+type BulkOperation struct {
+	gorm.Model
+	JobID         uint                `gorm:"index;not null"`
+	RequestedByID uint                `gorm:"not null"`
+	Action        BulkAction          `gorm:"type:varchar(20);not null"`
+	Status        BulkOperationStatus `gorm:"type:varchar(30);not null;default:'pending'"`
+	Stage         ApplicationStage    `gorm:"type:varchar(20)"`
+	Reason        string
+	Tag           string
+	ReviewerID    *uint
+	TemplateID    *uint
+	Total         int
+	Succeeded     int
+	Failed        int
+	LockedAt      *time.Time
+	Items         []BulkOperationItem `gorm:"foreignKey:OperationID"`
+}
+
+// BulkOperationItem holds the per-application outcome of a BulkOperation.
+// Items are created pending with the operation and settled one by one.
+type BulkOperationItem struct {
+	gorm.Model
+	OperationID   uint           `gorm:"uniqueIndex:idx_bulk_item;not null"`
+	ApplicationID uint           `gorm:"uniqueIndex:idx_bulk_item;not null"`
+	Status        BulkItemStatus `gorm:"type:varchar(10);not null;default:'pending'"`
+	Error         string
+}
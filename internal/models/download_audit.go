@@ -0,0 +1,28 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type DownloadMethod string
+
+const (
+	DownloadDirect    DownloadMethod = "direct"
+	DownloadSignedURL DownloadMethod = "signed_url"
+)
+
+// DownloadAudit records one download of a resume file. For signed URLs,
+// UserID is the user who issued the link.
+type DownloadAudit struct {
+	gorm.Model
+	ResumeVersionID uint           `gorm:"index;not null"`
+	ApplicantID     uint           `gorm:"index;not null"`
+	ApplicationID   *uint          `gorm:"index"`
+	UserID          uint           `gorm:"index;not null"`
+	Method          DownloadMethod `gorm:"type:varchar(20);not null"`
+	IPAddress       string
+	UserAgent       string
+	// Redacted is set when the blind hiring rendition was served instead
+	// of the file.
+	Redacted bool `gorm:"not null;default:false"`
+}
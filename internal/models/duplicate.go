@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DuplicateStatus string
+
+const (
+	DuplicatePending   DuplicateStatus = "pending"
+	DuplicateMerged    DuplicateStatus = "merged"
+	DuplicateDismissed DuplicateStatus = "dismissed"
+)
+
+// DuplicateCandidate is a pair of applicants the detector believes to be the
+// same person. UserAID is always the lower of the two IDs.
+type DuplicateCandidate struct {
+	gorm.Model
+	UserAID      uint    `gorm:"uniqueIndex:idx_duplicate_pair;not null"`
+	UserA        User    `gorm:"foreignKey:UserAID"`
+	UserBID      uint    `gorm:"uniqueIndex:idx_duplicate_pair;not null"`
+	UserB        User    `gorm:"foreignKey:UserBID"`
+	Score        float64 `gorm:"not null"`
+	Reasons      string
+	Status       DuplicateStatus `gorm:"type:varchar(10);not null;default:'pending'"`
+	ReviewedByID *uint
+}
+
+// CandidateMerge records a merge of MergedID into SurvivorID. Snapshot holds
+// the JSON encoded list of rows that were moved so the merge can be undone
+// until UndoDeadline.
+type CandidateMerge struct {
+	gorm.Model
+	SurvivorID   uint   `gorm:"index;not null"`
+	MergedID     uint   `gorm:"index;not null"`
+	DuplicateID  *uint  `gorm:"index"`
+	MergedByID   uint   `gorm:"not null"`
+	Snapshot     string `gorm:"type:text;not null"`
+	UndoDeadline time.Time
+	UndoneAt     *time.Time
+}
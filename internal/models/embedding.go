@@ -0,0 +1,24 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Kinds of records that have embeddings.
+const (
+	EmbeddingOwnerJob       = "job"
+	EmbeddingOwnerCandidate = "candidate"
+)
+
+// Embedding is the vector of a job's or a candidate's text. Candidates are
+// keyed by user ID. ContentHash covers the text and the model, so a vector
+// is recomputed when either changes.
+type Embedding struct {
+	gorm.Model
+	OwnerType   string `gorm:"type:varchar(20);uniqueIndex:idx_embedding_owner;not null"`
+	OwnerID     uint   `gorm:"uniqueIndex:idx_embedding_owner;not null"`
+	ModelName   string `gorm:"column:model;index;not null"`
+	ContentHash string `gorm:"not null"`
+	// Vector holds little-endian float32 values.
+	Vector []byte `gorm:"not null"`
+}
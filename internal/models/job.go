@@ -6,6 +6,37 @@ import (
 	"gorm.io/gorm"
 )
 
+// EducationLevel is the highest degree a job asks for or a candidate holds.
+type EducationLevel string
+
+const (
+	EducationNone      EducationLevel = ""
+	EducationDiploma   EducationLevel = "diploma"
+	EducationBachelor  EducationLevel = "bachelor"
+	EducationMaster    EducationLevel = "master"
+	EducationDoctorate EducationLevel = "doctorate"
+)
+
+// Rank orders education levels; unknown levels rank lowest.
+func (l EducationLevel) Rank() int {
+	switch l {
+	case EducationDiploma:
+		return 1
+	case EducationBachelor:
+		return 2
+	case EducationMaster:
+		return 3
+	case EducationDoctorate:
+		return 4
+	}
+	return 0
+}
+
+// IsValid reports whether l is empty or one of the known education levels.
+func (l EducationLevel) IsValid() bool {
+	return l == EducationNone || l.Rank() > 0
+}
+
 type Job struct {
 	gorm.Model
 	Title             string        `gorm:"not null"`
@@ -16,4 +47,26 @@ type Job struct {
 	PostedByID        uint          `gorm:"not null"`
 	PostedBy          User          `gorm:"foreignKey:PostedByID"`
 	Applications      []Application `gorm:"foreignKey:JobID"`
+	// Criteria candidates are scored against. A zero value means the job
+	// does not ask for it.
+	MinExperienceYears int
+	EducationLevel     EducationLevel `gorm:"type:varchar(20)"`
+	Skills             []JobSkill     `gorm:"foreignKey:JobID"`
+	// BlindHiring hides who applicants are from reviewers without the
+	// reveal identity permission until they reach RevealStage. An empty
+	// RevealStage keeps them hidden throughout.
+	BlindHiring bool             `gorm:"not null;default:false"`
+	RevealStage ApplicationStage `gorm:"type:varchar(20)"`
+	// AlertsPending is set until the job alerts for a new job are raised,
+	// so alerts interrupted by a restart are raised after it.
+	AlertsPending bool `gorm:"not null;default:false;index"`
+}
+
+// JobSkill is a skill a job requires, or merely prefers when Required is
+// false.
+type JobSkill struct {
+	gorm.Model
+	JobID    uint   `gorm:"uniqueIndex:idx_job_skill;not null"`
+	Name     string `gorm:"uniqueIndex:idx_job_skill;not null"`
+	Required bool   `gorm:"not null;default:false"`
 }
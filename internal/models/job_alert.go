@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AlertFrequency is how often an applicant hears about new matching jobs.
+type AlertFrequency string
+
+const (
+	AlertInstant AlertFrequency = "instant"
+	AlertDaily   AlertFrequency = "daily"
+	AlertWeekly  AlertFrequency = "weekly"
+)
+
+// IsValid reports whether f is one of the known alert frequencies.
+func (f AlertFrequency) IsValid() bool {
+	switch f {
+	case AlertInstant, AlertDaily, AlertWeekly:
+		return true
+	}
+	return false
+}
+
+// Reasons a job alert was raised.
+const (
+	AlertReasonSavedSearch    = "saved_search"
+	AlertReasonRecommendation = "recommendation"
+)
+
+// SavedSearch is a job search an applicant wants to be alerted about.
+// Skills are comma separated and must all be listed by a job.
+type SavedSearch struct {
+	gorm.Model
+	UserID    uint   `gorm:"index;not null"`
+	Name      string `gorm:"not null"`
+	Keywords  string
+	Company   string
+	Skills    string
+	Frequency AlertFrequency `gorm:"type:varchar(10);not null;default:'daily'"`
+}
+
+// AlertPreference holds an applicant's settings for recommendation alerts,
+// raised for new jobs whose text is similar enough to their profile.
+type AlertPreference struct {
+	gorm.Model
+	UserID          uint           `gorm:"uniqueIndex;not null"`
+	Recommendations bool           `gorm:"not null;default:false"`
+	MinSimilarity   float64        `gorm:"not null"`
+	Frequency       AlertFrequency `gorm:"type:varchar(10);not null;default:'daily'"`
+}
+
+// JobAlert is one new job to tell an applicant about. An applicant is
+// alerted about a job at most once. Alerts are delivered as a Message,
+// instantly or in a digest; MessageID is set once delivered.
+type JobAlert struct {
+	gorm.Model
+	UserID        uint           `gorm:"uniqueIndex:idx_job_alert;not null"`
+	JobID         uint           `gorm:"uniqueIndex:idx_job_alert;not null"`
+	Job           Job            `gorm:"foreignKey:JobID"`
+	Reason        string         `gorm:"type:varchar(20);not null"`
+	SavedSearchID *uint          `gorm:"index"`
+	SavedSearch   *SavedSearch   `gorm:"foreignKey:SavedSearchID"`
+	Similarity    float64        `gorm:"not null;default:0"`
+	Frequency     AlertFrequency `gorm:"type:varchar(10);not null"`
+	MessageID     *uint          `gorm:"index"`
+	SentAt        *time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LLMUsage records one language model call with its tokens and cost. Calls
+// are billed to an organization, the company a resume was submitted to, and
+// to a UTC day. Self-service uploads have no organization.
+type LLMUsage struct {
+	gorm.Model
+	Day             time.Time `gorm:"type:date;index;not null"`
+	Organization    string    `gorm:"index"`
+	Purpose         string    `gorm:"type:varchar(30)"`
+	Provider        string    `gorm:"not null"`
+	ModelName       string    `gorm:"column:model;not null"`
+	PromptVersion   string
+	ResumeVersionID *uint `gorm:"index"`
+	PromptTokens    int
+	OutputTokens    int
+	CostUSD         float64
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MessageTemplate is a reusable message body rendered with text/template.
+// Available fields: {{.CandidateName}}, {{.JobTitle}}, {{.CompanyName}}.
+type MessageTemplate struct {
+	gorm.Model
+	Name        string `gorm:"uniqueIndex;not null"`
+	Subject     string `gorm:"not null"`
+	Body        string `gorm:"not null"`
+	CreatedByID uint   `gorm:"not null"`
+}
+
+// SystemSenderID is the sender of messages the system sends on its own,
+// such as job alerts.
+const SystemSenderID = 0
+
+// Message is an outbound message to a user. Rows act as an outbox; SentAt is
+// set once a delivery channel has picked the message up.
+type Message struct {
+	gorm.Model
+	RecipientID   uint  `gorm:"index;not null"`
+	SenderID      uint  `gorm:"not null"`
+	ApplicationID *uint `gorm:"index"`
+	Subject       string
+	Body          string `gorm:"not null"`
+	SentAt        *time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ParseCacheEntry is a stored LLM parse result. It is reused when a file
+// with the same content hash is parsed with the same prompt version.
+type ParseCacheEntry struct {
+	gorm.Model
+	ContentHash   string `gorm:"uniqueIndex:idx_parse_cache_key;not null"`
+	PromptVersion string `gorm:"uniqueIndex:idx_parse_cache_key;not null"`
+	Provider      string
+	ModelName     string `gorm:"column:model"`
+	ParsedData    string `gorm:"type:text;not null"`
+	Hits          int    `gorm:"not null;default:0"`
+}
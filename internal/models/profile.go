@@ -8,10 +8,52 @@ type Profile struct {
 	gorm.Model
 	UserID         uint `gorm:"uniqueIndex;not null"`
 	ResumeFilePath string
+	ResumeHash     string `gorm:"index"`
 	Skills         string
 	Education      string
 	Experience     string
 	Name           string
 	Email          string
 	Phone          string
+	Educations     []EducationEntry  `gorm:"foreignKey:ProfileID"`
+	Experiences    []ExperienceEntry `gorm:"foreignKey:ProfileID"`
+	ProfileSkills  []ProfileSkill    `gorm:"foreignKey:ProfileID"`
+	// Links are entered by the applicant; the parser does not fill them.
+	GitHubURL    string `gorm:"column:github_url"`
+	LinkedInURL  string `gorm:"column:linkedin_url"`
+	PortfolioURL string
+	// LegacyMigrated is set once the flat fields of a profile written by an
+	// earlier version were moved into the structured records.
+	LegacyMigrated bool `gorm:"not null;default:false"`
+}
+
+// FieldSource is where the current value of a profile field came from.
+type FieldSource string
+
+const (
+	FieldSourceParsed FieldSource = "parsed"
+	FieldSourceUser   FieldSource = "user"
+)
+
+// Profile fields whose source is tracked. The education, experience and
+// skill records are tracked as whole sections.
+const (
+	ProfileFieldName         = "name"
+	ProfileFieldEmail        = "email"
+	ProfileFieldPhone        = "phone"
+	ProfileFieldGitHubURL    = "github_url"
+	ProfileFieldLinkedInURL  = "linkedin_url"
+	ProfileFieldPortfolioURL = "portfolio_url"
+	ProfileFieldEducations   = "educations"
+	ProfileFieldExperiences  = "experiences"
+	ProfileFieldSkills       = "skills"
+)
+
+// ProfileFieldSource records the source of one profile field. Fields the
+// applicant edited are left alone when a resume is parsed again.
+type ProfileFieldSource struct {
+	gorm.Model
+	ProfileID uint        `gorm:"uniqueIndex:idx_profile_field;not null"`
+	Field     string      `gorm:"uniqueIndex:idx_profile_field;type:varchar(20);not null"`
+	Source    FieldSource `gorm:"type:varchar(10);not null"`
 }
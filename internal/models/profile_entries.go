@@ -0,0 +1,38 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// EducationEntry is one degree or course of study on a profile. Dates are
+// kept as "YYYY" or "YYYY-MM" since resumes rarely give exact days.
+type EducationEntry struct {
+	gorm.Model
+	ProfileID   uint `gorm:"index;not null"`
+	Institution string
+	Degree      string
+	Field       string
+	StartDate   string
+	EndDate     string
+}
+
+// ExperienceEntry is one position on a profile. An empty EndDate with a
+// StartDate set means the position is current.
+type ExperienceEntry struct {
+	gorm.Model
+	ProfileID   uint `gorm:"index;not null"`
+	Company     string
+	Title       string
+	StartDate   string
+	EndDate     string
+	Description string `gorm:"type:text"`
+}
+
+// ProfileSkill is one skill on a profile with optional level and years of use.
+type ProfileSkill struct {
+	gorm.Model
+	ProfileID uint   `gorm:"uniqueIndex:idx_profile_skill;not null"`
+	Name      string `gorm:"uniqueIndex:idx_profile_skill;not null"`
+	Level     string
+	Years     int
+}
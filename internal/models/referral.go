@@ -0,0 +1,38 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+type ReferralMethod string
+
+const (
+	ReferralUpload ReferralMethod = "upload"
+	ReferralInvite ReferralMethod = "invite"
+)
+
+type ReferralStatus string
+
+const (
+	ReferralPending ReferralStatus = "pending"
+	ReferralApplied ReferralStatus = "applied"
+)
+
+// Referral is an employee's recommendation of a candidate for a job. Upload
+// referrals create the application immediately; invite referrals wait for the
+// candidate to apply through the referral link.
+type Referral struct {
+	gorm.Model
+	JobID          uint           `gorm:"index;not null"`
+	Job            Job            `gorm:"foreignKey:JobID"`
+	ReferrerID     uint           `gorm:"index;not null"`
+	Referrer       User           `gorm:"foreignKey:ReferrerID"`
+	CandidateName  string         `gorm:"not null"`
+	CandidateEmail string         `gorm:"not null"`
+	Method         ReferralMethod `gorm:"type:varchar(10);not null"`
+	Token          string         `gorm:"uniqueIndex;not null"`
+	Status         ReferralStatus `gorm:"type:varchar(10);not null;default:'pending'"`
+	CandidateID    *uint
+	ApplicationID  *uint
+	Application    *Application `gorm:"foreignKey:ApplicationID"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ResumeJobStatus string
+
+const (
+	ResumeJobQueued     ResumeJobStatus = "queued"
+	ResumeJobProcessing ResumeJobStatus = "processing"
+	ResumeJobCompleted  ResumeJobStatus = "completed"
+	ResumeJobDead       ResumeJobStatus = "dead"
+)
+
+// ResumeJob is a queued request to extract and parse an uploaded resume.
+// Failed jobs are re-queued with a later NextRunAt until MaxAttempts is
+// reached, after which they are moved to the dead state.
+type ResumeJob struct {
+	gorm.Model
+	UserID          uint            `gorm:"index;not null"`
+	ResumeVersionID uint            `gorm:"index"`
+	FilePath        string          `gorm:"not null"`
+	Status          ResumeJobStatus `gorm:"type:varchar(20);index;not null;default:'queued'"`
+	Progress        string          `gorm:"type:varchar(20)"`
+	Attempts        int             `gorm:"not null;default:0"`
+	MaxAttempts     int             `gorm:"not null"`
+	NextRunAt       time.Time       `gorm:"index;not null"`
+	LockedAt        *time.Time
+	LastError       string
+	CompletedAt     *time.Time
+
+	// Organization is billed for any LLM calls the job makes.
+	Organization string
+}
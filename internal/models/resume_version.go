@@ -0,0 +1,33 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ResumeVersion is one uploaded resume file of an applicant. Every upload is
+// kept; the primary version is the one reflected in the applicant's Profile
+// and attached to new applications.
+type ResumeVersion struct {
+	gorm.Model
+	UserID   uint   `gorm:"index;not null"`
+	FileName string `gorm:"not null"`
+	// FilePath is the BlobStore key of the uploaded file. Rows written before
+	// blob storage hold a local path until cmd/migrateblobs moves them.
+	FilePath      string `gorm:"not null"`
+	ContentType   string
+	Size          int64
+	ContentHash   string `gorm:"index"`
+	ExtractedText string `gorm:"type:text"`
+	// Document is the extracted text as JSON blocks with roles (headings,
+	// paragraphs, list items) in reading order.
+	Document   string `gorm:"type:text"`
+	ParsedData string `gorm:"type:text"`
+	ParserName string
+	// PromptVersion is the prompt template an LLM parser used, e.g.
+	// resume_extraction@v1; empty for other parsers.
+	PromptVersion string
+	IsPrimary     bool `gorm:"not null;default:false"`
+	// Referred is set when someone else, such as a referring employee,
+	// uploaded the resume for the applicant.
+	Referred bool `gorm:"not null;default:false"`
+}
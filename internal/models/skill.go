@@ -0,0 +1,28 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Skill is a canonical skill in the managed taxonomy. Parsed and entered
+// skill names are normalized to Name through its synonyms.
+type Skill struct {
+	gorm.Model
+	Name     string `gorm:"uniqueIndex;not null"`
+	Category string `gorm:"index"`
+	ParentID *uint  `gorm:"index"`
+	Parent   *Skill `gorm:"foreignKey:ParentID"`
+	// Children are the more specific skills under this one, e.g. React
+	// under JavaScript.
+	Children []Skill        `gorm:"foreignKey:ParentID"`
+	Synonyms []SkillSynonym `gorm:"foreignKey:SkillID"`
+}
+
+// SkillSynonym is another name for a skill, e.g. "k8s" for Kubernetes.
+// Names are unique across the taxonomy so every synonym resolves to one
+// skill.
+type SkillSynonym struct {
+	gorm.Model
+	SkillID uint   `gorm:"index;not null"`
+	Name    string `gorm:"uniqueIndex;not null"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TalentPool is a named group of applicants kept independently of any job.
+type TalentPool struct {
+	gorm.Model
+	Name        string `gorm:"uniqueIndex;not null"`
+	Description string
+	CreatedByID uint               `gorm:"not null"`
+	Members     []TalentPoolMember `gorm:"foreignKey:PoolID"`
+}
+
+type TalentPoolMember struct {
+	gorm.Model
+	PoolID    uint `gorm:"uniqueIndex:idx_pool_member;not null"`
+	UserID    uint `gorm:"uniqueIndex:idx_pool_member;not null"`
+	User      User `gorm:"foreignKey:UserID"`
+	AddedByID uint `gorm:"not null"`
+}
+
+// UserTag is a free-form label on an applicant, independent of applications.
+type UserTag struct {
+	gorm.Model
+	UserID uint   `gorm:"uniqueIndex:idx_user_tag;not null"`
+	Tag    string `gorm:"uniqueIndex:idx_user_tag;not null"`
+}
+
+type InvitationStatus string
+
+const (
+	InvitationSent    InvitationStatus = "sent"
+	InvitationViewed  InvitationStatus = "viewed"
+	InvitationApplied InvitationStatus = "applied"
+)
+
+// JobInvitation records an invite for a pooled candidate to apply to a job,
+// so re-engagement can be measured.
+type JobInvitation struct {
+	gorm.Model
+	JobID         uint             `gorm:"uniqueIndex:idx_job_invitation;not null"`
+	UserID        uint             `gorm:"uniqueIndex:idx_job_invitation;not null"`
+	PoolID        *uint            `gorm:"index"`
+	InvitedByID   uint             `gorm:"not null"`
+	Token         string           `gorm:"uniqueIndex;not null"`
+	Status        InvitationStatus `gorm:"type:varchar(10);not null;default:'sent'"`
+	ViewedAt      *time.Time
+	AppliedAt     *time.Time
+	ApplicationID *uint
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// TrackedLink is an apply link handed out to a job board or campaign. Its
+// code is passed to ApplyJob as the "src" query parameter so the resulting
+// application inherits the link's source and UTM parameters.
+type TrackedLink struct {
+	gorm.Model
+	JobID       uint              `gorm:"index;not null"`
+	Code        string            `gorm:"uniqueIndex;not null"`
+	Source      ApplicationSource `gorm:"type:varchar(50);not null"`
+	UTMSource   string
+	UTMMedium   string
+	UTMCampaign string
+	UTMTerm     string
+	UTMContent  string
+	CreatedByID uint `gorm:"not null"`
+}
@@ -9,6 +9,7 @@ type UserType string
 const (
 	Admin     UserType = "Admin"
 	Applicant UserType = "Applicant"
+	Employee  UserType = "Employee"
 )
 
 type User struct {
@@ -22,4 +23,32 @@ type User struct {
 	Profile         Profile       `gorm:"foreignKey:UserID"`
 	JobsPosted      []Job         `gorm:"foreignKey:PostedByID"`
 	Applications    []Application `gorm:"foreignKey:ApplicantID"`
+	Tags            []UserTag     `gorm:"foreignKey:UserID"`
+	// Unclaimed accounts were created for a referred candidate, whose
+	// password nobody knows. Signing up with the same email claims them.
+	Unclaimed bool `gorm:"not null;default:false"`
+}
+
+// Permission is a right granted to individual users on top of their type.
+type Permission string
+
+const (
+	// PermissionRevealIdentity lets a reviewer see who candidates to blind
+	// hiring jobs are.
+	PermissionRevealIdentity Permission = "reveal_identity"
+)
+
+// IsValid reports whether p is one of the known permissions.
+func (p Permission) IsValid() bool {
+	return p == PermissionRevealIdentity
+}
+
+// UserPermission grants a permission to a user. GrantedByID is zero for
+// grants made from configuration.
+type UserPermission struct {
+	gorm.Model
+	UserID      uint       `gorm:"uniqueIndex:idx_user_permission;not null"`
+	User        User       `gorm:"foreignKey:UserID"`
+	Permission  Permission `gorm:"uniqueIndex:idx_user_permission;type:varchar(40);not null"`
+	GrantedByID uint       `gorm:"not null"`
 }
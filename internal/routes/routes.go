@@ -4,20 +4,37 @@ import (
 	"github.com/GolangAssignment/internal/config"
 	"github.com/GolangAssignment/internal/controllers"
 	"github.com/GolangAssignment/internal/middlewares"
+	"github.com/GolangAssignment/internal/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg config.Config) {
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg config.Config, store services.BlobStore, pipeline *services.ResumePipeline, meter *services.UsageMeter, embeddings *services.EmbeddingService, skills *services.SkillTaxonomy, alerts *services.JobAlertService, bulk *services.BulkService, identity *services.IdentityGuard, signer *services.URLSigner) {
+	uploadValidator := services.NewUploadValidator(cfg.UploadMaxBytes, cfg.UploadMaxPages, services.NewMalwareScanner(cfg.ClamAVAddress, cfg.ClamAVTimeout))
+
 	// Initialize controllers with dependencies
 	authController := controllers.NewAuthController(db, cfg)
-	adminController := controllers.NewAdminController(db)
-	jobController := controllers.NewJobController(db)
-	applicantController := controllers.NewApplicantController(db, cfg)
+	adminController := controllers.NewAdminController(db, skills, alerts, identity)
+	jobController := controllers.NewJobController(db, skills)
+	applicantController := controllers.NewApplicantController(db, cfg, store, uploadValidator, pipeline)
+	bulkController := controllers.NewBulkController(db, bulk)
+	talentController := controllers.NewTalentController(db, skills, identity)
+	referralController := controllers.NewReferralController(db, store, uploadValidator, pipeline)
+	reportController := controllers.NewReportController(db)
+	downloadController := controllers.NewDownloadController(db, store, signer, identity)
+	usageController := controllers.NewUsageController(db, meter)
+	matchController := controllers.NewMatchController(db, services.NewCandidateMatcher(db, skills), identity)
+	similarityController := controllers.NewSimilarityController(db, embeddings, identity)
+	skillController := controllers.NewSkillController(db, skills)
+	alertController := controllers.NewAlertController(db, skills)
+	permissionController := controllers.NewPermissionController(db, identity)
+	profileController := controllers.NewProfileController(db, skills)
+	duplicateController := controllers.NewDuplicateController(db, services.NewDuplicateDetector(db), services.NewMergeService(db, cfg.MergeUndoWindow), identity)
 
 	// Public routes
 	router.POST("/signup", authController.SignUp)
 	router.POST("/login", authController.Login)
+	router.GET("/files/resumes/:version_id", downloadController.DownloadSigned)
 
 	// Protected routes
 	protected := router.Group("/")
@@ -25,16 +42,96 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg config.Config) {
 
 	// Applicant-specific routes
 	protected.POST("/uploadResume", middlewares.RoleMiddleware("Applicant"), applicantController.UploadResume)
+	protected.GET("/me/resume/status", middlewares.RoleMiddleware("Applicant"), applicantController.GetResumeStatus)
+	protected.GET("/me/resumes", middlewares.RoleMiddleware("Applicant"), applicantController.GetResumeVersions)
+	protected.POST("/me/resumes/:version_id/primary", middlewares.RoleMiddleware("Applicant"), applicantController.SetPrimaryResume)
+	protected.GET("/me/profile", middlewares.RoleMiddleware("Applicant"), profileController.GetProfile)
+	protected.PATCH("/me/profile", middlewares.RoleMiddleware("Applicant"), profileController.UpdateProfile)
 	protected.GET("/jobs", jobController.GetJobs)
 	protected.GET("/jobs/apply", middlewares.RoleMiddleware("Applicant"), jobController.ApplyJob)
+	protected.GET("/jobs/recommended", middlewares.RoleMiddleware("Applicant"), similarityController.GetRecommendedJobs)
+	protected.GET("/me/saved-searches", middlewares.RoleMiddleware("Applicant"), alertController.GetSavedSearches)
+	protected.POST("/me/saved-searches", middlewares.RoleMiddleware("Applicant"), alertController.CreateSavedSearch)
+	protected.PATCH("/me/saved-searches/:search_id", middlewares.RoleMiddleware("Applicant"), alertController.UpdateSavedSearch)
+	protected.DELETE("/me/saved-searches/:search_id", middlewares.RoleMiddleware("Applicant"), alertController.DeleteSavedSearch)
+	protected.GET("/me/alerts", middlewares.RoleMiddleware("Applicant"), alertController.GetAlerts)
+	protected.GET("/me/alerts/preferences", middlewares.RoleMiddleware("Applicant"), alertController.GetAlertPreference)
+	protected.PUT("/me/alerts/preferences", middlewares.RoleMiddleware("Applicant"), alertController.UpdateAlertPreference)
+	protected.GET("/invitations", middlewares.RoleMiddleware("Applicant"), talentController.GetMyInvitations)
+	protected.GET("/skills/normalize", skillController.NormalizeSkills)
+
+	// Resume downloads, for admins and the resume's owner
+	protected.GET("/resumes/:version_id/download", downloadController.DownloadResume)
+	protected.POST("/resumes/:version_id/download-url", downloadController.CreateDownloadURL)
+
+	// Employee-specific routes
+	protected.POST("/referrals", middlewares.RoleMiddleware("Employee"), referralController.CreateReferral)
+	protected.GET("/referrals", middlewares.RoleMiddleware("Employee"), referralController.GetMyReferrals)
 
 	// Admin-specific routes
 	admin := protected.Group("/admin")
 	admin.Use(middlewares.RoleMiddleware("Admin"))
 	{
 		admin.POST("/job", adminController.CreateJob)
+		admin.POST("/employees", authController.CreateEmployee)
 		admin.GET("/job/:job_id", adminController.GetJob)
+		admin.PUT("/job/:job_id/requirements", adminController.UpdateJobRequirements)
+		admin.PUT("/job/:job_id/blind-hiring", adminController.UpdateBlindHiring)
+		admin.GET("/job/:job_id/ranking", matchController.GetRankedApplicants)
+		admin.GET("/job/:job_id/suggestions", matchController.GetSuggestions)
+		admin.GET("/job/:job_id/similar", similarityController.GetSimilarJobs)
 		admin.GET("/applicants", adminController.GetAllApplicants)
 		admin.GET("/applicant/:applicant_id", adminController.GetApplicantData)
+		admin.GET("/applicant/:applicant_id/similar", similarityController.GetSimilarCandidates)
+		admin.GET("/applications/:application_id", adminController.GetApplication)
+		admin.GET("/applications/:application_id/resume", downloadController.DownloadApplicationResume)
+		admin.GET("/downloads", downloadController.GetDownloadAudits)
+		admin.POST("/job/:job_id/links", adminController.CreateTrackedLink)
+		admin.GET("/job/:job_id/links", adminController.GetTrackedLinks)
+
+		// Bulk actions on applications
+		admin.POST("/job/:job_id/bulk", bulkController.StartBulkAction)
+		admin.GET("/bulk/:operation_id", bulkController.GetBulkOperation)
+		admin.POST("/filters", bulkController.CreateFilter)
+		admin.GET("/filters", bulkController.GetFilters)
+		admin.POST("/message-templates", bulkController.CreateMessageTemplate)
+		admin.GET("/message-templates", bulkController.GetMessageTemplates)
+
+		// Talent pools and applicant tags
+		admin.POST("/talent-pools", talentController.CreatePool)
+		admin.GET("/talent-pools", talentController.GetPools)
+		admin.GET("/talent-pools/:pool_id", talentController.GetPool)
+		admin.POST("/talent-pools/:pool_id/members", talentController.AddPoolMembers)
+		admin.DELETE("/talent-pools/:pool_id/members/:user_id", talentController.RemovePoolMember)
+		admin.POST("/talent-pools/:pool_id/invite", talentController.InvitePool)
+		admin.POST("/applicant/:applicant_id/tags", talentController.AddApplicantTags)
+		admin.DELETE("/applicant/:applicant_id/tags/:tag", talentController.RemoveApplicantTag)
+		admin.GET("/talent/search", talentController.SearchTalent)
+		admin.GET("/job/:job_id/invitations", talentController.GetInvitationStats)
+
+		// Duplicate candidates
+		admin.GET("/duplicates", duplicateController.GetDuplicates)
+		admin.POST("/duplicates/scan", duplicateController.ScanDuplicates)
+		admin.POST("/duplicates/:duplicate_id/dismiss", duplicateController.DismissDuplicate)
+		admin.POST("/duplicates/:duplicate_id/merge", duplicateController.MergeDuplicate)
+		admin.POST("/merges/:merge_id/undo", duplicateController.UndoMerge)
+
+		// Skills taxonomy
+		admin.GET("/skills", skillController.GetSkills)
+		admin.POST("/skills", skillController.CreateSkill)
+		admin.POST("/skills/import", skillController.ImportSkills)
+		admin.GET("/skills/:skill_id", skillController.GetSkill)
+		admin.PATCH("/skills/:skill_id", skillController.UpdateSkill)
+		admin.DELETE("/skills/:skill_id", skillController.DeleteSkill)
+
+		// Permissions beyond the user type
+		admin.GET("/permissions", permissionController.GetPermissions)
+		admin.POST("/permissions", permissionController.GrantPermission)
+		admin.DELETE("/permissions/:permission_id", permissionController.RevokePermission)
+
+		// Reports
+		admin.GET("/reports/referrals", referralController.GetReferralReport)
+		admin.GET("/reports/sources", reportController.GetSourceReport)
+		admin.GET("/reports/llm-spend", usageController.GetSpend)
 	}
 }
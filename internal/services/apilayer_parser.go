@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,16 +12,22 @@ import (
 	"path/filepath"
 )
 
-type ResumeData struct {
-	Education  string `json:"education"`
-	Email      string `json:"email"`
-	Experience string `json:"experience"`
-	Name       string `json:"name"`
-	Phone      string `json:"phone"`
-	Skills     string `json:"skills"`
+// APILayerParser sends the resume file to the API Layer resume parser.
+type APILayerParser struct {
+	APIKey string
+	URL    string
+	Client *http.Client
 }
 
-func ProcessResume(apiKey, filePath string) (*ResumeData, error) {
+func NewAPILayerParser(apiKey, url string) *APILayerParser {
+	return &APILayerParser{APIKey: apiKey, URL: url, Client: &http.Client{}}
+}
+
+func (p *APILayerParser) Name() string {
+	return "apilayer"
+}
+
+func (p *APILayerParser) Parse(ctx context.Context, filePath, _ string) (*ResumeData, error) {
 	// Open the file
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -29,7 +36,6 @@ func ProcessResume(apiKey, filePath string) (*ResumeData, error) {
 	defer file.Close()
 
 	// Prepare the request
-	url := "http://localhost:3000/resume_parser/upload"
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
 
@@ -43,16 +49,15 @@ func ProcessResume(apiKey, filePath string) (*ResumeData, error) {
 	}
 	writer.Close()
 
-	req, err := http.NewRequest("POST", url, &requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", p.URL, &requestBody)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("apikey", apiKey)
+	req.Header.Set("apikey", p.APIKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := p.Client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -94,39 +99,24 @@ func ProcessResume(apiKey, filePath string) (*ResumeData, error) {
 		Name:  apiResponse.Name,
 		Email: apiResponse.Email,
 		Phone: apiResponse.Phone,
-		Skills: func() string {
-			return stringifySlice(apiResponse.Skills)
-		}(),
-		Education:  stringifyEducation(apiResponse.Education),
-		Experience: stringifyExperience(apiResponse.Experience),
 	}
-
-	return resumeData, nil
-}
-
-func stringifySlice(slice []string) string {
-	return fmt.Sprintf("%v", slice)
-}
-
-func stringifyEducation(education []struct {
-	Name string `json:"name"`
-	URL  string `json:"url"`
-}) string {
-	var names []string
-	for _, edu := range education {
-		names = append(names, edu.Name)
+	for _, edu := range apiResponse.Education {
+		resumeData.EducationEntries = append(resumeData.EducationEntries, EducationRecord{Institution: edu.Name})
 	}
-	return fmt.Sprintf("%v", names)
-}
-
-func stringifyExperience(experience []struct {
-	Dates []string `json:"dates"`
-	Name  string   `json:"name"`
-	URL   string   `json:"url"`
-}) string {
-	var experiences []string
-	for _, exp := range experience {
-		experiences = append(experiences, exp.Name+" ("+fmt.Sprintf("%v", exp.Dates)+")")
+	for _, exp := range apiResponse.Experience {
+		record := ExperienceRecord{Company: exp.Name}
+		if len(exp.Dates) > 0 {
+			record.StartDate = NormalizeResumeDate(exp.Dates[0])
+		}
+		if len(exp.Dates) > 1 {
+			record.EndDate = NormalizeResumeDate(exp.Dates[len(exp.Dates)-1])
+		}
+		resumeData.ExperienceEntries = append(resumeData.ExperienceEntries, record)
 	}
-	return fmt.Sprintf("%v", experiences)
+	for _, skill := range apiResponse.Skills {
+		resumeData.SkillEntries = append(resumeData.SkillEntries, SkillRecord{Name: skill})
+	}
+	resumeData.Summarize()
+
+	return resumeData, nil
 }
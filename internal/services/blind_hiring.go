@@ -0,0 +1,429 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Placeholders written over redacted text.
+const (
+	redactedName    = "[name]"
+	redactedEmail   = "[email]"
+	redactedPhone   = "[phone]"
+	redactedAddress = "[address]"
+	redactedSchool  = "[school]"
+	redactedLink    = "[link]"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b(?:linkedin\.com|github\.com)/\S+`)
+
+// IdentityGuard decides who may see the identity of candidates to blind
+// hiring jobs. A reviewer sees a candidate if they hold the reveal identity
+// permission, or if the candidate's application reached the job's reveal
+// stage, even if it was rejected afterwards.
+type IdentityGuard struct {
+	DB *gorm.DB
+}
+
+// A merged candidate's permissions move to the survivor, who keeps the
+// ones they already hold.
+func init() {
+	registerMergeTable(mergeTable{name: "permissions", model: &models.UserPermission{}, column: "user_id", unique: true, key: "permission"})
+}
+
+func NewIdentityGuard(db *gorm.DB) *IdentityGuard {
+	return &IdentityGuard{DB: db}
+}
+
+// Seed grants the reveal identity permission to the admins with the given
+// emails. Emails that are not an admin's are logged and skipped.
+func (g *IdentityGuard) Seed(emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	var admins []models.User
+	if err := g.DB.Where("LOWER(email) IN ? AND user_type = ?", lowerAll(emails), models.Admin).Find(&admins).Error; err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, admin := range admins {
+		found[strings.ToLower(admin.Email)] = true
+	}
+	for _, email := range lowerAll(emails) {
+		if email != "" && !found[email] {
+			log.Printf("Not granting reveal_identity to %s: no admin with that email", email)
+		}
+	}
+	for _, admin := range admins {
+		grant := models.UserPermission{UserID: admin.ID, Permission: models.PermissionRevealIdentity}
+		if err := g.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&grant).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnguardedBlindJobs returns the number of blind hiring jobs if no admin
+// holds the reveal identity permission, and zero otherwise. Without a
+// holder nobody can change those jobs' blind hiring settings or grant the
+// permission.
+func (g *IdentityGuard) UnguardedBlindJobs() (int64, error) {
+	var holders int64
+	err := g.DB.Model(&models.UserPermission{}).
+		Joins("JOIN users ON users.id = user_permissions.user_id AND users.deleted_at IS NULL").
+		Where("user_permissions.permission = ? AND users.user_type = ?", models.PermissionRevealIdentity, models.Admin).
+		Count(&holders).Error
+	if err != nil || holders > 0 {
+		return 0, err
+	}
+	var jobs int64
+	err = g.DB.Model(&models.Job{}).Where("blind_hiring").Count(&jobs).Error
+	return jobs, err
+}
+
+// HasPermission reports whether a user was granted a permission.
+func (g *IdentityGuard) HasPermission(userID uint, permission models.Permission) (bool, error) {
+	var count int64
+	err := g.DB.Model(&models.UserPermission{}).Where("user_id = ? AND permission = ?", userID, permission).Count(&count).Error
+	return count > 0, err
+}
+
+// blindApplication is an application to a blind hiring job.
+type blindApplication struct {
+	ID          uint
+	ApplicantID uint
+	Stage       models.ApplicationStage
+	RevealStage models.ApplicationStage
+}
+
+// unrevealed returns the applicants of the blind applications matching the
+// condition that have not reached their job's reveal stage.
+func (g *IdentityGuard) unrevealed(condition func(*gorm.DB) *gorm.DB) (map[uint]bool, error) {
+	var applications []blindApplication
+	query := g.DB.Table("applications a").
+		Select("a.id, a.applicant_id, a.stage, j.reveal_stage").
+		Joins("JOIN jobs j ON j.id = a.job_id AND j.deleted_at IS NULL").
+		Where("a.deleted_at IS NULL AND j.blind_hiring")
+	if err := condition(query).Scan(&applications).Error; err != nil {
+		return nil, err
+	}
+
+	// Stages each application has been in, for those that can be revealed
+	reached := map[uint][]models.ApplicationStage{}
+	var ids []uint
+	for _, application := range applications {
+		if application.RevealStage != "" {
+			reached[application.ID] = append(reached[application.ID], application.Stage)
+			ids = append(ids, application.ID)
+		}
+	}
+	if len(ids) > 0 {
+		var events []models.ApplicationStageEvent
+		if err := g.DB.Select("application_id", "to_stage").Where("application_id IN ?", ids).Find(&events).Error; err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			reached[event.ApplicationID] = append(reached[event.ApplicationID], event.ToStage)
+		}
+	}
+
+	hidden := map[uint]bool{}
+	for _, application := range applications {
+		if !reachedAny(reached[application.ID], models.StagesFrom(application.RevealStage)) {
+			hidden[application.ApplicantID] = true
+		}
+	}
+	return hidden, nil
+}
+
+func reachedAny(stages, targets []models.ApplicationStage) bool {
+	for _, stage := range stages {
+		for _, target := range targets {
+			if stage == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HiddenApplicants returns which of the users viewerID may not identify
+// because they have an unrevealed application to a blind hiring job. It
+// guards views of candidates outside the context of a job.
+func (g *IdentityGuard) HiddenApplicants(viewerID uint, userIDs []uint) (map[uint]bool, error) {
+	if len(userIDs) == 0 {
+		return map[uint]bool{}, nil
+	}
+	if ok, err := g.HasPermission(viewerID, models.PermissionRevealIdentity); err != nil || ok {
+		return map[uint]bool{}, err
+	}
+	return g.unrevealed(func(query *gorm.DB) *gorm.DB {
+		return query.Where("a.applicant_id IN ?", userIDs)
+	})
+}
+
+// HiddenForJob returns which of the users viewerID may not identify when
+// reviewing them for a job. On blind hiring jobs that is everyone whose
+// application has not reached the reveal stage, including candidates who
+// have not applied.
+func (g *IdentityGuard) HiddenForJob(viewerID uint, job *models.Job, userIDs []uint) (map[uint]bool, error) {
+	hidden := map[uint]bool{}
+	if !job.BlindHiring || len(userIDs) == 0 {
+		return hidden, nil
+	}
+	if ok, err := g.HasPermission(viewerID, models.PermissionRevealIdentity); err != nil || ok {
+		return hidden, err
+	}
+
+	unrevealed, err := g.unrevealed(func(query *gorm.DB) *gorm.DB {
+		return query.Where("a.job_id = ? AND a.applicant_id IN ?", job.ID, userIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var applied []uint
+	if err := g.DB.Model(&models.Application{}).Where("job_id = ? AND applicant_id IN ?", job.ID, userIDs).
+		Pluck("applicant_id", &applied).Error; err != nil {
+		return nil, err
+	}
+	revealed := map[uint]bool{}
+	for _, id := range applied {
+		revealed[id] = !unrevealed[id]
+	}
+	for _, id := range userIDs {
+		if !revealed[id] {
+			hidden[id] = true
+		}
+	}
+	return hidden, nil
+}
+
+// CandidateIdentity is what blind hiring hides about a candidate, gathered
+// from their user record and profile so it can be found in free text.
+type CandidateIdentity struct {
+	UserID    uint
+	Names     []string
+	Phones    []string
+	Addresses []string
+	Schools   []string
+
+	// phrases are the details above as patterns, built once by
+	// NewCandidateIdentity.
+	phrases []identityPhrase
+}
+
+// identityPhrase finds one identifying detail in free text.
+type identityPhrase struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// Identity loads the identity of a candidate.
+func (g *IdentityGuard) Identity(userID uint) (*CandidateIdentity, error) {
+	var user models.User
+	if err := g.DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	var profile models.Profile
+	if err := g.DB.Preload("Educations").Where("user_id = ?", userID).Limit(1).Find(&profile).Error; err != nil {
+		return nil, err
+	}
+	return NewCandidateIdentity(&user, &profile), nil
+}
+
+// NewCandidateIdentity collects the identifying details of a user and their
+// profile, which may be empty.
+func NewCandidateIdentity(user *models.User, profile *models.Profile) *CandidateIdentity {
+	identity := &CandidateIdentity{UserID: user.ID}
+	add := func(list *[]string, values ...string) {
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				*list = append(*list, value)
+			}
+		}
+	}
+	add(&identity.Names, user.Name, profile.Name)
+	add(&identity.Phones, profile.Phone)
+	add(&identity.Addresses, user.Address)
+	for _, education := range profile.Educations {
+		add(&identity.Schools, education.Institution)
+	}
+	identity.phrases = identity.compile()
+	return identity
+}
+
+// Pseudonym is the name shown for a hidden candidate.
+func Pseudonym(userID uint) string {
+	return fmt.Sprintf("Candidate #%d", userID)
+}
+
+// RedactUser blanks the identifying fields of a user, and of their profile
+// if it was loaded.
+func RedactUser(user *models.User) {
+	identity := NewCandidateIdentity(user, &user.Profile)
+	user.Name = Pseudonym(user.ID)
+	user.Email = ""
+	user.Address = ""
+	user.ProfileHeadline = identity.Redact(user.ProfileHeadline)
+	if user.Profile.ID != 0 {
+		RedactProfile(&user.Profile, identity)
+	}
+}
+
+// RedactProfile blanks the identifying fields of a profile and redacts its
+// free text.
+func RedactProfile(profile *models.Profile, identity *CandidateIdentity) {
+	profile.Name = Pseudonym(profile.UserID)
+	profile.Email = ""
+	profile.Phone = ""
+	profile.GitHubURL = ""
+	profile.LinkedInURL = ""
+	profile.PortfolioURL = ""
+	// Stored file names are often the candidate's name
+	profile.ResumeFilePath = ""
+	profile.Education = identity.Redact(profile.Education)
+	profile.Experience = identity.Redact(profile.Experience)
+	for i := range profile.Educations {
+		if profile.Educations[i].Institution != "" {
+			profile.Educations[i].Institution = redactedSchool
+		}
+	}
+	for i := range profile.Experiences {
+		profile.Experiences[i].Description = identity.Redact(profile.Experiences[i].Description)
+	}
+}
+
+// RedactResumeVersion replaces a resume with its redacted text. The file
+// itself, its layout and the parsed data are withheld.
+func RedactResumeVersion(version *models.ResumeVersion, identity *CandidateIdentity) {
+	version.ExtractedText = identity.Redact(version.ExtractedText)
+	version.Document = ""
+	version.ParsedData = ""
+	version.FileName = "resume.pdf"
+	version.FilePath = ""
+}
+
+// Redact replaces the candidate's names, contact details, address and
+// schools in free text with placeholders, along with any email address,
+// phone number or link. Single name parts are redacted as whole words, so
+// it errs on the side of hiding too much.
+func (identity *CandidateIdentity) Redact(text string) string {
+	if text == "" {
+		return text
+	}
+	// The patterns are shared with the local parser
+	text = emailPattern.ReplaceAllString(text, redactedEmail)
+	text = linkPattern.ReplaceAllString(text, redactedLink)
+	text = phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		digits := 0
+		for _, r := range match {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits < 9 || digits > 15 || dateRangePattern.MatchString(match) {
+			return match
+		}
+		return redactedPhone
+	})
+
+	phrases := identity.phrases
+	if phrases == nil {
+		phrases = identity.compile()
+	}
+	for _, phrase := range phrases {
+		text = replaceWords(text, phrase.pattern, phrase.replacement)
+	}
+	return text
+}
+
+// compile builds the patterns of the candidate's details, longest first so
+// a full name goes before its parts.
+func (identity *CandidateIdentity) compile() []identityPhrase {
+	replacements := map[string]string{}
+	for _, phone := range identity.Phones {
+		replacements[phone] = redactedPhone
+	}
+	for _, address := range identity.Addresses {
+		replacements[address] = redactedAddress
+	}
+	for _, school := range identity.Schools {
+		replacements[school] = redactedSchool
+	}
+	for _, name := range identity.Names {
+		replacements[name] = redactedName
+		for _, part := range strings.Fields(name) {
+			if part = strings.Trim(part, ".,"); len([]rune(part)) > 1 {
+				replacements[part] = redactedName
+			}
+		}
+	}
+	texts := make([]string, 0, len(replacements))
+	for text := range replacements {
+		if strings.TrimSpace(text) != "" {
+			texts = append(texts, text)
+		}
+	}
+	sort.Slice(texts, func(i, j int) bool {
+		if len(texts[i]) != len(texts[j]) {
+			return len(texts[i]) > len(texts[j])
+		}
+		return texts[i] < texts[j]
+	})
+
+	phrases := make([]identityPhrase, len(texts))
+	for i, text := range texts {
+		phrases[i] = identityPhrase{pattern: phrasePattern(text), replacement: replacements[text]}
+	}
+	return phrases
+}
+
+// phrasePattern matches phrase ignoring case and allowing any run of
+// whitespace between its words.
+func phrasePattern(phrase string) *regexp.Regexp {
+	words := strings.Fields(phrase)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(words, `\s+`))
+}
+
+// replaceWords replaces the matches of pattern in text where they are not
+// part of a longer word.
+func replaceWords(text string, pattern *regexp.Regexp, replacement string) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		before, _ := utf8.DecodeLastRuneInString(text[:match[0]])
+		after, _ := utf8.DecodeRuneInString(text[match[1]:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+		b.WriteString(text[last:match[0]])
+		b.WriteString(replacement)
+		last = match[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(strings.TrimSpace(value))
+	}
+	return lowered
+}
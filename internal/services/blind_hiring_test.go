@@ -0,0 +1,138 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+)
+
+func TestCandidateIdentityRedact(t *testing.T) {
+	identity := NewCandidateIdentity(
+		&models.User{Name: "Jane Q. Doe", Address: "12 Elm Street, Springfield"},
+		&models.Profile{Name: "Jane Doe", Phone: "0555 12 34", Educations: []models.EducationEntry{
+			{Institution: "MIT"}, {Institution: "University of Oxford"}, {Institution: " "},
+		}},
+	)
+	tests := []struct {
+		name, text, want string
+	}{
+		{"empty", "", ""},
+		{"full name", "Jane Q. Doe led the team", "[name] led the team"},
+		{"name across whitespace and case", "JANE\n  doe wrote this", "[name] wrote this"},
+		{"name parts", "Ask Jane or Mr. Doe", "Ask [name] or Mr. [name]"},
+		{"possessive", "Jane's team", "[name]'s team"},
+		{"initials are kept", "Plan Q shipped", "Plan Q shipped"},
+		{"parts of longer words are kept", "Janet Doerr and janedoe", "Janet Doerr and janedoe"},
+		{"profile phone too short for the pattern", "Call 0555 12 34", "Call [phone]"},
+		{"any phone", "Tel: +44 20 7946 0958 or 555.123.4567", "Tel: [phone] or [phone]"},
+		{"eight digits are kept", "Badge 1234 5678", "Badge 1234 5678"},
+		{"sixteen digits are kept", "Card 1234 5678 9012 3456", "Card 1234 5678 9012 3456"},
+		{"date ranges are kept", "Acme 2017 - 2019 2020", "Acme 2017 - 2019 2020"},
+		{"schools", "MIT, then University of  Oxford; MITRE", "[school], then [school]; MITRE"},
+		{"address", "Lives at 12 Elm Street, Springfield.", "Lives at [address]."},
+		{"email and links", "jane@example.com, https://jane.dev and github.com/jdoe", "[email], [link] and [link]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := identity.Redact(tt.text); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	// An identity not built by NewCandidateIdentity still redacts
+	literal := &CandidateIdentity{Names: []string{"Jane Doe"}}
+	if got := literal.Redact("By Jane Doe"); got != "By [name]" {
+		t.Errorf("Redact with a literal identity = %q", got)
+	}
+}
+
+func TestIdentityGuardHidden(t *testing.T) {
+	db := testdb.Open(t, &models.User{}, &models.UserPermission{}, &models.Job{}, &models.Application{}, &models.ApplicationStageEvent{})
+	guard := NewIdentityGuard(db)
+
+	reviewer := models.User{Name: "Reviewer", Email: "reviewer@example.com", UserType: models.Admin}
+	holder := models.User{Name: "Holder", Email: "holder@example.com", UserType: models.Admin}
+	mustCreate(t, db, &reviewer)
+	mustCreate(t, db, &holder)
+	mustCreate(t, db, &models.UserPermission{UserID: holder.ID, Permission: models.PermissionRevealIdentity})
+
+	blind := models.Job{Title: "Blind", Description: "x", CompanyName: "Acme", PostedByID: reviewer.ID, BlindHiring: true, RevealStage: models.StageInterview}
+	hidden := models.Job{Title: "Always blind", Description: "x", CompanyName: "Acme", PostedByID: reviewer.ID, BlindHiring: true}
+	open := models.Job{Title: "Open", Description: "x", CompanyName: "Acme", PostedByID: reviewer.ID}
+	mustCreate(t, db, &blind)
+	mustCreate(t, db, &hidden)
+	mustCreate(t, db, &open)
+
+	candidates := map[string]*models.User{}
+	for _, name := range []string{"applied", "interviewed", "hired", "rejected", "rejected later", "other job", "not applied"} {
+		user := &models.User{Name: name, Email: name + "@example.com", UserType: models.Applicant}
+		mustCreate(t, db, user)
+		candidates[name] = user
+	}
+	apply := func(name string, job *models.Job, stage models.ApplicationStage, history ...models.ApplicationStage) {
+		application := models.Application{ApplicantID: candidates[name].ID, JobID: job.ID, Stage: stage}
+		mustCreate(t, db, &application)
+		for _, to := range history {
+			mustCreate(t, db, &models.ApplicationStageEvent{ApplicationID: application.ID, ToStage: to, ChangedByID: reviewer.ID})
+		}
+	}
+	apply("applied", &blind, models.StageScreening, models.StageScreening)
+	apply("interviewed", &blind, models.StageInterview, models.StageScreening, models.StageInterview)
+	apply("hired", &blind, models.StageHired)
+	apply("rejected", &blind, models.StageRejected, models.StageRejected)
+	// Reaching the reveal stage shows up only in the stage history
+	apply("rejected later", &blind, models.StageRejected, models.StageInterview, models.StageRejected)
+	apply("other job", &open, models.StageApplied)
+	apply("interviewed", &hidden, models.StageOffer, models.StageInterview, models.StageOffer)
+
+	var all []uint
+	for _, name := range []string{"applied", "interviewed", "hired", "rejected", "rejected later", "other job", "not applied"} {
+		all = append(all, candidates[name].ID)
+	}
+	ids := func(names ...string) map[uint]bool {
+		set := map[uint]bool{}
+		for _, name := range names {
+			set[candidates[name].ID] = true
+		}
+		return set
+	}
+
+	tests := []struct {
+		name   string
+		viewer uint
+		job    *models.Job
+		want   map[uint]bool
+	}{
+		{"blind job", reviewer.ID, &blind, ids("applied", "rejected", "other job", "not applied")},
+		{"never revealed", reviewer.ID, &hidden, ids("applied", "interviewed", "hired", "rejected", "rejected later", "other job", "not applied")},
+		{"open job", reviewer.ID, &open, ids()},
+		{"permission holder", holder.ID, &blind, ids()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := guard.HiddenForJob(tt.viewer, tt.job, all)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HiddenForJob = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Outside a job, a candidate is hidden while any blind application is
+	// unrevealed
+	got, err := guard.HiddenApplicants(reviewer.ID, all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ids("applied", "interviewed", "rejected"); !reflect.DeepEqual(got, want) {
+		t.Errorf("HiddenApplicants = %v, want %v", got, want)
+	}
+	if got, err := guard.HiddenApplicants(holder.ID, all); err != nil || len(got) != 0 {
+		t.Errorf("HiddenApplicants for a permission holder = %v, %v, want none", got, err)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/GolangAssignment/internal/config"
+)
+
+// ErrBlobNotFound is returned when a key does not exist in a BlobStore.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores resume files and other attachments by key. Keys use
+// forward slashes regardless of the backend, e.g. "resumes/ab/ab12….pdf".
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore builds the store selected by cfg.StorageBackend.
+func NewBlobStore(cfg config.Config) (BlobStore, error) {
+	switch cfg.StorageBackend {
+	case "", "local":
+		return NewLocalBlobStore(cfg.StorageLocalRoot), nil
+	case "s3":
+		if cfg.S3Bucket == "" {
+			return nil, errors.New("S3_BUCKET is required for the s3 storage backend")
+		}
+		return NewS3BlobStore(S3Options{
+			Endpoint:             cfg.S3Endpoint,
+			Region:               cfg.S3Region,
+			Bucket:               cfg.S3Bucket,
+			AccessKey:            cfg.S3AccessKey,
+			SecretKey:            cfg.S3SecretKey,
+			PathStyle:            cfg.S3PathStyle,
+			ServerSideEncryption: cfg.S3ServerSideEncryption,
+			KMSKeyID:             cfg.S3KMSKeyID,
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend: %s", cfg.StorageBackend)
+}
+
+// ContentKey returns a content-addressed key for a file: identical uploads
+// share one object. The first two hash characters fan keys out into
+// sub-directories.
+func ContentKey(prefix, hash, fileName string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if len(hash) < 2 {
+		return path.Join(prefix, hash+ext)
+	}
+	return path.Join(prefix, hash[:2], hash+ext)
+}
+
+// LocalBlobStore keeps blobs as files under a root directory.
+type LocalBlobStore struct {
+	Root string
+}
+
+func NewLocalBlobStore(root string) *LocalBlobStore {
+	return &LocalBlobStore{Root: root}
+}
+
+// path maps a key to a file path, refusing keys that escape the root.
+func (s *LocalBlobStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid key: %q", key)
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(clean[1:])), nil
+}
+
+func (s *LocalBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see partial blobs.
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *LocalBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return f, err
+}
+
+func (s *LocalBlobStore) Exists(_ context.Context, key string) (bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalBlobStore) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// DownloadToTemp copies a blob to a temporary file, keeping the key's
+// extension so extractors can recognise the format. The caller must call the
+// returned cleanup function.
+func DownloadToTemp(ctx context.Context, store BlobStore, key string) (string, func(), error) {
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	defer r.Close()
+
+	tmp, err := os.CreateTemp("", "blob-*"+filepath.Ext(key))
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
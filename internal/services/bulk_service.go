@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BulkService executes BulkOperations against the applications of a job.
+// Operations are stored with a pending item per application before they
+// run, and claimed by a worker with SELECT ... FOR UPDATE SKIP LOCKED, so an
+// operation interrupted by a restart resumes with the items not yet done.
+type BulkService struct {
+	DB           *gorm.DB
+	PollInterval time.Duration
+}
+
+func NewBulkService(db *gorm.DB) *BulkService {
+	return &BulkService{DB: db, PollInterval: 2 * time.Second}
+}
+
+// Enqueue stores a pending operation with an item for each application.
+func (s *BulkService) Enqueue(op *models.BulkOperation, applicationIDs []uint) error {
+	op.Status = models.BulkPending
+	op.Total = len(applicationIDs)
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(op).Error; err != nil {
+			return err
+		}
+		items := make([]models.BulkOperationItem, len(applicationIDs))
+		for i, appID := range applicationIDs {
+			items[i] = models.BulkOperationItem{OperationID: op.ID, ApplicationID: appID, Status: models.BulkItemPending}
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+}
+
+// Start runs queued operations in the background until ctx is cancelled.
+// Each application is processed independently so one failure does not abort
+// the rest.
+func (s *BulkService) Start(ctx context.Context) {
+	go func() {
+		for {
+			op, err := s.claim()
+			if err != nil {
+				log.Printf("Bulk worker: failed to claim operation: %v", err)
+			}
+			if op == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(s.PollInterval):
+				}
+				continue
+			}
+			s.run(op)
+		}
+	}()
+}
+
+// claim locks the next pending operation, or one whose worker stopped
+// mid-run, and marks it as running.
+func (s *BulkService) claim() (*models.BulkOperation, error) {
+	var op models.BulkOperation
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND (locked_at IS NULL OR locked_at < ?))",
+				models.BulkPending, models.BulkRunning, now.Add(-staleJobTimeout)).
+			Order("id").First(&op).Error
+		if err != nil {
+			return err
+		}
+		op.Status = models.BulkRunning
+		op.LockedAt = &now
+		return tx.Model(&op).Updates(map[string]interface{}{"status": op.Status, "locked_at": now}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
+
+// run settles the pending items of a claimed operation and records the
+// outcome. If it stops early the operation is claimed again once stale.
+func (s *BulkService) run(op *models.BulkOperation) {
+	pending := s.DB.Model(&models.BulkOperationItem{}).Where("operation_id = ? AND status = ?", op.ID, models.BulkItemPending).
+		Session(&gorm.Session{})
+
+	var tmpl *models.MessageTemplate
+	if op.Action == models.BulkSendMessage {
+		tmpl = &models.MessageTemplate{}
+		if op.TemplateID == nil || s.DB.First(tmpl, *op.TemplateID).Error != nil {
+			log.Printf("Bulk operation %d: message template not found", op.ID)
+			if err := pending.Updates(map[string]interface{}{"status": models.BulkItemError, "error": "message template not found"}).Error; err != nil {
+				log.Printf("Bulk operation %d: failed to record items: %v", op.ID, err)
+				return
+			}
+			s.finish(op)
+			return
+		}
+	}
+
+	var items []models.BulkOperationItem
+	if err := pending.Order("id").Find(&items).Error; err != nil {
+		log.Printf("Bulk operation %d: failed to load items: %v", op.ID, err)
+		return
+	}
+	for i := range items {
+		if err := s.runItem(op, &items[i], tmpl); err != nil {
+			log.Printf("Bulk operation %d: failed to record item %d: %v", op.ID, items[i].ApplicationID, err)
+			return
+		}
+	}
+	s.finish(op)
+}
+
+// runItem applies the operation to one application. The action and the
+// item's outcome commit together, so a resumed operation never applies an
+// item twice. It returns an error only if the outcome could not be stored.
+func (s *BulkService) runItem(op *models.BulkOperation, item *models.BulkOperationItem, tmpl *models.MessageTemplate) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var application models.Application
+		if err := tx.Preload("Applicant").Preload("Job").
+			Where("id = ? AND job_id = ?", item.ApplicationID, op.JobID).First(&application).Error; err != nil {
+			return errors.New("application not found for this job")
+		}
+		if err := applyBulkAction(tx, op, &application, tmpl); err != nil {
+			return err
+		}
+		return tx.Model(item).Update("status", models.BulkItemOK).Error
+	})
+	if err != nil {
+		if err := s.DB.Model(item).Updates(map[string]interface{}{"status": models.BulkItemError, "error": err.Error()}).Error; err != nil {
+			return err
+		}
+	}
+
+	counts, err := s.counts(op.ID)
+	if err != nil {
+		return err
+	}
+	return s.DB.Model(op).Updates(map[string]interface{}{
+		"succeeded": counts[models.BulkItemOK],
+		"failed":    counts[models.BulkItemError],
+		"locked_at": time.Now(),
+	}).Error
+}
+
+// counts returns the number of items of an operation in each status.
+func (s *BulkService) counts(operationID uint) (map[models.BulkItemStatus]int, error) {
+	var rows []struct {
+		Status models.BulkItemStatus
+		Count  int
+	}
+	err := s.DB.Model(&models.BulkOperationItem{}).Select("status, COUNT(*) AS count").
+		Where("operation_id = ?", operationID).Group("status").Scan(&rows).Error
+	counts := map[models.BulkItemStatus]int{}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, err
+}
+
+// finish records the final counts and status of an operation.
+func (s *BulkService) finish(op *models.BulkOperation) {
+	counts, err := s.counts(op.ID)
+	if err != nil {
+		log.Printf("Bulk operation %d: failed to count items: %v", op.ID, err)
+		return
+	}
+	succeeded, failed := counts[models.BulkItemOK], counts[models.BulkItemError]
+
+	status := models.BulkCompleted
+	if failed > 0 && succeeded == 0 {
+		status = models.BulkFailed
+	} else if failed > 0 {
+		status = models.BulkCompletedWithErrors
+	}
+	err = s.DB.Model(op).Updates(map[string]interface{}{
+		"status":    status,
+		"succeeded": succeeded,
+		"failed":    failed,
+		"locked_at": nil,
+	}).Error
+	if err != nil {
+		log.Printf("Bulk operation %d: failed to record outcome: %v", op.ID, err)
+		return
+	}
+	log.Printf("Bulk operation %d finished: %d succeeded, %d failed", op.ID, succeeded, failed)
+}
+
+func applyBulkAction(tx *gorm.DB, op *models.BulkOperation, application *models.Application, tmpl *models.MessageTemplate) error {
+	switch op.Action {
+	case models.BulkMoveStage:
+		return ChangeStage(tx, application, op.Stage, op.RequestedByID)
+	case models.BulkReject:
+		if err := tx.Model(application).Update("rejection_reason", op.Reason).Error; err != nil {
+			return err
+		}
+		return ChangeStage(tx, application, models.StageRejected, op.RequestedByID)
+	case models.BulkTag:
+		tag := models.ApplicationTag{ApplicationID: application.ID, Tag: op.Tag}
+		return tx.Where(tag).FirstOrCreate(&tag).Error
+	case models.BulkAssign:
+		return tx.Model(application).Update("reviewer_id", op.ReviewerID).Error
+	case models.BulkSendMessage:
+		subject, body, err := RenderMessageTemplate(tmpl, application)
+		if err != nil {
+			return err
+		}
+		message := models.Message{
+			RecipientID:   application.ApplicantID,
+			SenderID:      op.RequestedByID,
+			ApplicationID: &application.ID,
+			Subject:       subject,
+			Body:          body,
+		}
+		return tx.Create(&message).Error
+	}
+	return fmt.Errorf("unsupported action: %s", op.Action)
+}
+
+// ChangeStage moves an application to a new stage and records the transition.
+func ChangeStage(tx *gorm.DB, application *models.Application, stage models.ApplicationStage, changedByID uint) error {
+	if !stage.IsValid() {
+		return fmt.Errorf("invalid stage: %s", stage)
+	}
+	if application.Stage == stage {
+		return nil
+	}
+
+	event := models.ApplicationStageEvent{
+		ApplicationID: application.ID,
+		FromStage:     application.Stage,
+		ToStage:       stage,
+		ChangedByID:   changedByID,
+	}
+	if err := tx.Model(application).Update("stage", stage).Error; err != nil {
+		return err
+	}
+	return tx.Create(&event).Error
+}
+
+// RenderMessageTemplate fills a template with the candidate and job details
+// of an application.
+func RenderMessageTemplate(tmpl *models.MessageTemplate, application *models.Application) (string, string, error) {
+	data := map[string]string{
+		"CandidateName": application.Applicant.Name,
+		"JobTitle":      application.Job.Title,
+		"CompanyName":   application.Job.CompanyName,
+	}
+
+	render := func(name, text string) (string, error) {
+		t, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid template %s: %v", name, err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("failed to render %s: %v", name, err)
+		}
+		return buf.String(), nil
+	}
+
+	subject, err := render("subject", tmpl.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := render("body", tmpl.Body)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// bulkFixture is a job with two applications, and an application to
+// another job.
+type bulkFixture struct {
+	db       *gorm.DB
+	job      models.Job
+	apps     []models.Application
+	otherApp models.Application
+}
+
+func newBulkFixture(t *testing.T) *bulkFixture {
+	t.Helper()
+	db := testdb.Open(t, &models.User{}, &models.Job{}, &models.Application{}, &models.ApplicationTag{},
+		&models.ApplicationStageEvent{}, &models.MessageTemplate{}, &models.Message{},
+		&models.BulkOperation{}, &models.BulkOperationItem{})
+	f := &bulkFixture{db: db}
+
+	admin := models.User{Name: "Admin", Email: "admin@example.com", UserType: models.Admin}
+	mustCreate(t, db, &admin)
+	f.job = models.Job{Title: "Go Developer", Description: "Go", CompanyName: "Acme", PostedByID: admin.ID}
+	other := models.Job{Title: "Designer", Description: "Figma", CompanyName: "Acme", PostedByID: admin.ID}
+	mustCreate(t, db, &f.job)
+	mustCreate(t, db, &other)
+
+	for i := 0; i < 3; i++ {
+		applicant := models.User{Name: fmt.Sprintf("Applicant %d", i), Email: fmt.Sprintf("a%d@example.com", i), UserType: models.Applicant}
+		mustCreate(t, db, &applicant)
+		app := models.Application{ApplicantID: applicant.ID, JobID: f.job.ID, Stage: models.StageApplied}
+		if i == 2 {
+			app.JobID = other.ID
+		}
+		mustCreate(t, db, &app)
+		if i == 2 {
+			f.otherApp = app
+		} else {
+			f.apps = append(f.apps, app)
+		}
+	}
+	return f
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runNext claims and runs the next operation.
+func runNext(t *testing.T, s *BulkService) *models.BulkOperation {
+	t.Helper()
+	op, err := s.claim()
+	if err != nil || op == nil {
+		t.Fatalf("claim = %v, %v; want an operation", op, err)
+	}
+	s.run(op)
+	if err := s.DB.Preload("Items").First(op, op.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return op
+}
+
+func TestBulkPartialFailure(t *testing.T) {
+	f := newBulkFixture(t)
+	s := NewBulkService(f.db)
+
+	op := models.BulkOperation{JobID: f.job.ID, RequestedByID: 1, Action: models.BulkMoveStage, Stage: models.StageScreening}
+	ids := []uint{f.apps[0].ID, 9999, f.apps[1].ID, f.otherApp.ID}
+	if err := s.Enqueue(&op, ids); err != nil {
+		t.Fatal(err)
+	}
+
+	done := runNext(t, s)
+	if done.Status != models.BulkCompletedWithErrors || done.Succeeded != 2 || done.Failed != 2 || done.Total != 4 {
+		t.Errorf("operation = %s, %d succeeded, %d failed of %d; want completed_with_errors, 2, 2 of 4",
+			done.Status, done.Succeeded, done.Failed, done.Total)
+	}
+	want := map[uint]models.BulkItemStatus{
+		f.apps[0].ID: models.BulkItemOK, 9999: models.BulkItemError,
+		f.apps[1].ID: models.BulkItemOK, f.otherApp.ID: models.BulkItemError,
+	}
+	for _, item := range done.Items {
+		if item.Status != want[item.ApplicationID] {
+			t.Errorf("item for application %d = %s, want %s", item.ApplicationID, item.Status, want[item.ApplicationID])
+		}
+		if item.Status == models.BulkItemError && item.Error == "" {
+			t.Errorf("failed item for application %d has no error", item.ApplicationID)
+		}
+	}
+
+	var other models.Application
+	f.db.First(&other, f.otherApp.ID)
+	if other.Stage != models.StageApplied {
+		t.Errorf("application of another job moved to %s", other.Stage)
+	}
+	var events int64
+	f.db.Model(&models.ApplicationStageEvent{}).Count(&events)
+	if events != 2 {
+		t.Errorf("%d stage events recorded, want 2", events)
+	}
+}
+
+func TestBulkAllFail(t *testing.T) {
+	f := newBulkFixture(t)
+	s := NewBulkService(f.db)
+
+	// The template is gone by the time the operation runs
+	op := models.BulkOperation{JobID: f.job.ID, RequestedByID: 1, Action: models.BulkSendMessage, TemplateID: new(uint)}
+	if err := s.Enqueue(&op, []uint{f.apps[0].ID, f.apps[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+	done := runNext(t, s)
+	if done.Status != models.BulkFailed || done.Failed != 2 {
+		t.Errorf("operation = %s with %d failed, want failed with 2", done.Status, done.Failed)
+	}
+}
+
+func TestBulkResume(t *testing.T) {
+	f := newBulkFixture(t)
+	s := NewBulkService(f.db)
+	tmpl := models.MessageTemplate{Name: "hello", Subject: "Hi {{.CandidateName}}", Body: "About {{.JobTitle}}", CreatedByID: 1}
+	mustCreate(t, f.db, &tmpl)
+
+	op := models.BulkOperation{JobID: f.job.ID, RequestedByID: 1, Action: models.BulkSendMessage, TemplateID: &tmpl.ID}
+	if err := s.Enqueue(&op, []uint{f.apps[0].ID, f.apps[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A worker sent the first message, then stopped
+	claimed, err := s.claim()
+	if err != nil || claimed == nil {
+		t.Fatalf("claim = %v, %v", claimed, err)
+	}
+	var first models.BulkOperationItem
+	f.db.Where("operation_id = ?", op.ID).Order("id").First(&first)
+	if err := s.runItem(claimed, &first, &tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another worker leaves it alone until it goes stale
+	if again, err := s.claim(); err != nil || again != nil {
+		t.Fatalf("claim of a live operation = %v, %v; want none", again, err)
+	}
+	f.db.Model(&models.BulkOperation{}).Where("id = ?", op.ID).Update("locked_at", time.Now().Add(-2*staleJobTimeout))
+
+	done := runNext(t, s)
+	if done.Status != models.BulkCompleted || done.Succeeded != 2 {
+		t.Errorf("operation = %s with %d succeeded, want completed with 2", done.Status, done.Succeeded)
+	}
+	var messages int64
+	f.db.Model(&models.Message{}).Count(&messages)
+	if messages != 2 {
+		t.Errorf("%d messages sent, want 2: a resumed operation must not redo items", messages)
+	}
+}
@@ -0,0 +1,479 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Legacy Word (.doc) files are OLE compound files. The text lives in the
+// WordDocument stream; the piece table in the 0Table or 1Table stream maps
+// character positions to byte ranges. See [MS-CFB] and [MS-DOC].
+
+const (
+	cfbEndOfChain = 0xFFFFFFFE
+	cfbFreeSector = 0xFFFFFFFF
+	// cfbMaxSectors bounds sector numbers on top of the file's own size.
+	cfbMaxSectors = 1 << 20
+)
+
+// compoundFile is a minimal read-only OLE compound file reader.
+type compoundFile struct {
+	data          []byte
+	sectorSize    int
+	miniSize      int
+	miniCutoff    uint32
+	fat           []uint32
+	miniFAT       []uint32
+	miniStream    []byte
+	entries       map[string]cfbEntry
+	directoryList []cfbEntry
+}
+
+type cfbEntry struct {
+	name  string
+	kind  byte
+	start uint32
+	size  uint64
+}
+
+func openCompoundFile(data []byte) (*compoundFile, error) {
+	if len(data) < 512 || !bytes.HasPrefix(data, oleMagic) {
+		return nil, errors.New("not a compound file")
+	}
+	le := binary.LittleEndian
+	cf := &compoundFile{
+		data:       data,
+		sectorSize: 1 << le.Uint16(data[0x1E:]),
+		miniSize:   1 << le.Uint16(data[0x20:]),
+		miniCutoff: le.Uint32(data[0x38:]),
+		entries:    map[string]cfbEntry{},
+	}
+	if cf.sectorSize != 512 && cf.sectorSize != 4096 {
+		return nil, fmt.Errorf("unsupported sector size %d", cf.sectorSize)
+	}
+	if cf.miniSize != 64 {
+		return nil, fmt.Errorf("unsupported mini sector size %d", cf.miniSize)
+	}
+
+	// The FAT sectors are listed in the header DIFAT, continued in DIFAT
+	// sectors for large files. A file has no more FAT sectors than sectors,
+	// and a DIFAT chain that revisits a sector is corrupt.
+	sectors := cf.sectorCount()
+	var fatSectors []uint32
+	for i := 0; i < 109; i++ {
+		if s := le.Uint32(data[0x4C+i*4:]); s != cfbFreeSector {
+			fatSectors = append(fatSectors, s)
+		}
+	}
+	visited := make([]bool, sectors)
+	difat := le.Uint32(data[0x44:])
+	for difat != cfbEndOfChain && difat != cfbFreeSector {
+		if int(difat) >= sectors || visited[difat] {
+			return nil, errors.New("corrupt DIFAT chain")
+		}
+		visited[difat] = true
+		sector, err := cf.sector(difat)
+		if err != nil {
+			return nil, err
+		}
+		per := cf.sectorSize/4 - 1
+		for i := 0; i < per; i++ {
+			if s := le.Uint32(sector[i*4:]); s != cfbFreeSector {
+				fatSectors = append(fatSectors, s)
+			}
+		}
+		if len(fatSectors) > sectors {
+			return nil, errors.New("corrupt DIFAT chain")
+		}
+		difat = le.Uint32(sector[per*4:])
+	}
+	for _, s := range fatSectors {
+		sector, err := cf.sector(s)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < cf.sectorSize/4; i++ {
+			cf.fat = append(cf.fat, le.Uint32(sector[i*4:]))
+		}
+	}
+
+	directory, err := cf.chain(le.Uint32(data[0x30:]), 0)
+	if err != nil {
+		return nil, err
+	}
+	for off := 0; off+128 <= len(directory); off += 128 {
+		raw := directory[off : off+128]
+		nameLen := int(le.Uint16(raw[64:]))
+		if nameLen < 2 || nameLen > 64 {
+			continue
+		}
+		units := make([]uint16, nameLen/2-1)
+		for i := range units {
+			units[i] = le.Uint16(raw[i*2:])
+		}
+		entry := cfbEntry{
+			name:  string(utf16.Decode(units)),
+			kind:  raw[66],
+			start: le.Uint32(raw[116:]),
+			size:  le.Uint64(raw[120:]),
+		}
+		if cf.sectorSize == 512 {
+			// Version 3 files only use the low 32 bits
+			entry.size &= 0xFFFFFFFF
+		}
+		cf.directoryList = append(cf.directoryList, entry)
+		if _, ok := cf.entries[entry.name]; !ok {
+			cf.entries[entry.name] = entry
+		}
+	}
+	if len(cf.directoryList) == 0 || cf.directoryList[0].kind != 5 {
+		return nil, errors.New("missing root entry")
+	}
+
+	// Small streams live in the mini stream, owned by the root entry
+	root := cf.directoryList[0]
+	if cf.miniStream, err = cf.chain(root.start, root.size); err != nil {
+		return nil, err
+	}
+	miniFAT, err := cf.chain(le.Uint32(data[0x3C:]), 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+4 <= len(miniFAT); i += 4 {
+		cf.miniFAT = append(cf.miniFAT, le.Uint32(miniFAT[i:]))
+	}
+	return cf, nil
+}
+
+// sectorCount is the number of sectors after the header.
+func (cf *compoundFile) sectorCount() int {
+	if n := len(cf.data)/cf.sectorSize - 1; n > 0 {
+		return n
+	}
+	return 0
+}
+
+func (cf *compoundFile) sector(n uint32) ([]byte, error) {
+	off := (int(n) + 1) * cf.sectorSize
+	if n >= cfbMaxSectors || off+cf.sectorSize > len(cf.data) {
+		return nil, fmt.Errorf("sector %d out of range", n)
+	}
+	return cf.data[off : off+cf.sectorSize], nil
+}
+
+// chain reads a FAT sector chain; size 0 reads the whole chain. A chain
+// that revisits a sector is corrupt, so a chain is never longer than the
+// file.
+func (cf *compoundFile) chain(start uint32, size uint64) ([]byte, error) {
+	var buf []byte
+	visited := make([]bool, cf.sectorCount())
+	for s := start; s != cfbEndOfChain && s != cfbFreeSector; {
+		if int(s) >= len(cf.fat) || int(s) >= len(visited) || visited[s] {
+			return nil, errors.New("corrupt sector chain")
+		}
+		visited[s] = true
+		sector, err := cf.sector(s)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, sector...)
+		if size > 0 && uint64(len(buf)) >= size {
+			break
+		}
+		s = cf.fat[s]
+	}
+	if size > 0 && uint64(len(buf)) > size {
+		buf = buf[:size]
+	}
+	return buf, nil
+}
+
+func (cf *compoundFile) miniChain(start uint32, size uint64) ([]byte, error) {
+	var buf []byte
+	visited := make([]bool, len(cf.miniStream)/cf.miniSize)
+	for s := start; s != cfbEndOfChain && s != cfbFreeSector && uint64(len(buf)) < size; {
+		off := int(s) * cf.miniSize
+		if int(s) >= len(cf.miniFAT) || int(s) >= len(visited) || visited[s] {
+			return nil, errors.New("corrupt mini sector chain")
+		}
+		visited[s] = true
+		buf = append(buf, cf.miniStream[off:off+cf.miniSize]...)
+		s = cf.miniFAT[s]
+	}
+	if uint64(len(buf)) > size {
+		buf = buf[:size]
+	}
+	return buf, nil
+}
+
+// stream returns the contents of a named stream.
+func (cf *compoundFile) stream(name string) ([]byte, error) {
+	entry, ok := cf.entries[name]
+	if !ok || entry.kind != 2 {
+		return nil, fmt.Errorf("stream %s not found", name)
+	}
+	if entry.size < uint64(cf.miniCutoff) {
+		return cf.miniChain(entry.start, entry.size)
+	}
+	return cf.chain(entry.start, entry.size)
+}
+
+// wordFIB holds the File Information Block fields needed to find the text.
+type wordFIB struct {
+	encrypted bool
+	table     string
+	ccpText   int
+	fcClx     uint32
+	lcbClx    uint32
+}
+
+func readWordFIB(stream []byte) (*wordFIB, error) {
+	le := binary.LittleEndian
+	if len(stream) < 0x1AA || le.Uint16(stream) != 0xA5EC {
+		return nil, errors.New("not a Word document")
+	}
+	flags := le.Uint16(stream[0x0A:])
+	fib := &wordFIB{
+		encrypted: flags&0x0100 != 0,
+		table:     "0Table",
+		ccpText:   int(int32(le.Uint32(stream[0x4C:]))),
+		fcClx:     le.Uint32(stream[0x1A2:]),
+		lcbClx:    le.Uint32(stream[0x1A6:]),
+	}
+	if flags&0x0200 != 0 {
+		fib.table = "1Table"
+	}
+	return fib, nil
+}
+
+// extractTextFromDOC extracts the main document text of a Word 97-2003
+// file through its piece table. Files that cannot be read that way fall
+// back to collecting runs of readable text.
+func extractTextFromDOC(content []byte) (string, error) {
+	text, err := extractDOCPieces(content)
+	if err != nil {
+		if errors.Is(err, errDOCEncrypted) {
+			return "", err
+		}
+		text = scavengeText(content)
+		if text == "" {
+			return "", err
+		}
+	}
+	return text, nil
+}
+
+var errDOCEncrypted = errors.New("document is encrypted")
+
+func extractDOCPieces(content []byte) (string, error) {
+	cf, err := openCompoundFile(content)
+	if err != nil {
+		return "", err
+	}
+	word, err := cf.stream("WordDocument")
+	if err != nil {
+		return "", err
+	}
+	fib, err := readWordFIB(word)
+	if err != nil {
+		return "", err
+	}
+	if fib.encrypted {
+		return "", errDOCEncrypted
+	}
+	table, err := cf.stream(fib.table)
+	if err != nil {
+		return "", err
+	}
+	if uint64(fib.fcClx)+uint64(fib.lcbClx) > uint64(len(table)) || fib.lcbClx == 0 {
+		return "", errors.New("piece table out of range")
+	}
+	clx := table[fib.fcClx : fib.fcClx+fib.lcbClx]
+
+	plc, err := docPieceTable(clx)
+	if err != nil {
+		return "", err
+	}
+	le := binary.LittleEndian
+	lcb := len(plc)
+	pieces := (lcb - 4) / 12
+
+	var buf strings.Builder
+	remaining := fib.ccpText
+	for i := 0; i < pieces && remaining > 0; i++ {
+		cpStart := int(le.Uint32(plc[i*4:]))
+		cpEnd := int(le.Uint32(plc[(i+1)*4:]))
+		pcd := plc[(pieces+1)*4+i*8:]
+		fc := le.Uint32(pcd[2:])
+		chars := cpEnd - cpStart
+		if chars <= 0 {
+			continue
+		}
+		if chars > remaining {
+			chars = remaining
+		}
+		remaining -= chars
+
+		if fc&0x40000000 != 0 {
+			// Compressed: one Windows-1252 byte per character
+			off := int(fc&0x3FFFFFFF) / 2
+			if off+chars > len(word) {
+				return "", errors.New("piece out of range")
+			}
+			decoded, _ := charmap.Windows1252.NewDecoder().Bytes(word[off : off+chars])
+			buf.Write(decoded)
+		} else {
+			off := int(fc)
+			if off+chars*2 > len(word) {
+				return "", errors.New("piece out of range")
+			}
+			units := make([]uint16, chars)
+			for j := range units {
+				units[j] = le.Uint16(word[off+j*2:])
+			}
+			buf.WriteString(string(utf16.Decode(units)))
+		}
+	}
+	return cleanWordText(buf.String()), nil
+}
+
+// docPieceTable finds the PlcPcd, the piece descriptors, in a Clx by
+// skipping the Prc entries (0x01) before the Pcdt (0x02).
+func docPieceTable(clx []byte) ([]byte, error) {
+	le := binary.LittleEndian
+	pos := 0
+	for pos < len(clx) && clx[pos] == 0x01 {
+		if pos+3 > len(clx) {
+			return nil, errors.New("corrupt piece table")
+		}
+		// cbGrpprl is signed in the file format but never negative
+		cb := int(int16(le.Uint16(clx[pos+1:])))
+		if cb < 0 {
+			return nil, errors.New("corrupt piece table")
+		}
+		pos += 3 + cb
+	}
+	if pos+5 > len(clx) || clx[pos] != 0x02 {
+		return nil, errors.New("piece table not found")
+	}
+	lcb := int(le.Uint32(clx[pos+1:]))
+	plc := clx[pos+5:]
+	if lcb > len(plc) || lcb < 4 {
+		return nil, errors.New("corrupt piece table")
+	}
+	return plc[:lcb], nil
+}
+
+// cleanWordText converts Word's special characters to plain text and drops
+// field instructions, keeping field results.
+func cleanWordText(text string) string {
+	var buf strings.Builder
+	fieldDepth, inInstruction := 0, []bool{}
+	for _, r := range text {
+		switch r {
+		case 0x13: // field begin
+			fieldDepth++
+			inInstruction = append(inInstruction, true)
+			continue
+		case 0x14: // field separator
+			if fieldDepth > 0 {
+				inInstruction[fieldDepth-1] = false
+			}
+			continue
+		case 0x15: // field end
+			if fieldDepth > 0 {
+				fieldDepth--
+				inInstruction = inInstruction[:fieldDepth]
+			}
+			continue
+		}
+		if fieldDepth > 0 && inInstruction[fieldDepth-1] {
+			continue
+		}
+		switch r {
+		case '\r', 0x0B, 0x0C, 0x0E:
+			buf.WriteRune('\n')
+		case 0x07:
+			buf.WriteRune('\t')
+		case 0x1E:
+			buf.WriteRune('-')
+		case 0x1F, 0x01, 0x08:
+		case '\t', '\n':
+			buf.WriteRune(r)
+		default:
+			if r >= 0x20 {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// scavengeText collects runs of readable UTF-16 or, failing that,
+// single-byte text from a binary file. It is the last resort for documents
+// whose structure cannot be parsed.
+func scavengeText(content []byte) string {
+	if text := scavengeRuns(content, 2); text != "" {
+		return text
+	}
+	return scavengeRuns(content, 1)
+}
+
+func scavengeRuns(content []byte, width int) string {
+	var lines []string
+	var run []rune
+	flush := func() {
+		if len(run) >= 8 {
+			lines = append(lines, strings.TrimSpace(string(run)))
+		}
+		run = run[:0]
+	}
+	for i := 0; i+width <= len(content); i += width {
+		var unit rune
+		if width == 2 {
+			unit = rune(binary.LittleEndian.Uint16(content[i:]))
+		} else {
+			unit = rune(content[i])
+		}
+		if unit >= 0x20 && unit < 0x7F || width == 2 && unit >= 0xA0 && unit < 0xD800 {
+			run = append(run, unit)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// inspectDOC rejects Word 97-2003 documents that are encrypted or carry
+// macros.
+func inspectDOC(content []byte) (int, error) {
+	cf, err := openCompoundFile(content)
+	if err != nil {
+		return 0, rejectUpload("File is not a readable DOC document")
+	}
+	for _, entry := range cf.directoryList {
+		switch entry.name {
+		case "Macros", "_VBA_PROJECT_CUR", "VBA":
+			return 0, rejectUpload("Documents containing macros are not accepted")
+		}
+	}
+	word, err := cf.stream("WordDocument")
+	if err != nil {
+		return 0, rejectUpload("File is not a readable DOC document")
+	}
+	fib, err := readWordFIB(word)
+	if err != nil {
+		return 0, rejectUpload("File is not a readable DOC document")
+	}
+	if fib.encrypted {
+		return 0, rejectUpload("Password-protected documents are not accepted")
+	}
+	return 0, nil
+}
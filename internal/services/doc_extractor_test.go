@@ -0,0 +1,48 @@
+package services
+
+import "testing"
+
+func TestCompoundFileCyclicChain(t *testing.T) {
+	cf := &compoundFile{
+		data:       make([]byte, 512*4),
+		sectorSize: 512,
+		miniSize:   64,
+		// Sector 0 points to 1 and 1 back to 0
+		fat:        []uint32{1, 0, cfbEndOfChain},
+		miniFAT:    []uint32{0},
+		miniStream: make([]byte, 64),
+	}
+	if _, err := cf.chain(0, 0); err == nil {
+		t.Error("chain followed a cycle without an error")
+	}
+	if _, err := cf.miniChain(0, 1<<20); err == nil {
+		t.Error("miniChain followed a cycle without an error")
+	}
+	if _, err := cf.chain(2, 0); err != nil {
+		t.Errorf("chain of one sector: %v", err)
+	}
+}
+
+func TestDOCPieceTable(t *testing.T) {
+	tests := []struct {
+		name string
+		clx  []byte
+		ok   bool
+	}{
+		{"pcdt only", []byte{0x02, 4, 0, 0, 0, 0, 0, 0, 0}, true},
+		{"prc then pcdt", []byte{0x01, 1, 0, 0xAA, 0x02, 4, 0, 0, 0, 0, 0, 0, 0}, true},
+		// cbGrpprl of -3 would point back at the same Prc forever
+		{"prc of -3", []byte{0x01, 0xFD, 0xFF, 0x02, 4, 0, 0, 0, 0, 0, 0, 0}, false},
+		{"negative prc", []byte{0x01, 0x00, 0x80, 0x02, 4, 0, 0, 0, 0, 0, 0, 0}, false},
+		{"truncated prc", []byte{0x01, 0x05}, false},
+		{"short pcdt", []byte{0x02, 12, 0, 0, 0, 0, 0, 0, 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := docPieceTable(tt.clx)
+			if (err == nil) != tt.ok {
+				t.Errorf("docPieceTable error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// BlockRole is the function of a block of text within a document.
+type BlockRole string
+
+const (
+	BlockHeading   BlockRole = "heading"
+	BlockParagraph BlockRole = "paragraph"
+	BlockListItem  BlockRole = "list_item"
+	BlockPageBreak BlockRole = "page_break"
+)
+
+// Block is one heading, paragraph or list item of an extracted document.
+// Paragraph text keeps its line breaks, since resume entries often put the
+// title, employer and dates on separate lines.
+type Block struct {
+	Role BlockRole `json:"role"`
+	Text string    `json:"text,omitempty"`
+	Page int       `json:"page"`
+	// Column is the zero-based column on multi-column pages, or -1 for
+	// blocks spanning the full page width.
+	Column int `json:"column"`
+	// Level is 1 for top-level headings and 2 for subheadings.
+	Level int `json:"level,omitempty"`
+}
+
+// Document is the structured text of a resume in reading order.
+// Parsers are given its Text; the blocks are stored on the resume version.
+type Document struct {
+	Blocks []Block `json:"blocks"`
+}
+
+// Text renders the document as plain text: headings on their own lines
+// after a blank line, list items as "- " bullets.
+func (d *Document) Text() string {
+	var buf strings.Builder
+	for i, block := range d.Blocks {
+		switch block.Role {
+		case BlockPageBreak:
+			continue
+		case BlockHeading:
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString(block.Text)
+		case BlockListItem:
+			buf.WriteString("- " + block.Text)
+		default:
+			buf.WriteString(block.Text)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// listMarkers start list items in extracted text.
+var listMarkers = []string{"•", "●", "▪", "■", "◦", "○", "·", "►", "▸", "➢", "✓", "✔", "", "-", "–", "—", "*"}
+
+// trimListMarker removes a leading bullet or list number from a line and
+// reports whether there was one.
+func trimListMarker(line string) (string, bool) {
+	for _, marker := range listMarkers {
+		if rest := strings.TrimPrefix(line, marker); rest != line && (rest == "" || unicode.IsSpace([]rune(rest)[0])) {
+			return strings.TrimSpace(rest), true
+		}
+	}
+	// Numbered items such as "1." or "2)"
+	i := 0
+	for i < len(line) && i < 3 && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && i+1 < len(line) && (line[i] == '.' || line[i] == ')') && line[i+1] == ' ' {
+		return strings.TrimSpace(line[i+1:]), true
+	}
+	return line, false
+}
+
+// looksLikeHeading reports whether a line on its own reads as a heading:
+// a known resume section, or a short all-caps line.
+func looksLikeHeading(line string) bool {
+	if _, ok := headingSection(line); ok {
+		return true
+	}
+	if len([]rune(line)) > 40 || strings.HasSuffix(line, ".") || strings.HasSuffix(line, ",") {
+		return false
+	}
+	letters := 0
+	for _, r := range line {
+		if unicode.IsLetter(r) {
+			if unicode.IsLower(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 3
+}
+
+// DocumentFromText builds a document from plain text for formats without
+// layout information. Blank lines separate paragraphs; bullets and
+// heading-like lines become their own blocks.
+func DocumentFromText(text string) *Document {
+	doc := &Document{}
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			doc.Blocks = append(doc.Blocks, Block{Role: BlockParagraph, Text: strings.Join(paragraph, "\n"), Page: 1})
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(normalizeNewlines(text), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flush()
+		case looksLikeHeading(line):
+			flush()
+			doc.Blocks = append(doc.Blocks, Block{Role: BlockHeading, Text: strings.TrimRight(line, ":"), Page: 1, Level: 1})
+		default:
+			if item, ok := trimListMarker(line); ok {
+				flush()
+				doc.Blocks = append(doc.Blocks, Block{Role: BlockListItem, Text: item, Page: 1})
+				continue
+			}
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return doc
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewURLSignerRequiresKey(t *testing.T) {
+	if _, err := NewURLSigner("", time.Minute); err == nil {
+		t.Error("NewURLSigner accepted an empty key")
+	}
+}
+
+func TestURLSigner(t *testing.T) {
+	signer, err := NewURLSigner("secret", 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	const path = "/files/resumes/12"
+	link, expires := signer.Sign(path, 7, now)
+	if !expires.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("expires = %v, want %v", expires, now.Add(15*time.Minute))
+	}
+	signedPath, rawQuery, _ := strings.Cut(link, "?")
+	if signedPath != path {
+		t.Fatalf("link %q is not for %s", link, path)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		change  func(url.Values)
+		at      time.Time
+		wantErr error
+	}{
+		{"valid", path, nil, now, nil},
+		{"at expiry", path, nil, expires, nil},
+		{"expired", path, nil, expires.Add(time.Second), ErrLinkExpired},
+		{"other path", "/files/resumes/13", nil, now, ErrInvalidSignature},
+		{"tampered uid", path, func(q url.Values) { q.Set("uid", "1") }, now, ErrInvalidSignature},
+		{"extended expiry", path, func(q url.Values) { q.Set("expires", "9999999999") }, now, ErrInvalidSignature},
+		{"tampered signature", path, func(q url.Values) {
+			sig := []byte(q.Get("sig"))
+			sig[0] ^= 1
+			q.Set("sig", string(sig))
+		}, now, ErrInvalidSignature},
+		{"missing signature", path, func(q url.Values) { q.Del("sig") }, now, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(rawQuery)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.change != nil {
+				tt.change(query)
+			}
+			uid, err := signer.Verify(tt.path, query, tt.at)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && uid != 7 {
+				t.Errorf("uid = %d, want 7", uid)
+			}
+		})
+	}
+
+	other, _ := NewURLSigner("other secret", 15*time.Minute)
+	query, _ := url.ParseQuery(rawQuery)
+	if _, err := other.Verify(path, query, now); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify with another key = %v, want %v", err, ErrInvalidSignature)
+	}
+}
@@ -0,0 +1,677 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+)
+
+// DuplicateThreshold is the minimum score at which a pair is queued for review.
+const DuplicateThreshold = 0.5
+
+// Signal weights used when scoring a candidate pair. A pair is scored by
+// summing the weights of every signal that matches, capped at 1.
+const (
+	weightResumeHash = 1.0
+	weightEmail      = 0.6
+	weightPhone      = 0.4
+	weightName       = 0.3
+)
+
+// DuplicateDetector finds applicants that are likely the same person.
+type DuplicateDetector struct {
+	DB *gorm.DB
+}
+
+func NewDuplicateDetector(db *gorm.DB) *DuplicateDetector {
+	return &DuplicateDetector{DB: db}
+}
+
+// Start scans for duplicates immediately and then every interval.
+func (d *DuplicateDetector) Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for {
+			if n, err := d.Scan(); err != nil {
+				log.Printf("Duplicate scan failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Duplicate scan queued %d new pairs", n)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// candidateKeys holds the normalized identity signals of one applicant.
+type candidateKeys struct {
+	userID     uint
+	email      []string
+	phone      []string
+	name       []string
+	resumeHash string
+}
+
+// Scan compares all applicants and records pairs scoring at or above
+// DuplicateThreshold. Pairs that were already reviewed are left untouched.
+// It returns the number of newly queued pairs.
+func (d *DuplicateDetector) Scan() (int, error) {
+	var users []models.User
+	if err := d.DB.Preload("Profile").Where("user_type = ?", models.Applicant).Find(&users).Error; err != nil {
+		return 0, err
+	}
+
+	// Bucket applicants by each signal so only pairs sharing at least one
+	// signal are compared.
+	buckets := map[string][]int{}
+	keys := make([]candidateKeys, len(users))
+	for i, u := range users {
+		keys[i] = candidateKeys{
+			userID:     u.ID,
+			email:      nonEmpty(NormalizeEmail(u.Email), NormalizeEmail(u.Profile.Email)),
+			phone:      nonEmpty(NormalizePhone(u.Profile.Phone)),
+			name:       nonEmpty(NormalizeName(u.Name), NormalizeName(u.Profile.Name)),
+			resumeHash: u.Profile.ResumeHash,
+		}
+		for _, k := range keys[i].email {
+			buckets["e:"+k] = append(buckets["e:"+k], i)
+		}
+		for _, k := range keys[i].phone {
+			buckets["p:"+k] = append(buckets["p:"+k], i)
+		}
+		for _, k := range keys[i].name {
+			buckets["n:"+k] = append(buckets["n:"+k], i)
+		}
+		if keys[i].resumeHash != "" {
+			buckets["h:"+keys[i].resumeHash] = append(buckets["h:"+keys[i].resumeHash], i)
+		}
+	}
+
+	seen := map[[2]int]bool{}
+	created := 0
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				pair := [2]int{members[x], members[y]}
+				if pair[0] > pair[1] {
+					pair[0], pair[1] = pair[1], pair[0]
+				}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				score, reasons := ScoreDuplicate(keys[pair[0]], keys[pair[1]])
+				if score < DuplicateThreshold {
+					continue
+				}
+				isNew, err := d.record(keys[pair[0]].userID, keys[pair[1]].userID, score, reasons)
+				if err != nil {
+					return created, err
+				}
+				if isNew {
+					created++
+				}
+			}
+		}
+	}
+	return created, nil
+}
+
+func (d *DuplicateDetector) record(a, b uint, score float64, reasons []string) (bool, error) {
+	if a > b {
+		a, b = b, a
+	}
+
+	var existing models.DuplicateCandidate
+	err := d.DB.Where("user_a_id = ? AND user_b_id = ?", a, b).First(&existing).Error
+	if err == nil {
+		if existing.Status == models.DuplicatePending {
+			return false, d.DB.Model(&existing).Updates(map[string]interface{}{
+				"score":   score,
+				"reasons": strings.Join(reasons, ","),
+			}).Error
+		}
+		return false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	candidate := models.DuplicateCandidate{
+		UserAID: a,
+		UserBID: b,
+		Score:   score,
+		Reasons: strings.Join(reasons, ","),
+		Status:  models.DuplicatePending,
+	}
+	return true, d.DB.Create(&candidate).Error
+}
+
+// ScoreDuplicate returns a similarity score between 0 and 1 for two
+// applicants along with the signals that matched.
+func ScoreDuplicate(a, b candidateKeys) (float64, []string) {
+	score := 0.0
+	var reasons []string
+	if a.resumeHash != "" && a.resumeHash == b.resumeHash {
+		score += weightResumeHash
+		reasons = append(reasons, "resume_hash")
+	}
+	if intersects(a.email, b.email) {
+		score += weightEmail
+		reasons = append(reasons, "email")
+	}
+	if intersects(a.phone, b.phone) {
+		score += weightPhone
+		reasons = append(reasons, "phone")
+	}
+	if intersects(a.name, b.name) {
+		score += weightName
+		reasons = append(reasons, "name")
+	}
+	if score > 1 {
+		score = 1
+	}
+	return score, reasons
+}
+
+var nonDigits = regexp.MustCompile(`\D`)
+var nonLetters = regexp.MustCompile(`[^\p{L}\s]`)
+
+// NormalizeEmail lowercases an address, drops "+tag" suffixes and, for Gmail,
+// dots in the local part.
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return ""
+	}
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + domain
+}
+
+// NormalizePhone keeps the last ten digits of a phone number so that
+// numbers with and without a country code compare equal.
+func NormalizePhone(phone string) string {
+	digits := nonDigits.ReplaceAllString(phone, "")
+	if len(digits) < 7 {
+		return ""
+	}
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
+
+// NormalizeName lowercases a name, strips punctuation and sorts its tokens
+// so "Doe, John" and "John Doe" compare equal.
+func NormalizeName(name string) string {
+	tokens := strings.Fields(nonLetters.ReplaceAllString(strings.ToLower(name), " "))
+	if len(tokens) < 2 {
+		return ""
+	}
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" && !contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, b []string) bool {
+	for _, v := range a {
+		if contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSnapshot records what a merge changed so it can be reverted. Each part
+// of the merge keeps its rows under keys of its own.
+type mergeSnapshot map[string]json.RawMessage
+
+// put stores v under key.
+func (s mergeSnapshot) put(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s[key] = data
+	return nil
+}
+
+// get decodes the value under key into v. Merges made before a part existed
+// have no value for it, and v is left alone.
+func (s mergeSnapshot) get(key string, v interface{}) error {
+	data, ok := s[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// mergeRun is one merge of MergedID into SurvivorID, or its undo.
+type mergeRun struct {
+	SurvivorID uint
+	MergedID   uint
+	// The users' profiles, zero if they have none, and the survivor profile
+	// fields filled from the merged one. These are only set when merging.
+	SurvivorProfileID uint
+	MergedProfileID   uint
+	Filled            []string
+	Snapshot          mergeSnapshot
+}
+
+// mergeStep is one part of a merge. merge changes the merged candidate's rows
+// and records them in the snapshot, and undo reverts that. Either may be nil.
+// Steps run in the order they were registered, after the applications and
+// profile are merged, and are undone in reverse order before them.
+type mergeStep struct {
+	merge func(tx *gorm.DB, run *mergeRun) error
+	undo  func(tx *gorm.DB, run *mergeRun) error
+}
+
+var mergeSteps []mergeStep
+
+// registerMergeStep adds a step to every merge. Features that keep their own
+// data about a candidate register one so merges carry it over.
+func registerMergeStep(step mergeStep) {
+	mergeSteps = append(mergeSteps, step)
+}
+
+// mergeTable is a table of rows a candidate owns through column.
+type mergeTable struct {
+	// name keys the moved rows in the snapshot, and "deleted_" + name the
+	// rows deleted instead
+	name   string
+	model  interface{}
+	column string
+	// unique tables hold at most one row per user and key, or per user when
+	// key is empty
+	unique bool
+	key    string
+}
+
+// registerMergeTable registers a step that moves the table's rows to the
+// survivor, and back on undo. Rows of a unique table that would clash with
+// the survivor's are soft-deleted instead, and restored on undo.
+func registerMergeTable(table mergeTable) {
+	registerMergeStep(mergeStep{merge: table.merge, undo: table.undo})
+}
+
+// newModel returns an empty model of the table. GORM writes updated columns
+// back into the model, so merges do not share one.
+func (t mergeTable) newModel() interface{} {
+	return reflect.New(reflect.TypeOf(t.model).Elem()).Interface()
+}
+
+func (t mergeTable) merge(tx *gorm.DB, run *mergeRun) error {
+	var moved, deleted []uint
+	var err error
+	if t.unique {
+		moved, deleted, err = moveOwned(tx, t.newModel(), t.column, t.key, run.MergedID, run.SurvivorID)
+	} else {
+		moved, err = moveAll(tx, t.newModel(), t.column, run.MergedID, run.SurvivorID)
+	}
+	if err != nil {
+		return err
+	}
+	if err := run.Snapshot.put(t.name, moved); err != nil {
+		return err
+	}
+	if t.unique {
+		return run.Snapshot.put("deleted_"+t.name, deleted)
+	}
+	return nil
+}
+
+func (t mergeTable) undo(tx *gorm.DB, run *mergeRun) error {
+	var moved, deleted []uint
+	if err := run.Snapshot.get(t.name, &moved); err != nil {
+		return err
+	}
+	if err := run.Snapshot.get("deleted_"+t.name, &deleted); err != nil {
+		return err
+	}
+	if len(moved) > 0 {
+		if err := tx.Model(t.newModel()).Where("id IN ?", moved).Update(t.column, run.MergedID).Error; err != nil {
+			return err
+		}
+	}
+	if len(deleted) > 0 {
+		if err := tx.Unscoped().Model(t.newModel()).Where("id IN ?", deleted).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	registerMergeTable(mergeTable{name: "user_tags", model: &models.UserTag{}, column: "user_id", unique: true, key: "tag"})
+	registerMergeTable(mergeTable{name: "pool_members", model: &models.TalentPoolMember{}, column: "user_id", unique: true, key: "pool_id"})
+	registerMergeTable(mergeTable{name: "invitations", model: &models.JobInvitation{}, column: "user_id", unique: true, key: "job_id"})
+	registerMergeTable(mergeTable{name: "messages", model: &models.Message{}, column: "recipient_id"})
+	registerMergeTable(mergeTable{name: "referrals", model: &models.Referral{}, column: "candidate_id"})
+}
+
+// MergeService consolidates duplicate applicants.
+type MergeService struct {
+	DB         *gorm.DB
+	UndoWindow time.Duration
+}
+
+func NewMergeService(db *gorm.DB, undoWindow time.Duration) *MergeService {
+	return &MergeService{DB: db, UndoWindow: undoWindow}
+}
+
+// Merge moves everything owned by mergedID to survivorID and soft-deletes the
+// merged user. Applications to jobs both users applied for are kept on the
+// survivor and the merged user's copy is removed, and likewise for rows
+// unique per user, such as tags and pool memberships. Empty survivor profile
+// fields, including the resume file, are filled from the merged profile.
+func (s *MergeService) Merge(survivorID, mergedID, actorID uint, duplicateID *uint) (*models.CandidateMerge, error) {
+	if survivorID == mergedID {
+		return nil, errors.New("cannot merge a user into itself")
+	}
+
+	var record models.CandidateMerge
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var survivor, merged models.User
+		if err := tx.Preload("Profile").Where("user_type = ?", models.Applicant).First(&survivor, survivorID).Error; err != nil {
+			return fmt.Errorf("survivor not found")
+		}
+		if err := tx.Preload("Profile").Where("user_type = ?", models.Applicant).First(&merged, mergedID).Error; err != nil {
+			return fmt.Errorf("merged user not found")
+		}
+
+		run := &mergeRun{SurvivorID: survivorID, MergedID: mergedID, Snapshot: mergeSnapshot{}}
+
+		// Applications
+		var apps []models.Application
+		if err := tx.Where("applicant_id = ?", mergedID).Find(&apps).Error; err != nil {
+			return err
+		}
+		var moved, deleted []uint
+		for _, app := range apps {
+			var count int64
+			if err := tx.Model(&models.Application{}).Where("applicant_id = ? AND job_id = ?", survivorID, app.JobID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				if err := tx.Delete(&app).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&models.Job{}).Where("id = ?", app.JobID).Update("total_applications", gorm.Expr("total_applications - 1")).Error; err != nil {
+					return err
+				}
+				deleted = append(deleted, app.ID)
+				continue
+			}
+			if err := tx.Model(&app).Update("applicant_id", survivorID).Error; err != nil {
+				return err
+			}
+			moved = append(moved, app.ID)
+		}
+		if err := run.Snapshot.put("applications", moved); err != nil {
+			return err
+		}
+		if err := run.Snapshot.put("deleted_applications", deleted); err != nil {
+			return err
+		}
+
+		// Profile
+		if err := run.Snapshot.put("survivor_profile", survivor.Profile); err != nil {
+			return err
+		}
+		if err := run.Snapshot.put("survivor_had_profile", survivor.Profile.ID != 0); err != nil {
+			return err
+		}
+		run.SurvivorProfileID = survivor.Profile.ID
+		run.MergedProfileID = merged.Profile.ID
+		if merged.Profile.ID != 0 {
+			profile := survivor.Profile
+			profile.UserID = survivorID
+			fills := []struct {
+				field string
+				dst   *string
+				value string
+			}{
+				{"", &profile.ResumeFilePath, merged.Profile.ResumeFilePath},
+				{"", &profile.ResumeHash, merged.Profile.ResumeHash},
+				{models.ProfileFieldSkills, &profile.Skills, merged.Profile.Skills},
+				{models.ProfileFieldEducations, &profile.Education, merged.Profile.Education},
+				{models.ProfileFieldExperiences, &profile.Experience, merged.Profile.Experience},
+				{models.ProfileFieldName, &profile.Name, merged.Profile.Name},
+				{models.ProfileFieldEmail, &profile.Email, merged.Profile.Email},
+				{models.ProfileFieldPhone, &profile.Phone, merged.Profile.Phone},
+				{models.ProfileFieldGitHubURL, &profile.GitHubURL, merged.Profile.GitHubURL},
+				{models.ProfileFieldLinkedInURL, &profile.LinkedInURL, merged.Profile.LinkedInURL},
+				{models.ProfileFieldPortfolioURL, &profile.PortfolioURL, merged.Profile.PortfolioURL},
+			}
+			for _, f := range fills {
+				if *f.dst == "" && f.value != "" {
+					*f.dst = f.value
+					if f.field != "" {
+						run.Filled = append(run.Filled, f.field)
+					}
+				}
+			}
+			if err := tx.Save(&profile).Error; err != nil {
+				return err
+			}
+			run.SurvivorProfileID = profile.ID
+		}
+
+		for _, step := range mergeSteps {
+			if step.merge == nil {
+				continue
+			}
+			if err := step.merge(tx, run); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(&merged).Error; err != nil {
+			return err
+		}
+
+		snapshot, err := json.Marshal(run.Snapshot)
+		if err != nil {
+			return err
+		}
+		record = models.CandidateMerge{
+			SurvivorID:   survivorID,
+			MergedID:     mergedID,
+			DuplicateID:  duplicateID,
+			MergedByID:   actorID,
+			Snapshot:     string(snapshot),
+			UndoDeadline: time.Now().Add(s.UndoWindow),
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+
+		if duplicateID != nil {
+			return tx.Model(&models.DuplicateCandidate{}).Where("id = ?", *duplicateID).
+				Updates(map[string]interface{}{"status": models.DuplicateMerged, "reviewed_by_id": actorID}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Undo reverts a merge that is still within its undo window.
+func (s *MergeService) Undo(mergeID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var record models.CandidateMerge
+		if err := tx.First(&record, mergeID).Error; err != nil {
+			return fmt.Errorf("merge not found")
+		}
+		if record.UndoneAt != nil {
+			return fmt.Errorf("merge has already been undone")
+		}
+		if time.Now().After(record.UndoDeadline) {
+			return fmt.Errorf("undo window has expired")
+		}
+
+		run := &mergeRun{SurvivorID: record.SurvivorID, MergedID: record.MergedID}
+		if err := json.Unmarshal([]byte(record.Snapshot), &run.Snapshot); err != nil {
+			return err
+		}
+		mergedID := record.MergedID
+
+		for i := len(mergeSteps) - 1; i >= 0; i-- {
+			if mergeSteps[i].undo == nil {
+				continue
+			}
+			if err := mergeSteps[i].undo(tx, run); err != nil {
+				return err
+			}
+		}
+
+		// Applications
+		var moved, deleted []uint
+		if err := run.Snapshot.get("applications", &moved); err != nil {
+			return err
+		}
+		if err := run.Snapshot.get("deleted_applications", &deleted); err != nil {
+			return err
+		}
+		if len(moved) > 0 {
+			if err := tx.Model(&models.Application{}).Where("id IN ?", moved).Update("applicant_id", mergedID).Error; err != nil {
+				return err
+			}
+		}
+		if len(deleted) > 0 {
+			if err := tx.Unscoped().Model(&models.Application{}).Where("id IN ?", deleted).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+			var jobIDs []uint
+			if err := tx.Model(&models.Application{}).Where("id IN ?", deleted).Pluck("job_id", &jobIDs).Error; err != nil {
+				return err
+			}
+			for _, jobID := range jobIDs {
+				if err := tx.Model(&models.Job{}).Where("id = ?", jobID).Update("total_applications", gorm.Expr("total_applications + 1")).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Profile
+		var survivorProfile models.Profile
+		var survivorHadProfile bool
+		if err := run.Snapshot.get("survivor_profile", &survivorProfile); err != nil {
+			return err
+		}
+		if err := run.Snapshot.get("survivor_had_profile", &survivorHadProfile); err != nil {
+			return err
+		}
+		if survivorHadProfile {
+			if err := tx.Save(&survivorProfile).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Unscoped().Where("user_id = ?", record.SurvivorID).Delete(&models.Profile{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Model(&models.User{}).Where("id = ?", mergedID).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&record).Update("undone_at", now).Error; err != nil {
+			return err
+		}
+		if record.DuplicateID != nil {
+			return tx.Model(&models.DuplicateCandidate{}).Where("id = ?", *record.DuplicateID).
+				Update("status", models.DuplicatePending).Error
+		}
+		return nil
+	})
+}
+
+// moveOwned re-points the rows of model owned by from to to and returns
+// their IDs. Rows that would clash with a row of to on key are soft-deleted
+// instead and returned separately. An empty key means a user has at most one
+// row.
+func moveOwned(tx *gorm.DB, model interface{}, column, key string, from, to uint) ([]uint, []uint, error) {
+	var moved, deleted []uint
+	clash := tx.Model(model).Where(column+" = ?", from)
+	if key != "" {
+		clash = clash.Where(key+" IN (?)", tx.Model(model).Select(key).Where(column+" = ?", to))
+	} else {
+		var count int64
+		if err := tx.Model(model).Where(column+" = ?", to).Count(&count).Error; err != nil {
+			return nil, nil, err
+		}
+		if count == 0 {
+			clash = clash.Where("1 = 0")
+		}
+	}
+	if err := clash.Pluck("id", &deleted).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(deleted) > 0 {
+		if err := tx.Where("id IN ?", deleted).Delete(model).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+
+	moved, err := moveAll(tx, model, column, from, to)
+	return moved, deleted, err
+}
+
+// moveAll re-points every row of model owned by from to to and returns their
+// IDs.
+func moveAll(tx *gorm.DB, model interface{}, column string, from, to uint) ([]uint, error) {
+	var ids []uint
+	if err := tx.Model(model).Where(column+" = ?", from).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		if err := tx.Model(model).Where("id IN ?", ids).Update(column, to).Error; err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
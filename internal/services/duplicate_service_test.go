@@ -0,0 +1,272 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+	"gorm.io/gorm"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{" Jane.Doe+jobs@Example.com ", "jane.doe@example.com"},
+		{"jane.doe@gmail.com", "janedoe@gmail.com"},
+		{"Jane.Doe+x@googlemail.com", "janedoe@gmail.com"},
+		{"@example.com", ""},
+		{"not an email", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.in); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"+1 (555) 123-4567", "5551234567"},
+		{"555.123.4567", "5551234567"},
+		{"123-4567", "1234567"},
+		{"12345", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizePhone(tt.in); got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"John Doe", "doe john"},
+		{"Doe, John", "doe john"},
+		{"  JOHN   doe. ", "doe john"},
+		{"Madonna", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.in); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScoreDuplicate(t *testing.T) {
+	jane := candidateKeys{email: []string{"jane@example.com"}, phone: []string{"5551234567"}, name: []string{"doe jane"}, resumeHash: "abc"}
+	tests := []struct {
+		name        string
+		a, b        candidateKeys
+		wantScore   float64
+		wantReasons []string
+	}{
+		{"nothing shared", jane, candidateKeys{email: []string{"john@example.com"}}, 0, nil},
+		{"name only", jane, candidateKeys{name: []string{"doe jane"}}, weightName, []string{"name"}},
+		{"phone and name", jane, candidateKeys{phone: []string{"5551234567"}, name: []string{"doe jane"}}, weightPhone + weightName, []string{"phone", "name"}},
+		{"capped at one", jane, candidateKeys{email: []string{"jane@example.com"}, phone: []string{"5551234567"}}, 1, []string{"email", "phone"}},
+		{"same resume", jane, candidateKeys{resumeHash: "abc"}, 1, []string{"resume_hash"}},
+		{"no resume on either", candidateKeys{}, candidateKeys{}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reasons := ScoreDuplicate(tt.a, tt.b)
+			if math.Abs(score-tt.wantScore) > 1e-9 || !reflect.DeepEqual(reasons, tt.wantReasons) {
+				t.Errorf("ScoreDuplicate = %v %v, want %v %v", score, reasons, tt.wantScore, tt.wantReasons)
+			}
+		})
+	}
+}
+
+// mergeFixture is two applicants who both applied to a shared job, share a
+// tag and have profiles. The merged one also has rows of their own.
+type mergeFixture struct {
+	db       *gorm.DB
+	survivor models.User
+	merged   models.User
+	shared   models.Job
+	// other is a job only the merged applicant applied to
+	other models.Job
+}
+
+func newMergeFixture(t *testing.T) *mergeFixture {
+	t.Helper()
+	db := testdb.Open(t, &models.User{}, &models.Profile{}, &models.ProfileFieldSource{}, &models.Job{}, &models.Application{},
+		&models.UserTag{}, &models.TalentPoolMember{}, &models.JobInvitation{}, &models.Message{}, &models.Referral{},
+		&models.ResumeJob{}, &models.ResumeVersion{}, &models.DownloadAudit{}, &models.Embedding{},
+		&models.SavedSearch{}, &models.AlertPreference{}, &models.JobAlert{}, &models.UserPermission{},
+		&models.DuplicateCandidate{}, &models.CandidateMerge{})
+	f := &mergeFixture{db: db}
+
+	admin := models.User{Name: "Admin", Email: "admin@example.com", UserType: models.Admin}
+	mustCreate(t, db, &admin)
+	f.survivor = models.User{Name: "Jane Doe", Email: "jane@example.com", UserType: models.Applicant,
+		Profile: models.Profile{Name: "Jane Doe", Email: "jane@example.com"}}
+	f.merged = models.User{Name: "Jane Doe", Email: "jane.doe@example.com", UserType: models.Applicant,
+		Profile: models.Profile{Name: "J. Doe", Phone: "555 123 4567", Skills: "Go"}}
+	mustCreate(t, db, &f.survivor)
+	mustCreate(t, db, &f.merged)
+
+	f.shared = models.Job{Title: "Go Developer", Description: "Go", CompanyName: "Acme", PostedByID: admin.ID, TotalApplications: 2}
+	f.other = models.Job{Title: "Designer", Description: "Figma", CompanyName: "Acme", PostedByID: admin.ID, TotalApplications: 1}
+	mustCreate(t, db, &f.shared)
+	mustCreate(t, db, &f.other)
+	mustCreate(t, db, &models.Application{ApplicantID: f.survivor.ID, JobID: f.shared.ID, Stage: models.StageApplied})
+	mustCreate(t, db, &models.Application{ApplicantID: f.merged.ID, JobID: f.shared.ID, Stage: models.StageApplied})
+	mustCreate(t, db, &models.Application{ApplicantID: f.merged.ID, JobID: f.other.ID, Stage: models.StageApplied})
+
+	mustCreate(t, db, &models.UserTag{UserID: f.survivor.ID, Tag: "go"})
+	mustCreate(t, db, &models.UserTag{UserID: f.merged.ID, Tag: "go"})
+	mustCreate(t, db, &models.UserTag{UserID: f.merged.ID, Tag: "remote"})
+	mustCreate(t, db, &models.Message{RecipientID: f.merged.ID, SenderID: admin.ID, Body: "Hello"})
+	mustCreate(t, db, &models.Referral{JobID: f.other.ID, ReferrerID: admin.ID, CandidateName: "Jane", CandidateEmail: "jane.doe@example.com",
+		Method: models.ReferralInvite, Token: "token", CandidateID: &f.merged.ID})
+	mustCreate(t, db, &models.ResumeVersion{UserID: f.survivor.ID, FileName: "jane.pdf", FilePath: "resumes/a", IsPrimary: true})
+	mustCreate(t, db, &models.ResumeVersion{UserID: f.merged.ID, FileName: "jane-doe.pdf", FilePath: "resumes/b", IsPrimary: true})
+	mustCreate(t, db, &models.DownloadAudit{ResumeVersionID: 2, ApplicantID: f.merged.ID, UserID: admin.ID, Method: models.DownloadDirect})
+	mustCreate(t, db, &models.SavedSearch{UserID: f.merged.ID, Name: "Go jobs", Keywords: "go", Frequency: models.AlertDaily})
+	mustCreate(t, db, &models.AlertPreference{UserID: f.survivor.ID, Recommendations: true, MinSimilarity: 0.5, Frequency: models.AlertDaily})
+	mustCreate(t, db, &models.AlertPreference{UserID: f.merged.ID, Recommendations: true, MinSimilarity: 0.2, Frequency: models.AlertInstant})
+	mustCreate(t, db, &models.JobAlert{UserID: f.survivor.ID, JobID: f.shared.ID, Reason: models.AlertReasonRecommendation, Frequency: models.AlertDaily})
+	mustCreate(t, db, &models.JobAlert{UserID: f.merged.ID, JobID: f.shared.ID, Reason: models.AlertReasonRecommendation, Frequency: models.AlertInstant})
+	mustCreate(t, db, &models.JobAlert{UserID: f.merged.ID, JobID: f.other.ID, Reason: models.AlertReasonRecommendation, Frequency: models.AlertInstant})
+	return f
+}
+
+// mergeState describes who owns what, for comparing before a merge and after
+// its undo.
+func mergeState(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var state []string
+	tables := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.Application{}, "applicant_id"},
+		{&models.UserTag{}, "user_id"},
+		{&models.Message{}, "recipient_id"},
+		{&models.Referral{}, "candidate_id"},
+		{&models.ResumeVersion{}, "user_id"},
+		{&models.DownloadAudit{}, "applicant_id"},
+		{&models.SavedSearch{}, "user_id"},
+		{&models.AlertPreference{}, "user_id"},
+		{&models.JobAlert{}, "user_id"},
+		{&models.ProfileFieldSource{}, "profile_id"},
+	}
+	for _, table := range tables {
+		var rows []struct {
+			ID    uint
+			Owner uint
+		}
+		if err := db.Model(table.model).Select("id, " + table.column + " AS owner").Order("id").Scan(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			state = append(state, fmt.Sprintf("%T %d: %d", table.model, row.ID, row.Owner))
+		}
+	}
+
+	var users []models.User
+	db.Preload("Profile").Order("id").Find(&users)
+	for _, u := range users {
+		state = append(state, fmt.Sprintf("user %d: %q %q %q", u.ID, u.Profile.Name, u.Profile.Phone, u.Profile.Skills))
+	}
+	var jobs []models.Job
+	db.Order("id").Find(&jobs)
+	for _, job := range jobs {
+		state = append(state, fmt.Sprintf("job %d: %d applications", job.ID, job.TotalApplications))
+	}
+	var versions []models.ResumeVersion
+	db.Order("id").Find(&versions)
+	for _, v := range versions {
+		state = append(state, fmt.Sprintf("version %d: primary %v", v.ID, v.IsPrimary))
+	}
+	return state
+}
+
+func TestMergeUndo(t *testing.T) {
+	f := newMergeFixture(t)
+	before := mergeState(t, f.db)
+	merges := NewMergeService(f.db, time.Hour)
+
+	record, err := merges.Merge(f.survivor.ID, f.merged.ID, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	f.db.Model(&models.User{}).Where("id = ?", f.merged.ID).Count(&count)
+	if count != 0 {
+		t.Error("merged user still exists")
+	}
+	f.db.Model(&models.Application{}).Where("applicant_id = ?", f.survivor.ID).Count(&count)
+	if count != 2 {
+		t.Errorf("survivor has %d applications, want 2", count)
+	}
+	var shared models.Job
+	f.db.First(&shared, f.shared.ID)
+	if shared.TotalApplications != 1 {
+		t.Errorf("shared job has %d applications, want 1", shared.TotalApplications)
+	}
+	var tags []string
+	f.db.Model(&models.UserTag{}).Where("user_id = ?", f.survivor.ID).Order("tag").Pluck("tag", &tags)
+	if !reflect.DeepEqual(tags, []string{"go", "remote"}) {
+		t.Errorf("survivor tags = %v, want [go remote]", tags)
+	}
+	f.db.Model(&models.Message{}).Where("recipient_id = ?", f.survivor.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("survivor has %d messages, want 1", count)
+	}
+	var profile models.Profile
+	f.db.Where("user_id = ?", f.survivor.ID).First(&profile)
+	if profile.Name != "Jane Doe" || profile.Phone != "555 123 4567" || profile.Skills != "Go" {
+		t.Errorf("survivor profile = %q %q %q, want the name kept and the phone and skills filled", profile.Name, profile.Phone, profile.Skills)
+	}
+
+	var alertJobs []uint
+	f.db.Model(&models.JobAlert{}).Where("user_id = ?", f.survivor.ID).Order("job_id").Pluck("job_id", &alertJobs)
+	var minSimilarity []float64
+	f.db.Model(&models.AlertPreference{}).Where("user_id = ?", f.survivor.ID).Pluck("min_similarity", &minSimilarity)
+	f.db.Model(&models.SavedSearch{}).Where("user_id = ?", f.survivor.ID).Count(&count)
+	if count != 1 || !reflect.DeepEqual(alertJobs, []uint{f.shared.ID, f.other.ID}) || !reflect.DeepEqual(minSimilarity, []float64{0.5}) {
+		t.Errorf("survivor has %d saved searches, alerts for jobs %v and preferences %v, want 1, [%d %d] and their own [0.5]",
+			count, alertJobs, minSimilarity, f.shared.ID, f.other.ID)
+	}
+
+	var primaries []string
+	f.db.Model(&models.ResumeVersion{}).Where("user_id = ? AND is_primary = ?", f.survivor.ID, true).Pluck("file_name", &primaries)
+	f.db.Model(&models.ResumeVersion{}).Where("user_id = ?", f.survivor.ID).Count(&count)
+	if count != 2 || !reflect.DeepEqual(primaries, []string{"jane.pdf"}) {
+		t.Errorf("survivor has %d resumes with primary %v, want 2 with primary [jane.pdf]", count, primaries)
+	}
+
+	if err := merges.Undo(record.ID); err != nil {
+		t.Fatal(err)
+	}
+	if after := mergeState(t, f.db); !reflect.DeepEqual(after, before) {
+		t.Errorf("after undo:\n%v\nwant:\n%v", after, before)
+	}
+	if err := merges.Undo(record.ID); err == nil {
+		t.Error("second Undo succeeded")
+	}
+}
+
+func TestMergeUndoExpired(t *testing.T) {
+	f := newMergeFixture(t)
+	merges := NewMergeService(f.db, -time.Minute)
+	record, err := merges.Merge(f.survivor.ID, f.merged.ID, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merges.Undo(record.ID); err == nil {
+		t.Error("Undo after the window succeeded")
+	}
+}
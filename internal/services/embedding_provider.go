@@ -0,0 +1,287 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/config"
+)
+
+// EmbeddingProvider turns texts into vectors whose cosine similarity
+// reflects how related the texts are.
+type EmbeddingProvider interface {
+	// Model identifies the vector space; vectors from different models
+	// cannot be compared.
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbeddingProvider builds the provider named by cfg.EmbeddingProvider:
+// local, openai or gemini.
+func NewEmbeddingProvider(cfg config.Config) (EmbeddingProvider, error) {
+	httpClient := &http.Client{}
+	switch strings.ToLower(cfg.EmbeddingProvider) {
+	case "local", "":
+		return NewLocalEmbedder(cfg.EmbeddingDimensions), nil
+	case "openai":
+		return &OpenAIEmbedder{
+			APIKey:       cfg.OpenAIAPIKey,
+			BaseURL:      strings.TrimRight(cfg.OpenAIAPIURL, "/"),
+			ModelName:    embeddingModel(cfg.EmbeddingModel, "text-embedding-3-small"),
+			Timeout:      cfg.LLMTimeout,
+			MaxRetries:   cfg.LLMMaxRetries,
+			RetryBackoff: cfg.LLMRetryBackoff,
+			HTTPClient:   httpClient,
+		}, nil
+	case "gemini":
+		return &GeminiEmbedder{
+			APIKey:       cfg.GeminiAPIKey,
+			BaseURL:      strings.TrimRight(cfg.GeminiAPIURL, "/"),
+			ModelName:    embeddingModel(cfg.EmbeddingModel, "text-embedding-004"),
+			Timeout:      cfg.LLMTimeout,
+			MaxRetries:   cfg.LLMMaxRetries,
+			RetryBackoff: cfg.LLMRetryBackoff,
+			HTTPClient:   httpClient,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown embedding provider: %s", cfg.EmbeddingProvider)
+}
+
+func embeddingModel(model, def string) string {
+	if model == "" {
+		return def
+	}
+	return model
+}
+
+// LocalEmbedder hashes words and character trigrams into a fixed number of
+// dimensions. It needs no network and always gives the same vector for the
+// same text, which makes it suitable for tests and offline use. It captures
+// shared vocabulary, not meaning: "Golang" and "Go" are close only because
+// they share trigrams.
+type LocalEmbedder struct {
+	Dimensions int
+}
+
+func NewLocalEmbedder(dimensions int) *LocalEmbedder {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &LocalEmbedder{Dimensions: dimensions}
+}
+
+func (e *LocalEmbedder) Model() string {
+	return fmt.Sprintf("local-hash-%d", e.Dimensions)
+}
+
+var embeddingToken = regexp.MustCompile(`[\pL\pN+#]+`)
+
+func (e *LocalEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, e.Dimensions)
+		for _, token := range embeddingToken.FindAllString(strings.ToLower(text), -1) {
+			e.add(vector, token, 1)
+			padded := []rune("^" + token + "$")
+			for j := 0; j+3 <= len(padded); j++ {
+				e.add(vector, string(padded[j:j+3]), 0.5)
+			}
+		}
+		normalizeVector(vector)
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// add hashes a feature to a dimension and a sign, so unrelated features
+// tend to cancel out rather than pile up.
+func (e *LocalEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New32a()
+	h.Write([]byte(feature))
+	sum := h.Sum32()
+	if sum&1 == 1 {
+		weight = -weight
+	}
+	vector[int((sum>>1)%uint32(e.Dimensions))] += weight
+}
+
+// normalizeVector scales a vector to unit length, so cosine similarity is a
+// dot product.
+func normalizeVector(vector []float32) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vector {
+		vector[i] *= scale
+	}
+}
+
+// OpenAIEmbedder calls an OpenAI-compatible /v1/embeddings endpoint.
+type OpenAIEmbedder struct {
+	APIKey       string
+	BaseURL      string
+	ModelName    string
+	Timeout      time.Duration
+	MaxRetries   int
+	RetryBackoff time.Duration
+	HTTPClient   *http.Client
+}
+
+func (e *OpenAIEmbedder) Model() string {
+	return e.ModelName
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	payload, err := json.Marshal(map[string]interface{}{"model": e.ModelName, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{}
+	if e.APIKey != "" {
+		headers["Authorization"] = "Bearer " + e.APIKey
+	}
+
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	err = postEmbeddingRequest(ctx, e.HTTPClient, e.BaseURL+"/embeddings", headers, payload,
+		e.Timeout, e.MaxRetries, e.RetryBackoff, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range response.Data {
+		if item.Index >= 0 && item.Index < len(vectors) {
+			vectors[item.Index] = item.Embedding
+		}
+	}
+	return checkEmbeddings(vectors)
+}
+
+// GeminiEmbedder calls the Generative Language batchEmbedContents API.
+type GeminiEmbedder struct {
+	APIKey       string
+	BaseURL      string
+	ModelName    string
+	Timeout      time.Duration
+	MaxRetries   int
+	RetryBackoff time.Duration
+	HTTPClient   *http.Client
+}
+
+func (e *GeminiEmbedder) Model() string {
+	return e.ModelName
+}
+
+func (e *GeminiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	type part struct {
+		Text string `json:"text"`
+	}
+	type content struct {
+		Parts []part `json:"parts"`
+	}
+	type request struct {
+		Model   string  `json:"model"`
+		Content content `json:"content"`
+	}
+	var requests []request
+	for _, text := range texts {
+		requests = append(requests, request{Model: "models/" + e.ModelName, Content: content{Parts: []part{{Text: text}}}})
+	}
+	payload, err := json.Marshal(map[string]interface{}{"requests": requests})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+	url := fmt.Sprintf("%s/models/%s:batchEmbedContents", e.BaseURL, e.ModelName)
+	err = postEmbeddingRequest(ctx, e.HTTPClient, url, map[string]string{"x-goog-api-key": e.APIKey}, payload,
+		e.Timeout, e.MaxRetries, e.RetryBackoff, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i := range response.Embeddings {
+		if i < len(vectors) {
+			vectors[i] = response.Embeddings[i].Values
+		}
+	}
+	return checkEmbeddings(vectors)
+}
+
+// postEmbeddingRequest posts a JSON payload and decodes the answer,
+// retrying rate limits, server errors and timeouts like the LLM clients.
+func postEmbeddingRequest(ctx context.Context, client *http.Client, url string, headers map[string]string, payload []byte,
+	timeout time.Duration, maxRetries int, backoff time.Duration, v interface{}) error {
+	body, err := withRetries(ctx, maxRetries, backoff, func() ([]byte, error) {
+		ctx := ctx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, newAPIError(resp, body)
+		}
+		return body, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid embedding response: %v", err)
+	}
+	return nil
+}
+
+// checkEmbeddings makes sure every text got a vector and normalizes them.
+func checkEmbeddings(vectors [][]float32) ([][]float32, error) {
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("embedding response is missing vector %d", i)
+		}
+		normalizeVector(vector)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// embeddingBatchSize bounds the texts sent to a provider in one call.
+const embeddingBatchSize = 32
+
+// embeddingSyncOverlap is how far back each sync looks before the start of
+// the last one, to catch changes committed while it ran.
+const embeddingSyncOverlap = time.Minute
+
+// Similar is a job or candidate with its cosine similarity to a query.
+type Similar struct {
+	ID         uint
+	Similarity float64
+}
+
+// embeddedVector is an indexed vector with the hash of the text behind it.
+type embeddedVector struct {
+	hash   string
+	vector []float32
+}
+
+// EmbeddingService keeps embeddings of job and candidate text in the
+// database and an in-process index of them for similarity search. The index
+// is searched by brute force, which is fast enough for tens of thousands of
+// records.
+type EmbeddingService struct {
+	DB       *gorm.DB
+	Provider EmbeddingProvider
+
+	mu      sync.RWMutex
+	vectors map[string]map[uint]embeddedVector
+	// synced is when the last successful sync started, zero before the
+	// first one.
+	synced time.Time
+}
+
+func init() {
+	registerMergeStep(mergeStep{merge: dropMergedEmbedding})
+}
+
+func NewEmbeddingService(db *gorm.DB, provider EmbeddingProvider) *EmbeddingService {
+	return &EmbeddingService{DB: db, Provider: provider, vectors: map[string]map[uint]embeddedVector{}}
+}
+
+// Start loads the stored embeddings and then embeds new and changed jobs
+// and candidates immediately and every interval.
+func (s *EmbeddingService) Start(interval time.Duration) {
+	if err := s.Load(); err != nil {
+		log.Printf("Failed to load embeddings: %v", err)
+	}
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for {
+			if n, err := s.Sync(context.Background()); err != nil {
+				log.Printf("Embedding sync failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Embedding sync embedded %d records", n)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// Load fills the index with the stored embeddings of the current model. The
+// next sync then reads every record, to drop those deleted since.
+func (s *EmbeddingService) Load() error {
+	var rows []models.Embedding
+	if err := s.DB.Where("model = ?", s.Provider.Model()).Find(&rows).Error; err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vectors = map[string]map[uint]embeddedVector{}
+	s.synced = time.Time{}
+	for _, row := range rows {
+		s.put(row.OwnerType, row.OwnerID, embeddedVector{hash: row.ContentHash, vector: decodeVector(row.Vector)})
+	}
+	return nil
+}
+
+// embeddingSource is the text of one job or candidate.
+type embeddingSource struct {
+	ownerType string
+	ownerID   uint
+	text      string
+}
+
+// Sync embeds the jobs and candidates whose text changed since they were
+// last embedded, and drops deleted ones from the index. The first sync
+// reads every record; later ones only those updated or deleted since the
+// previous sync. It returns the number of records embedded.
+func (s *EmbeddingService) Sync(ctx context.Context) (int, error) {
+	started := time.Now()
+	s.mu.RLock()
+	since := s.synced
+	s.mu.RUnlock()
+
+	jobQuery := s.DB.Preload("Skills")
+	// Profiles of merged and other deleted users are left out
+	profileQuery := s.DB.Preload("Educations").Preload("Experiences").Preload("ProfileSkills").
+		Joins("JOIN users ON users.id = profiles.user_id AND users.deleted_at IS NULL")
+	if !since.IsZero() {
+		since = since.Add(-embeddingSyncOverlap)
+		jobQuery = jobQuery.Where("jobs.updated_at >= ?", since)
+		// A user restored by undoing a merge is updated, not their profile
+		profileQuery = profileQuery.Where("profiles.updated_at >= ? OR users.updated_at >= ?", since, since)
+	}
+	var jobs []models.Job
+	if err := jobQuery.Find(&jobs).Error; err != nil {
+		return 0, err
+	}
+	var profiles []models.Profile
+	if err := profileQuery.Find(&profiles).Error; err != nil {
+		return 0, err
+	}
+
+	var sources []embeddingSource
+	for i := range jobs {
+		sources = append(sources, embeddingSource{models.EmbeddingOwnerJob, jobs[i].ID, JobEmbeddingText(&jobs[i])})
+	}
+	for i := range profiles {
+		sources = append(sources, embeddingSource{models.EmbeddingOwnerCandidate, profiles[i].UserID, CandidateEmbeddingText(&profiles[i])})
+	}
+	if err := s.prune(since, jobs, profiles); err != nil {
+		return 0, err
+	}
+
+	n, err := s.embed(ctx, sources)
+	if err == nil {
+		s.mu.Lock()
+		s.synced = started
+		s.mu.Unlock()
+	}
+	return n, err
+}
+
+// prune drops deleted jobs and candidates from the index. With a zero since
+// jobs and profiles are every live record and anything else is dropped;
+// otherwise the records deleted since then are looked up.
+func (s *EmbeddingService) prune(since time.Time, jobs []models.Job, profiles []models.Profile) error {
+	if since.IsZero() {
+		live := map[string]map[uint]bool{models.EmbeddingOwnerJob: {}, models.EmbeddingOwnerCandidate: {}}
+		for _, job := range jobs {
+			live[models.EmbeddingOwnerJob][job.ID] = true
+		}
+		for _, profile := range profiles {
+			live[models.EmbeddingOwnerCandidate][profile.UserID] = true
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for ownerType, vectors := range s.vectors {
+			for id := range vectors {
+				if !live[ownerType][id] {
+					delete(vectors, id)
+				}
+			}
+		}
+		return nil
+	}
+
+	var jobIDs, userIDs []uint
+	if err := s.DB.Unscoped().Model(&models.Job{}).Where("deleted_at >= ?", since).Pluck("id", &jobIDs).Error; err != nil {
+		return err
+	}
+	if err := s.DB.Unscoped().Model(&models.User{}).Where("deleted_at >= ?", since).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range jobIDs {
+		delete(s.vectors[models.EmbeddingOwnerJob], id)
+	}
+	for _, id := range userIDs {
+		delete(s.vectors[models.EmbeddingOwnerCandidate], id)
+	}
+	return nil
+}
+
+// dropMergedEmbedding deletes the merged candidate's embedding. Embeddings
+// are derived, so the survivor's is refreshed by the next sync, and the
+// merged candidate's comes back after an undo.
+func dropMergedEmbedding(tx *gorm.DB, run *mergeRun) error {
+	return tx.Unscoped().Where("owner_type = ? AND owner_id = ?", models.EmbeddingOwnerCandidate, run.MergedID).
+		Delete(&models.Embedding{}).Error
+}
+
+// embed computes and stores the vectors of the sources that are not
+// indexed with the same text already.
+func (s *EmbeddingService) embed(ctx context.Context, sources []embeddingSource) (int, error) {
+	var stale []embeddingSource
+	var hashes []string
+	s.mu.RLock()
+	for _, source := range sources {
+		hash := s.contentHash(source.text)
+		if s.vectors[source.ownerType][source.ownerID].hash != hash && strings.TrimSpace(source.text) != "" {
+			stale = append(stale, source)
+			hashes = append(hashes, hash)
+		}
+	}
+	s.mu.RUnlock()
+
+	for start := 0; start < len(stale); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(stale) {
+			end = len(stale)
+		}
+		texts := make([]string, end-start)
+		for i, source := range stale[start:end] {
+			texts[i] = source.text
+		}
+		vectors, err := s.Provider.Embed(ctx, texts)
+		if err != nil {
+			return start, err
+		}
+
+		for i, source := range stale[start:end] {
+			row := models.Embedding{
+				OwnerType:   source.ownerType,
+				OwnerID:     source.ownerID,
+				ModelName:   s.Provider.Model(),
+				ContentHash: hashes[start+i],
+				Vector:      encodeVector(vectors[i]),
+			}
+			err := s.DB.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "owner_type"}, {Name: "owner_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"model", "content_hash", "vector", "updated_at"}),
+			}).Create(&row).Error
+			if err != nil {
+				return start + i, err
+			}
+			s.mu.Lock()
+			s.put(source.ownerType, source.ownerID, embeddedVector{hash: row.ContentHash, vector: vectors[i]})
+			s.mu.Unlock()
+		}
+	}
+	return len(stale), nil
+}
+
+// ErrNothingToEmbed is returned for a job or candidate without text.
+var ErrNothingToEmbed = errors.New("no text to embed")
+
+// JobVector returns the vector of a job, embedding it first if its text
+// changed since the last sync.
+func (s *EmbeddingService) JobVector(ctx context.Context, jobID uint) ([]float32, error) {
+	var job models.Job
+	if err := s.DB.Preload("Skills").First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	return s.vector(ctx, embeddingSource{models.EmbeddingOwnerJob, job.ID, JobEmbeddingText(&job)})
+}
+
+// CandidateVector returns the vector of a candidate's profile, embedding
+// it first if it changed since the last sync.
+func (s *EmbeddingService) CandidateVector(ctx context.Context, userID uint) ([]float32, error) {
+	var profile models.Profile
+	if err := s.DB.Preload("Educations").Preload("Experiences").Preload("ProfileSkills").
+		Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return s.vector(ctx, embeddingSource{models.EmbeddingOwnerCandidate, userID, CandidateEmbeddingText(&profile)})
+}
+
+func (s *EmbeddingService) vector(ctx context.Context, source embeddingSource) ([]float32, error) {
+	if strings.TrimSpace(source.text) == "" {
+		return nil, ErrNothingToEmbed
+	}
+	if _, err := s.embed(ctx, []embeddingSource{source}); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.vectors[source.ownerType][source.ownerID].vector, nil
+}
+
+// Nearest returns up to limit records of ownerType most similar to vector,
+// most similar first, skipping those for which skip returns true.
+func (s *EmbeddingService) Nearest(ownerType string, vector []float32, limit int, skip func(id uint) bool) []Similar {
+	s.mu.RLock()
+	var results []Similar
+	for id, indexed := range s.vectors[ownerType] {
+		if skip != nil && skip(id) {
+			continue
+		}
+		results = append(results, Similar{ID: id, Similarity: cosine(vector, indexed.vector)})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Similarity != results[j].Similarity {
+			return results[i].Similarity > results[j].Similarity
+		}
+		return results[i].ID < results[j].ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (s *EmbeddingService) put(ownerType string, ownerID uint, v embeddedVector) {
+	if s.vectors[ownerType] == nil {
+		s.vectors[ownerType] = map[uint]embeddedVector{}
+	}
+	s.vectors[ownerType][ownerID] = v
+}
+
+// contentHash identifies a text embedded with the current model.
+func (s *EmbeddingService) contentHash(text string) string {
+	sum := sha256.Sum256([]byte(s.Provider.Model() + "\n" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// JobEmbeddingText is the text of a job that gets embedded: its title,
+// description and skills. Skills must be loaded.
+func JobEmbeddingText(job *models.Job) string {
+	parts := []string{job.Title, job.Description}
+	var skills []string
+	for _, skill := range job.Skills {
+		skills = append(skills, skill.Name)
+	}
+	if len(skills) > 0 {
+		parts = append(parts, "Skills: "+strings.Join(skills, ", "))
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// CandidateEmbeddingText is the text of a profile that gets embedded: the
+// positions held, degrees and skills, without personal details.
+func CandidateEmbeddingText(profile *models.Profile) string {
+	var parts []string
+	for _, e := range profile.Experiences {
+		parts = append(parts, joinNonEmpty(" at ", e.Title, e.Company), e.Description)
+	}
+	for _, e := range profile.Educations {
+		parts = append(parts, joinNonEmpty(" in ", e.Degree, e.Field))
+	}
+	if len(profile.Experiences) == 0 && len(profile.Educations) == 0 {
+		parts = append(parts, profile.Experience, profile.Education)
+	}
+	if skills := profileSkillNames(profile); len(skills) > 0 {
+		parts = append(parts, "Skills: "+strings.Join(skills, ", "))
+	}
+
+	var text []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			text = append(text, part)
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
+// cosine is the cosine similarity of two unit vectors; vectors of
+// different lengths are unrelated.
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return math.Round(dot*1000) / 1000
+}
+
+func encodeVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector
+}
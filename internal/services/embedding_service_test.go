@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+)
+
+func TestCosine(t *testing.T) {
+	s := float32(math.Sqrt(0.5))
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"same", []float32{1, 0}, []float32{1, 0}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"opposite", []float32{s, s}, []float32{-s, -s}, -1},
+		{"rounded to three places", []float32{1, 0}, []float32{s, s}, 0.707},
+		{"different lengths", []float32{1, 0}, []float32{1, 0, 0}, 0},
+		{"empty", nil, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := cosine(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: cosine = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeVector(t *testing.T) {
+	for _, vector := range [][]float32{
+		{},
+		{1},
+		{0.25, -0.5, 1e-8, -3.4e38, float32(math.Inf(1))},
+	} {
+		buf := encodeVector(vector)
+		if len(buf) != 4*len(vector) {
+			t.Errorf("encodeVector(%v) is %d bytes, want %d", vector, len(buf), 4*len(vector))
+		}
+		if got := decodeVector(buf); !reflect.DeepEqual(got, vector) {
+			t.Errorf("decodeVector(encodeVector(%v)) = %v", vector, got)
+		}
+	}
+	// Little-endian float32 bits
+	if got := encodeVector([]float32{1}); !reflect.DeepEqual(got, []byte{0, 0, 0x80, 0x3f}) {
+		t.Errorf("encodeVector(1) = %x", got)
+	}
+	// A truncated trailing value is dropped
+	if got := decodeVector([]byte{0, 0, 0x80, 0x3f, 0, 0}); !reflect.DeepEqual(got, []float32{1}) {
+		t.Errorf("decodeVector of 6 bytes = %v, want [1]", got)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	s := NewEmbeddingService(nil, NewLocalEmbedder(2))
+	for id, vector := range map[uint][]float32{
+		1: {0, 1},
+		2: {1, 0},
+		3: {0.6, 0.8},
+		4: {1, 0},
+		5: {-1, 0},
+	} {
+		s.put(models.EmbeddingOwnerCandidate, id, embeddedVector{vector: vector})
+	}
+	s.put(models.EmbeddingOwnerJob, 6, embeddedVector{vector: []float32{1, 0}})
+
+	query := []float32{1, 0}
+	tests := []struct {
+		name  string
+		limit int
+		skip  func(uint) bool
+		want  []Similar
+	}{
+		{"all, ties by ID", 0, nil, []Similar{{2, 1}, {4, 1}, {3, 0.6}, {1, 0}, {5, -1}}},
+		{"limit", 2, nil, []Similar{{2, 1}, {4, 1}}},
+		{"skip", 2, func(id uint) bool { return id == 2 }, []Similar{{4, 1}, {3, 0.6}}},
+	}
+	for _, tt := range tests {
+		if got := s.Nearest(models.EmbeddingOwnerCandidate, query, tt.limit, tt.skip); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Nearest = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := s.Nearest("unknown", query, 0, nil); len(got) != 0 {
+		t.Errorf("Nearest of an unknown owner type = %v", got)
+	}
+}
+
+// countingEmbedder counts the texts it embeds.
+type countingEmbedder struct {
+	*LocalEmbedder
+	texts int
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.texts += len(texts)
+	return e.LocalEmbedder.Embed(ctx, texts)
+}
+
+func TestEmbeddingSync(t *testing.T) {
+	db := testdb.Open(t, &models.User{}, &models.Profile{}, &models.EducationEntry{}, &models.ExperienceEntry{},
+		&models.ProfileSkill{}, &models.Job{}, &models.JobSkill{}, &models.Embedding{})
+	admin := models.User{Name: "Admin", Email: "admin@example.com", UserType: models.Admin}
+	jane := models.User{Name: "Jane", Email: "jane@example.com", UserType: models.Applicant,
+		Profile: models.Profile{Skills: "Go, Docker"}}
+	mustCreate(t, db, &admin)
+	mustCreate(t, db, &jane)
+	goJob := models.Job{Title: "Go Developer", Description: "Go services", CompanyName: "Acme", PostedByID: admin.ID}
+	designJob := models.Job{Title: "Designer", Description: "Figma", CompanyName: "Acme", PostedByID: admin.ID}
+	mustCreate(t, db, &goJob)
+	mustCreate(t, db, &designJob)
+
+	provider := &countingEmbedder{LocalEmbedder: NewLocalEmbedder(64)}
+	s := NewEmbeddingService(db, provider)
+	sync := func(want int) {
+		t.Helper()
+		n, err := s.Sync(context.Background())
+		if err != nil || n != want {
+			t.Fatalf("Sync = %d, %v, want %d", n, err, want)
+		}
+	}
+	indexed := func(ownerType string) []uint {
+		var ids []uint
+		for _, r := range s.Nearest(ownerType, make([]float32, 64), 0, nil) {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	sync(3)
+	sync(0)
+	if provider.texts != 3 {
+		t.Errorf("embedded %d texts, want 3", provider.texts)
+	}
+
+	// A change stamped before the last sync is not read again, a recent one is
+	db.Model(&designJob).UpdateColumns(map[string]interface{}{"title": "UX Designer", "updated_at": time.Now().Add(-time.Hour)})
+	db.Model(&goJob).Update("title", "Senior Go Developer")
+	sync(1)
+
+	db.Delete(&designJob)
+	db.Delete(&jane)
+	sync(0)
+	if jobs, candidates := indexed(models.EmbeddingOwnerJob), indexed(models.EmbeddingOwnerCandidate); !reflect.DeepEqual(jobs, []uint{goJob.ID}) || len(candidates) != 0 {
+		t.Errorf("index holds jobs %v and candidates %v, want only job %d", jobs, candidates, goJob.ID)
+	}
+
+	// A user restored by undoing a merge is embedded again
+	db.Unscoped().Model(&models.User{}).Where("id = ?", jane.ID).Update("deleted_at", nil)
+	sync(1)
+
+	// A reload reads the stored embeddings of deleted jobs too, and the next
+	// sync drops them once more
+	if err := s.Load(); err != nil {
+		t.Fatal(err)
+	}
+	sync(0)
+	if jobs, candidates := indexed(models.EmbeddingOwnerJob), indexed(models.EmbeddingOwnerCandidate); !reflect.DeepEqual(jobs, []uint{goJob.ID}) || !reflect.DeepEqual(candidates, []uint{jane.ID}) {
+		t.Errorf("index holds jobs %v and candidates %v, want job %d and candidate %d", jobs, candidates, goJob.ID, jane.ID)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FakeLLMResponse is one canned answer. It is returned for prompts that
+// contain Match; a response with an empty Match answers any prompt no other
+// response matches. Status or Blocked make the call fail instead.
+type FakeLLMResponse struct {
+	Match        string `json:"match,omitempty"`
+	Text         string `json:"text"`
+	FinishReason string `json:"finish_reason,omitempty"`
+	Status       int    `json:"status,omitempty"`
+	Blocked      string `json:"blocked,omitempty"`
+}
+
+// FakeLLMClient is a deterministic LLMClient that answers from canned
+// responses, for tests and for running without any model. Token counts are
+// estimated from the prompt and answer lengths.
+type FakeLLMClient struct {
+	Model     string
+	Responses []FakeLLMResponse
+
+	mu    sync.Mutex
+	calls []LLMRequest
+}
+
+func NewFakeLLMClient(responses ...FakeLLMResponse) *FakeLLMClient {
+	return &FakeLLMClient{Model: "fake", Responses: responses}
+}
+
+// LoadFakeLLMClient reads a JSON array of FakeLLMResponse from path. An
+// empty path gives a client that answers every prompt with an empty object.
+func LoadFakeLLMClient(path string) (*FakeLLMClient, error) {
+	if path == "" {
+		return NewFakeLLMClient(FakeLLMResponse{Text: "{}"}), nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var responses []FakeLLMResponse
+	if err := json.Unmarshal(content, &responses); err != nil {
+		return nil, fmt.Errorf("invalid fake LLM responses %s: %v", path, err)
+	}
+	return NewFakeLLMClient(responses...), nil
+}
+
+func (c *FakeLLMClient) Name() string {
+	return "fake"
+}
+
+func (c *FakeLLMClient) Generate(ctx context.Context, request LLMRequest) (*LLMResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.calls = append(c.calls, request)
+	c.mu.Unlock()
+
+	prompt := request.System + "\n" + request.User
+	var answer *FakeLLMResponse
+	for i := range c.Responses {
+		r := &c.Responses[i]
+		if r.Match != "" && strings.Contains(prompt, r.Match) {
+			answer = r
+			break
+		}
+		if r.Match == "" && answer == nil {
+			answer = r
+		}
+	}
+	if answer == nil {
+		return nil, fmt.Errorf("no fake response matches the prompt")
+	}
+	if answer.Status != 0 {
+		return nil, &APIError{StatusCode: answer.Status, Body: "fake error"}
+	}
+	if answer.Blocked != "" {
+		return nil, &BlockedError{Reason: answer.Blocked}
+	}
+
+	finish := answer.FinishReason
+	if finish == "" {
+		finish = "stop"
+	}
+	return &LLMResponse{
+		Text:         answer.Text,
+		FinishReason: finish,
+		Partial:      finish != "stop",
+		Provider:     c.Name(),
+		Model:        c.Model,
+		PromptTokens: estimateTokens(prompt),
+		OutputTokens: estimateTokens(answer.Text),
+	}, nil
+}
+
+// Calls returns the requests received so far.
+func (c *FakeLLMClient) Calls() []LLMRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]LLMRequest(nil), c.calls...)
+}
+
+// estimateTokens approximates a token count at four bytes per token.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fixture modes for FixtureTransport.
+const (
+	FixturesReplay = "replay"
+	FixturesRecord = "record"
+)
+
+// httpFixture is one recorded HTTP response. A fixture answers requests
+// whose body hashes to RequestSHA256, or contains Match; a fixture with
+// neither answers any request no other fixture matches. Once fixtures are
+// used a single time, so retries can be replayed as a sequence.
+type httpFixture struct {
+	Match         string          `json:"match,omitempty"`
+	RequestSHA256 string          `json:"request_sha256,omitempty"`
+	Once          bool            `json:"once,omitempty"`
+	Status        int             `json:"status"`
+	Headers       http.Header     `json:"headers,omitempty"`
+	Body          json.RawMessage `json:"body"`
+
+	file string
+	used bool
+}
+
+// FixtureTransport serves HTTP requests from recorded fixture files so LLM
+// clients can run offline. In record mode, requests without a fixture are
+// sent to Next and the responses saved as new fixtures.
+type FixtureTransport struct {
+	Dir  string
+	Mode string
+	Next http.RoundTripper
+
+	mu       sync.Mutex
+	fixtures []*httpFixture
+	loaded   bool
+}
+
+// NewFixtureTransport returns next unchanged when dir is empty.
+func NewFixtureTransport(dir, mode string, next http.RoundTripper) http.RoundTripper {
+	if dir == "" {
+		return next
+	}
+	if mode == "" {
+		mode = FixturesReplay
+	}
+	return &FixtureTransport{Dir: dir, Mode: mode, Next: next}
+}
+
+func (t *FixtureTransport) load() error {
+	if t.loaded {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(t.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var fixture httpFixture
+		if err := json.Unmarshal(content, &fixture); err != nil {
+			return fmt.Errorf("invalid fixture %s: %v", file, err)
+		}
+		fixture.file = file
+		t.fixtures = append(t.fixtures, &fixture)
+	}
+	t.loaded = true
+	return nil
+}
+
+func (t *FixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+
+	t.mu.Lock()
+	if err := t.load(); err != nil {
+		t.mu.Unlock()
+		return nil, err
+	}
+	fixture := t.find(hash, string(body))
+	t.mu.Unlock()
+
+	if fixture != nil {
+		return fixture.response(req), nil
+	}
+	if t.Mode != FixturesRecord {
+		return nil, fmt.Errorf("no fixture in %s for request %s", t.Dir, hash)
+	}
+	return t.record(req, body, hash)
+}
+
+func (t *FixtureTransport) find(hash, body string) *httpFixture {
+	var fallback *httpFixture
+	for _, f := range t.fixtures {
+		if f.Once && f.used {
+			continue
+		}
+		switch {
+		case f.RequestSHA256 == hash, f.Match != "" && strings.Contains(body, f.Match):
+			f.used = true
+			return f
+		case f.RequestSHA256 == "" && f.Match == "" && fallback == nil:
+			fallback = f
+		}
+	}
+	if fallback != nil {
+		fallback.used = true
+	}
+	return fallback
+}
+
+func (f *httpFixture) response(req *http.Request) *http.Response {
+	header := f.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		StatusCode:    f.Status,
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}
+}
+
+// record forwards the request and saves the response as a fixture. API
+// keys travel in headers, which are not recorded.
+func (t *FixtureTransport) record(req *http.Request, body []byte, hash string) (*http.Response, error) {
+	forward := req.Clone(req.Context())
+	forward.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := t.Next.RoundTrip(forward)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(respBody) {
+		respBody, _ = json.Marshal(string(respBody))
+	}
+	fixture := &httpFixture{RequestSHA256: hash, Status: resp.StatusCode, Body: respBody}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		fixture.Headers = http.Header{"Retry-After": {retryAfter}}
+	}
+	fixture.file = filepath.Join(t.Dir, hash[:16]+".json")
+
+	content, _ := json.MarshalIndent(fixture, "", "  ")
+	if err := os.MkdirAll(t.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(fixture.file, append(content, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	log.Printf("Recorded fixture %s", fixture.file)
+
+	t.mu.Lock()
+	t.fixtures = append(t.fixtures, fixture)
+	t.mu.Unlock()
+	return fixture.response(req), nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/config"
+)
+
+// GeminiClient calls the Generative Language API generateContent method.
+// It implements LLMClient.
+type GeminiClient struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+	// Timeout bounds each attempt; retries get a fresh timeout.
+	Timeout      time.Duration
+	MaxRetries   int
+	RetryBackoff time.Duration
+	HTTPClient   *http.Client
+}
+
+func NewGeminiClient(cfg config.Config, httpClient *http.Client) *GeminiClient {
+	return &GeminiClient{
+		APIKey:       cfg.GeminiAPIKey,
+		BaseURL:      strings.TrimRight(cfg.GeminiAPIURL, "/"),
+		Model:        cfg.GeminiModel,
+		Timeout:      cfg.LLMTimeout,
+		MaxRetries:   cfg.LLMMaxRetries,
+		RetryBackoff: cfg.LLMRetryBackoff,
+		HTTPClient:   httpClient,
+	}
+}
+
+func (c *GeminiClient) Name() string {
+	return "gemini"
+}
+
+// geminiSchema is the OpenAPI subset accepted as a response schema. Its
+// types are the upper case names of the API's Type enum.
+type geminiSchema struct {
+	Type       string                   `json:"type"`
+	Properties map[string]*geminiSchema `json:"properties,omitempty"`
+	Items      *geminiSchema            `json:"items,omitempty"`
+	Required   []string                 `json:"required,omitempty"`
+}
+
+func newGeminiSchema(s *LLMSchema) *geminiSchema {
+	if s == nil {
+		return nil
+	}
+	schema := &geminiSchema{
+		Type:     strings.ToUpper(s.Type),
+		Items:    newGeminiSchema(s.Items),
+		Required: s.Required,
+	}
+	if len(s.Properties) > 0 {
+		schema.Properties = map[string]*geminiSchema{}
+		for name, property := range s.Properties {
+			schema.Properties[name] = newGeminiSchema(property)
+		}
+	}
+	return schema
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature      float64       `json:"temperature"`
+	MaxOutputTokens  int           `json:"maxOutputTokens,omitempty"`
+	ResponseMIMEType string        `json:"responseMimeType,omitempty"`
+	ResponseSchema   *geminiSchema `json:"responseSchema,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+// blockedFinishReasons end a candidate because of content policy.
+var blockedFinishReasons = map[string]bool{
+	"SAFETY": true, "RECITATION": true, "BLOCKLIST": true,
+	"PROHIBITED_CONTENT": true, "SPII": true,
+}
+
+// Generate sends the prompt, retrying rate limits, server errors and
+// timeouts with exponential backoff.
+func (c *GeminiClient) Generate(ctx context.Context, prompt LLMRequest) (*LLMResponse, error) {
+	request := geminiRequest{
+		Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: prompt.User}}}},
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     prompt.Temperature,
+			MaxOutputTokens: prompt.MaxOutputTokens,
+		},
+	}
+	if prompt.System != "" {
+		request.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: prompt.System}}}
+	}
+	if prompt.Schema != nil {
+		request.GenerationConfig.ResponseMIMEType = "application/json"
+		request.GenerationConfig.ResponseSchema = newGeminiSchema(prompt.Schema)
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return withRetries(ctx, c.MaxRetries, c.RetryBackoff, func() (*LLMResponse, error) {
+		return c.generateOnce(ctx, payload)
+	})
+}
+
+func (c *GeminiClient) generateOnce(ctx context.Context, payload []byte) (*LLMResponse, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", c.BaseURL, c.Model)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, body)
+	}
+
+	var response geminiResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid Gemini response: %v", err)
+	}
+	if reason := response.PromptFeedback.BlockReason; reason != "" {
+		return nil, &BlockedError{Reason: reason}
+	}
+	if len(response.Candidates) == 0 {
+		return nil, errors.New("Gemini returned no candidates")
+	}
+
+	candidate := response.Candidates[0]
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		text.WriteString(part.Text)
+	}
+
+	result := &LLMResponse{
+		Text:         text.String(),
+		FinishReason: candidate.FinishReason,
+		Partial:      candidate.FinishReason != "" && candidate.FinishReason != "STOP",
+		Provider:     c.Name(),
+		Model:        response.ModelVersion,
+		PromptTokens: response.UsageMetadata.PromptTokenCount,
+		OutputTokens: response.UsageMetadata.CandidatesTokenCount,
+	}
+	if result.Model == "" {
+		result.Model = c.Model
+	}
+	if blockedFinishReasons[candidate.FinishReason] && strings.TrimSpace(result.Text) == "" {
+		return nil, &BlockedError{Reason: candidate.FinishReason}
+	}
+	return result, nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingTransport counts the requests sent through it and keeps the last
+// request body.
+type countingTransport struct {
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	calls int
+	body  []byte
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	t.mu.Lock()
+	t.calls++
+	t.body = body
+	t.mu.Unlock()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return t.next.RoundTrip(req)
+}
+
+// newFixtureGemini returns a Gemini client answered by the given fixtures,
+// written in order to a temporary directory.
+func newFixtureGemini(t *testing.T, fixtures ...httpFixture) (*GeminiClient, *countingTransport) {
+	t.Helper()
+	dir := t.TempDir()
+	for i, fixture := range fixtures {
+		content, err := json.Marshal(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%02d.json", i)), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	transport := &countingTransport{next: NewFixtureTransport(dir, FixturesReplay, nil)}
+	client := &GeminiClient{
+		BaseURL:      "https://gemini.test/v1beta",
+		Model:        "gemini-1.5-pro",
+		Timeout:      5 * time.Second,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		HTTPClient:   &http.Client{Transport: transport},
+	}
+	return client, transport
+}
+
+// geminiAnswer is a generateContent response with one candidate.
+func geminiAnswer(text, finishReason string) json.RawMessage {
+	body, _ := json.Marshal(map[string]interface{}{
+		"candidates": []map[string]interface{}{{
+			"content":      map[string]interface{}{"role": "model", "parts": []map[string]string{{"text": text}}},
+			"finishReason": finishReason,
+		}},
+		"usageMetadata": map[string]int{"promptTokenCount": 12, "candidatesTokenCount": 5},
+		"modelVersion":  "gemini-1.5-pro-002",
+	})
+	return body
+}
+
+func TestGeminiClientSchema(t *testing.T) {
+	client, transport := newFixtureGemini(t, httpFixture{Status: 200, Body: geminiAnswer("```json\n{\"name\": \"Jane Doe\", \"skill_entries\": [{\"name\": \"Go\", \"years\": 4}]}\n```", "STOP")})
+
+	response, err := client.Generate(context.Background(), LLMRequest{System: "Extract", User: "Jane Doe", Schema: resumeSchema})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	var request geminiRequest
+	if err := json.Unmarshal(transport.body, &request); err != nil {
+		t.Fatal(err)
+	}
+	config := request.GenerationConfig
+	if config.ResponseMIMEType != "application/json" || config.ResponseSchema == nil {
+		t.Fatalf("request has no JSON response schema: %s", transport.body)
+	}
+	if config.ResponseSchema.Type != "OBJECT" || config.ResponseSchema.Properties["skill_entries"].Items.Properties["years"].Type != "INTEGER" {
+		t.Errorf("schema types not converted to the API's Type enum: %s", transport.body)
+	}
+	if request.SystemInstruction == nil || request.SystemInstruction.Parts[0].Text != "Extract" {
+		t.Errorf("system prompt not sent as systemInstruction: %s", transport.body)
+	}
+
+	if response.Model != "gemini-1.5-pro-002" || response.PromptTokens != 12 || response.OutputTokens != 5 || response.Partial {
+		t.Errorf("Generate = %+v", response)
+	}
+	var data ResumeData
+	if err := decodeJSONAnswer(response.Text, &data); err != nil {
+		t.Fatalf("decodeJSONAnswer: %v", err)
+	}
+	if data.Name != "Jane Doe" || len(data.SkillEntries) != 1 || data.SkillEntries[0].Years != 4 {
+		t.Errorf("decoded %+v", data)
+	}
+}
+
+func TestGeminiClientSafety(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    json.RawMessage
+		blocked string
+		partial bool
+	}{
+		{"prompt blocked", json.RawMessage(`{"promptFeedback": {"blockReason": "SAFETY"}}`), "SAFETY", false},
+		{"answer blocked", geminiAnswer("", "PROHIBITED_CONTENT"), "PROHIBITED_CONTENT", false},
+		{"stopped mid-answer", geminiAnswer(`{"name": "Jane`, "SAFETY"), "", true},
+		{"token limit", geminiAnswer(`{"name": "Jane`, "MAX_TOKENS"), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, transport := newFixtureGemini(t, httpFixture{Status: 200, Body: tt.body})
+			response, err := client.Generate(context.Background(), LLMRequest{User: "Jane Doe"})
+
+			if tt.blocked != "" {
+				var blocked *BlockedError
+				if !errors.As(err, &blocked) || blocked.Reason != tt.blocked {
+					t.Fatalf("Generate = %v, want blocked for %s", err, tt.blocked)
+				}
+				if transport.calls != 1 {
+					t.Errorf("blocked request sent %d times, want 1", transport.calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if response.Partial != tt.partial {
+				t.Errorf("Partial = %v, want %v", response.Partial, tt.partial)
+			}
+		})
+	}
+}
+
+func TestGeminiClientRetries(t *testing.T) {
+	unavailable := httpFixture{Once: true, Status: 503, Headers: http.Header{"Retry-After": {"0"}}, Body: json.RawMessage(`{"error": {"code": 503}}`)}
+
+	t.Run("recovers", func(t *testing.T) {
+		client, transport := newFixtureGemini(t, unavailable, unavailable, httpFixture{Status: 200, Body: geminiAnswer("{}", "STOP")})
+		if _, err := client.Generate(context.Background(), LLMRequest{User: "Jane Doe"}); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		if transport.calls != 3 {
+			t.Errorf("sent %d requests, want 3", transport.calls)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		client, transport := newFixtureGemini(t, httpFixture{Status: 429, Body: json.RawMessage(`{"error": {"code": 429}}`)})
+		_, err := client.Generate(context.Background(), LLMRequest{User: "Jane Doe"})
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+			t.Fatalf("Generate = %v, want the rate limit error", err)
+		}
+		if transport.calls != client.MaxRetries+1 {
+			t.Errorf("sent %d requests, want %d", transport.calls, client.MaxRetries+1)
+		}
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		client, transport := newFixtureGemini(t, httpFixture{Status: 400, Body: json.RawMessage(`{"error": {"code": 400}}`)})
+		if _, err := client.Generate(context.Background(), LLMRequest{User: "Jane Doe"}); err == nil {
+			t.Fatal("Generate succeeded on a 400")
+		}
+		if transport.calls != 1 {
+			t.Errorf("bad request sent %d times, want 1", transport.calls)
+		}
+	})
+}
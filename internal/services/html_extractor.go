@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlBlockElements start a new line in the extracted text.
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+	atom.Ul: true, atom.Ol: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Table: true, atom.Blockquote: true, atom.Pre: true, atom.Hr: true,
+	atom.Address: true, atom.Aside: true, atom.Main: true, atom.Nav: true,
+}
+
+// htmlSkippedElements hold no visible text.
+var htmlSkippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Head: true, atom.Noscript: true,
+	atom.Template: true, atom.Svg: true, atom.Iframe: true, atom.Object: true,
+}
+
+var htmlSpaces = regexp.MustCompile(`[ \t\f\r\n]+`)
+
+// extractTextFromHTML renders the visible text of an HTML resume, keeping
+// block structure as line breaks and list items as bullets.
+func extractTextFromHTML(content []byte) (string, error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+	newline := func() {
+		text := buf.String()
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			text := n.Data
+			if !pre {
+				text = htmlSpaces.ReplaceAllString(text, " ")
+				if strings.HasSuffix(buf.String(), "\n") || buf.Len() == 0 {
+					text = strings.TrimLeft(text, " ")
+				}
+			}
+			buf.WriteString(text)
+			return
+		case html.ElementNode:
+			if htmlSkippedElements[n.DataAtom] {
+				return
+			}
+			if htmlBlockElements[n.DataAtom] {
+				newline()
+			}
+			switch n.DataAtom {
+			case atom.Li:
+				buf.WriteString("- ")
+			case atom.Td, atom.Th:
+				if text := buf.String(); text != "" && !strings.HasSuffix(text, "\n") {
+					buf.WriteString("\t")
+				}
+			case atom.Pre:
+				pre = true
+			}
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, pre)
+		}
+
+		if n.Type == html.ElementNode {
+			if htmlBlockElements[n.DataAtom] {
+				newline()
+			}
+			// Keep link targets so profile URLs survive
+			if n.DataAtom == atom.A {
+				for _, attr := range n.Attr {
+					if attr.Key == "href" && strings.HasPrefix(attr.Val, "http") && !strings.Contains(textContent(n), attr.Val) {
+						buf.WriteString(" (" + attr.Val + ")")
+					}
+				}
+			}
+		}
+	}
+	walk(doc, false)
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func textContent(n *html.Node) string {
+	var buf strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			buf.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return buf.String()
+}
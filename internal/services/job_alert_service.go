@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultAlertSimilarity is the profile similarity a new job needs before
+// a recommendation alert is raised, unless the applicant sets their own.
+const DefaultAlertSimilarity = 0.3
+
+// digestPeriods is how long the oldest pending alert waits before a digest
+// is sent. Instant alerts are delivered when they are raised; one still
+// pending after a minute failed to deliver and is sent again.
+var digestPeriods = map[models.AlertFrequency]time.Duration{
+	models.AlertInstant: time.Minute,
+	models.AlertDaily:   24 * time.Hour,
+	models.AlertWeekly:  7 * 24 * time.Hour,
+}
+
+// errAlertsDelivered means another worker delivered the alerts first.
+var errAlertsDelivered = errors.New("job alerts already delivered")
+
+// JobSearchFilter selects jobs by keywords, company and skills. Every
+// keyword must appear in the title or description, and every skill must be
+// listed by the job, directly or through a more specific skill.
+type JobSearchFilter struct {
+	Keywords string
+	Company  string
+	Skills   []string
+}
+
+// SavedSearchFilter returns the filter of a saved search.
+func SavedSearchFilter(search *models.SavedSearch) JobSearchFilter {
+	filter := JobSearchFilter{Keywords: search.Keywords, Company: search.Company}
+	for _, skill := range strings.Split(search.Skills, ",") {
+		if skill = strings.TrimSpace(skill); skill != "" {
+			filter.Skills = append(filter.Skills, skill)
+		}
+	}
+	return filter
+}
+
+// IsEmpty reports whether the filter matches every job.
+func (f JobSearchFilter) IsEmpty() bool {
+	return strings.TrimSpace(f.Keywords) == "" && strings.TrimSpace(f.Company) == "" && len(f.Skills) == 0
+}
+
+// Apply narrows a query on jobs to the filter.
+func (f JobSearchFilter) Apply(query *gorm.DB, skills *SkillTaxonomy) *gorm.DB {
+	for _, word := range strings.Fields(f.Keywords) {
+		pattern := utils.ContainsPattern(word)
+		query = query.Where("(LOWER(jobs.title) LIKE ? OR LOWER(jobs.description) LIKE ?)", pattern, pattern)
+	}
+	if company := strings.TrimSpace(f.Company); company != "" {
+		query = query.Where("LOWER(jobs.company_name) LIKE ?", utils.ContainsPattern(company))
+	}
+	for _, skill := range f.Skills {
+		query = query.Where("EXISTS (SELECT 1 FROM job_skills s WHERE s.job_id = jobs.id AND LOWER(s.name) IN ? AND s.deleted_at IS NULL)",
+			skills.Variants(skill, true))
+	}
+	return query
+}
+
+// Matches reports whether a job, with its Skills loaded, passes the filter.
+// It agrees with Apply.
+func (f JobSearchFilter) Matches(job *models.Job, skills *SkillTaxonomy) bool {
+	text := strings.ToLower(job.Title + "\n" + job.Description)
+	for _, word := range strings.Fields(strings.ToLower(f.Keywords)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	if company := strings.ToLower(strings.TrimSpace(f.Company)); !strings.Contains(strings.ToLower(job.CompanyName), company) {
+		return false
+	}
+
+	jobSkills := map[string]bool{}
+	for _, skill := range job.Skills {
+		jobSkills[NormalizeSkillName(skill.Name)] = true
+	}
+	for _, skill := range f.Skills {
+		found := false
+		for _, variant := range skills.Variants(skill, true) {
+			found = found || jobSkills[variant]
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// JobAlertService tells applicants about new jobs that match their saved
+// searches or are similar to their profile. Alerts become messages in the
+// outbox, either instantly or in daily and weekly digests.
+type JobAlertService struct {
+	DB         *gorm.DB
+	Skills     *SkillTaxonomy
+	Embeddings *EmbeddingService
+}
+
+// A merged candidate's saved searches move to the survivor. Their alert
+// preferences and alerts move too, unless the survivor has their own
+// preferences or was alerted about the same job.
+func init() {
+	registerMergeTable(mergeTable{name: "saved_searches", model: &models.SavedSearch{}, column: "user_id"})
+	registerMergeTable(mergeTable{name: "alert_preferences", model: &models.AlertPreference{}, column: "user_id", unique: true})
+	registerMergeTable(mergeTable{name: "job_alerts", model: &models.JobAlert{}, column: "user_id", unique: true, key: "job_id"})
+}
+
+func NewJobAlertService(db *gorm.DB, skills *SkillTaxonomy, embeddings *EmbeddingService) *JobAlertService {
+	return &JobAlertService{DB: db, Skills: skills, Embeddings: embeddings}
+}
+
+// Start raises the alerts still pending for new jobs, then does so and
+// sends the digests that are due every interval.
+func (s *JobAlertService) Start(interval time.Duration) {
+	if interval <= 0 {
+		go s.RaisePending(context.Background())
+		return
+	}
+	go func() {
+		for {
+			s.RaisePending(context.Background())
+			if n, err := s.SendDigests(time.Now()); err != nil {
+				log.Printf("Job alert digests failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Sent %d job alert digests", n)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// RaisePending raises the alerts of new jobs whose alerts were not raised
+// yet, because the server stopped or raising them failed. Raising alerts
+// twice is harmless, as an applicant is alerted about a job once.
+func (s *JobAlertService) RaisePending(ctx context.Context) {
+	var jobIDs []uint
+	if err := s.DB.Model(&models.Job{}).Where("alerts_pending = ?", true).Order("id").Pluck("id", &jobIDs).Error; err != nil {
+		log.Printf("Failed to find jobs with pending alerts: %v", err)
+		return
+	}
+	for _, jobID := range jobIDs {
+		n, err := s.JobPosted(ctx, jobID)
+		if err != nil {
+			log.Printf("Job alerts for job %d failed: %v", jobID, err)
+		} else if n > 0 {
+			log.Printf("Raised %d pending job alerts for job %d", n, jobID)
+		}
+	}
+}
+
+// Notify raises the alerts for a new job, created with AlertsPending set,
+// in the background and returns immediately. If they are not raised,
+// RaisePending retries them.
+func (s *JobAlertService) Notify(jobID uint) {
+	go func() {
+		n, err := s.JobPosted(context.Background(), jobID)
+		if err != nil {
+			log.Printf("Job alerts for job %d failed: %v", jobID, err)
+		} else if n > 0 {
+			log.Printf("Raised %d job alerts for job %d", n, jobID)
+		}
+	}()
+}
+
+// JobPosted raises alerts for a new job and delivers the instant ones, then
+// clears the job's AlertsPending. An applicant is alerted once even if
+// several saved searches match. It returns the number of alerts raised.
+func (s *JobAlertService) JobPosted(ctx context.Context, jobID uint) (int, error) {
+	var job models.Job
+	if err := s.DB.Preload("Skills").First(&job, jobID).Error; err != nil {
+		return 0, err
+	}
+	raised, err := s.raiseAll(ctx, &job)
+	if err != nil {
+		return raised, err
+	}
+	// UpdateColumn keeps updated_at, so the embedding sync does not read
+	// the job again
+	return raised, s.DB.Model(&job).UpdateColumn("alerts_pending", false).Error
+}
+
+// raiseAll raises the alerts of saved searches and recommendations for a
+// job.
+func (s *JobAlertService) raiseAll(ctx context.Context, job *models.Job) (int, error) {
+	raised := 0
+	var searches []models.SavedSearch
+	if err := s.DB.Find(&searches).Error; err != nil {
+		return 0, err
+	}
+	for i := range searches {
+		search := &searches[i]
+		if !SavedSearchFilter(search).Matches(job, s.Skills) {
+			continue
+		}
+		ok, err := s.raise(job, models.JobAlert{
+			UserID:        search.UserID,
+			Reason:        models.AlertReasonSavedSearch,
+			SavedSearchID: &search.ID,
+			SavedSearch:   search,
+			Frequency:     search.Frequency,
+		})
+		if err != nil {
+			return raised, err
+		}
+		if ok {
+			raised++
+		}
+	}
+
+	var preferences []models.AlertPreference
+	if err := s.DB.Where("recommendations = ?", true).Find(&preferences).Error; err != nil {
+		return raised, err
+	}
+	if len(preferences) == 0 || s.Embeddings == nil {
+		return raised, nil
+	}
+	vector, err := s.Embeddings.JobVector(ctx, job.ID)
+	if errors.Is(err, ErrNothingToEmbed) {
+		return raised, nil
+	} else if err != nil {
+		return raised, err
+	}
+	optedIn := map[uint]bool{}
+	for _, preference := range preferences {
+		optedIn[preference.UserID] = true
+	}
+	similarity := map[uint]float64{}
+	skip := func(id uint) bool { return !optedIn[id] }
+	for _, similar := range s.Embeddings.Nearest(models.EmbeddingOwnerCandidate, vector, 0, skip) {
+		similarity[similar.ID] = similar.Similarity
+	}
+	for _, preference := range preferences {
+		score, ok := similarity[preference.UserID]
+		if !ok || score < preference.MinSimilarity {
+			continue
+		}
+		ok, err := s.raise(job, models.JobAlert{
+			UserID:     preference.UserID,
+			Reason:     models.AlertReasonRecommendation,
+			Similarity: score,
+			Frequency:  preference.Frequency,
+		})
+		if err != nil {
+			return raised, err
+		}
+		if ok {
+			raised++
+		}
+	}
+	return raised, nil
+}
+
+// raise stores an alert unless the applicant was already alerted about the
+// job, and delivers it if it is instant.
+func (s *JobAlertService) raise(job *models.Job, alert models.JobAlert) (bool, error) {
+	alert.JobID = job.ID
+	savedSearch := alert.SavedSearch
+	alert.SavedSearch = nil
+	result := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&alert)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	if alert.Frequency == models.AlertInstant {
+		alert.Job, alert.SavedSearch = *job, savedSearch
+		if err := s.deliver(alert.UserID, alert.Frequency, []models.JobAlert{alert}); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// SendDigests delivers the pending daily and weekly alerts of every
+// applicant whose oldest pending alert has waited a full period, and
+// retries instant alerts that failed to deliver. It returns the number of
+// messages sent.
+func (s *JobAlertService) SendDigests(now time.Time) (int, error) {
+	sent := 0
+	for frequency, period := range digestPeriods {
+		var userIDs []uint
+		err := s.DB.Model(&models.JobAlert{}).
+			Where("message_id IS NULL AND frequency = ?", frequency).
+			Group("user_id").Having("MIN(created_at) <= ?", now.Add(-period)).
+			Pluck("user_id", &userIDs).Error
+		if err != nil {
+			return sent, err
+		}
+
+		for _, userID := range userIDs {
+			var alerts []models.JobAlert
+			if err := s.DB.Preload("Job").Preload("SavedSearch").
+				Where("user_id = ? AND frequency = ? AND message_id IS NULL", userID, frequency).
+				Order("id").Find(&alerts).Error; err != nil {
+				return sent, err
+			}
+			// Instant alerts are retried one message per alert, as if
+			// delivered when raised
+			batches := [][]models.JobAlert{alerts}
+			if frequency == models.AlertInstant {
+				batches = nil
+				for i := range alerts {
+					batches = append(batches, alerts[i:i+1])
+				}
+			}
+			for _, batch := range batches {
+				err := s.deliver(userID, frequency, batch)
+				if errors.Is(err, errAlertsDelivered) {
+					continue
+				} else if err != nil {
+					return sent, err
+				}
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// deliver writes alerts into one message and marks them delivered. Alerts
+// for jobs deleted in the meantime are dropped.
+func (s *JobAlertService) deliver(userID uint, frequency models.AlertFrequency, alerts []models.JobAlert) error {
+	var live []models.JobAlert
+	var ids, stale []uint
+	for _, alert := range alerts {
+		if alert.Job.ID == 0 {
+			stale = append(stale, alert.ID)
+			continue
+		}
+		live = append(live, alert)
+		ids = append(ids, alert.ID)
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if len(stale) > 0 {
+			if err := tx.Unscoped().Delete(&models.JobAlert{}, stale).Error; err != nil {
+				return err
+			}
+		}
+		if len(live) == 0 {
+			return nil
+		}
+
+		subject, body := alertMessage(frequency, live)
+		message := models.Message{
+			RecipientID: userID,
+			SenderID:    models.SystemSenderID,
+			Subject:     subject,
+			Body:        body,
+		}
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.JobAlert{}).Where("id IN ? AND message_id IS NULL", ids).
+			Updates(map[string]interface{}{"message_id": message.ID, "sent_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			return errAlertsDelivered
+		}
+		return nil
+	})
+}
+
+// alertMessage writes the subject and body of an alert message.
+func alertMessage(frequency models.AlertFrequency, alerts []models.JobAlert) (string, string) {
+	var lines []string
+	for _, alert := range alerts {
+		reason := "recommended for your profile"
+		if alert.Reason == models.AlertReasonSavedSearch {
+			reason = "matches a saved search"
+			if alert.SavedSearch != nil {
+				reason = fmt.Sprintf("matches your saved search %q", alert.SavedSearch.Name)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("- %s at %s (%s)\n  Apply: /jobs/apply?job_id=%d",
+			alert.Job.Title, alert.Job.CompanyName, reason, alert.Job.ID))
+	}
+
+	subject := fmt.Sprintf("New job: %s at %s", alerts[0].Job.Title, alerts[0].Job.CompanyName)
+	if frequency != models.AlertInstant {
+		subject = fmt.Sprintf("Your %s job digest: %d new jobs", frequency, len(alerts))
+		if len(alerts) == 1 {
+			subject = fmt.Sprintf("Your %s job digest: 1 new job", frequency)
+		}
+	}
+	return subject, "New jobs you may be interested in:\n\n" + strings.Join(lines, "\n")
+}
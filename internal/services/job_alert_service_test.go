@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+)
+
+func TestJobSearchFilterMatchesApply(t *testing.T) {
+	skills, db := newTaxonomyFixture(t)
+	if err := db.AutoMigrate(&models.Job{}, &models.JobSkill{}); err != nil {
+		t.Fatal(err)
+	}
+	jobs := []struct {
+		title, description, company string
+		skills                      []string
+	}{
+		{"Senior Go Developer", "Build APIs in Go", "Acme Corp", []string{"Go", "PostgreSQL"}},
+		{"Frontend Engineer", "React and TypeScript", "Initech", []string{"React", "TypeScript"}},
+		{"Full Stack Developer", "Next.js with a Go backend", "ACME Labs", []string{"Next.js", "Go"}},
+		{"Designer", "Figma prototypes", "Globex", nil},
+	}
+	for _, j := range jobs {
+		job := models.Job{Title: j.title, Description: j.description, CompanyName: j.company, PostedByID: 1}
+		mustCreate(t, db, &job)
+		if err := ReplaceJobSkills(db, &job, skills.Normalize(j.skills), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var deleted models.Job
+	mustCreate(t, db, &models.Job{Title: "Go Developer", Description: "Go", CompanyName: "Acme", PostedByID: 1})
+	db.Last(&deleted)
+	db.Delete(&deleted)
+
+	filters := []JobSearchFilter{
+		{},
+		{Keywords: "developer"},
+		{Keywords: "GO developer"},
+		{Keywords: "go  backend"},
+		{Keywords: "nowhere"},
+		{Company: "acme"},
+		{Company: " ACME LABS "},
+		{Skills: []string{"golang"}},
+		{Skills: []string{"JS"}},
+		{Skills: []string{"react"}},
+		{Skills: []string{"ts", "react"}},
+		{Skills: []string{"Rust"}},
+		{Keywords: "developer", Company: "acme", Skills: []string{"javascript"}},
+	}
+	for _, filter := range filters {
+		var all []models.Job
+		if err := db.Preload("Skills").Order("id").Find(&all).Error; err != nil {
+			t.Fatal(err)
+		}
+		var matched []uint
+		for i := range all {
+			if filter.Matches(&all[i], skills) {
+				matched = append(matched, all[i].ID)
+			}
+		}
+		var applied []uint
+		if err := filter.Apply(db.Model(&models.Job{}), skills).Order("id").Pluck("id", &applied).Error; err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(matched) != fmt.Sprint(applied) {
+			t.Errorf("filter %+v: Matches selects %v, Apply %v", filter, matched, applied)
+		}
+	}
+}
+
+func TestJobAlertRaisePending(t *testing.T) {
+	skills, db := newTaxonomyFixture(t)
+	if err := db.AutoMigrate(&models.Job{}, &models.JobSkill{}, &models.SavedSearch{}, &models.AlertPreference{},
+		&models.JobAlert{}, &models.Message{}); err != nil {
+		t.Fatal(err)
+	}
+	alerts := NewJobAlertService(db, skills, nil)
+	mustCreate(t, db, &models.SavedSearch{UserID: 7, Name: "Go", Keywords: "go", Frequency: models.AlertInstant})
+	mustCreate(t, db, &models.SavedSearch{UserID: 8, Name: "Golang", Skills: "golang", Frequency: models.AlertDaily})
+
+	// Posted before a restart, so Notify never ran
+	pending := models.Job{Title: "Go Developer", Description: "APIs", CompanyName: "Acme", PostedByID: 1, AlertsPending: true}
+	raised := models.Job{Title: "Go Engineer", Description: "APIs", CompanyName: "Acme", PostedByID: 1}
+	mustCreate(t, db, &pending)
+	mustCreate(t, db, &raised)
+	if err := ReplaceJobSkills(db, &pending, []string{"Go"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for run := 1; run <= 2; run++ {
+		alerts.RaisePending(context.Background())
+
+		var got []models.JobAlert
+		db.Order("user_id").Find(&got)
+		if len(got) != 2 || got[0].JobID != pending.ID || got[1].JobID != pending.ID {
+			t.Fatalf("run %d: alerts = %+v, want one per saved search for job %d", run, got, pending.ID)
+		}
+		if got[0].MessageID == nil || got[1].MessageID != nil {
+			t.Errorf("run %d: instant alert message %v, daily %v; want only the instant one delivered", run, got[0].MessageID, got[1].MessageID)
+		}
+		var job models.Job
+		db.First(&job, pending.ID)
+		if job.AlertsPending || !job.UpdatedAt.Equal(pending.UpdatedAt) {
+			t.Errorf("run %d: job alerts pending %v, updated at %v; want cleared without touching updated_at", run, job.AlertsPending, job.UpdatedAt)
+		}
+	}
+
+	var messages int64
+	db.Model(&models.Message{}).Count(&messages)
+	if messages != 1 {
+		t.Errorf("%d messages sent, want 1", messages)
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GolangAssignment/internal/config"
+)
+
+// LLMClient is a chat-style language model backend. Implementations handle
+// their own retries; callers see only the final outcome.
+type LLMClient interface {
+	Name() string
+	Generate(ctx context.Context, request LLMRequest) (*LLMResponse, error)
+}
+
+// LLMSchema is the JSON schema subset shared by the providers' structured
+// output modes. Types are lower case JSON schema names.
+type LLMSchema struct {
+	Type       string                `json:"type"`
+	Properties map[string]*LLMSchema `json:"properties,omitempty"`
+	Items      *LLMSchema            `json:"items,omitempty"`
+	Required   []string              `json:"required,omitempty"`
+}
+
+// LLMRequest is one prompt. When Schema is set the answer must be JSON
+// matching it.
+type LLMRequest struct {
+	System          string
+	User            string
+	Schema          *LLMSchema
+	Temperature     float64
+	MaxOutputTokens int
+}
+
+// LLMResponse is the answer text. Partial is set when the model stopped
+// early (token limit or a mid-answer safety stop) and the text may be
+// incomplete.
+type LLMResponse struct {
+	Text         string
+	FinishReason string
+	Partial      bool
+	Provider     string
+	Model        string
+	PromptTokens int
+	OutputTokens int
+}
+
+// TokenUsage totals the tokens used by one provider and model.
+type TokenUsage struct {
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	Calls        int    `json:"calls"`
+	Failures     int    `json:"failures"`
+	PromptTokens int    `json:"prompt_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+}
+
+// BlockedError reports a prompt or answer withheld by safety filters.
+// Retrying the same prompt will not help.
+type BlockedError struct {
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return "response blocked: " + e.Reason
+}
+
+// APIError is a non-success HTTP response from an LLM API.
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may succeed if sent again.
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// NewLLMClient builds the backends named in cfg.LLMProviders and wraps them
+// in an LLMGateway that fails over between them in order.
+func NewLLMClient(cfg config.Config) (*LLMGateway, error) {
+	var clients []LLMClient
+	for _, name := range cfg.LLMProviders {
+		name = strings.ToLower(strings.TrimSpace(name))
+		// Each provider replays its own recordings
+		transport := http.DefaultTransport
+		if cfg.LLMFixturesDir != "" {
+			transport = NewFixtureTransport(filepath.Join(cfg.LLMFixturesDir, name), cfg.LLMFixturesMode, transport)
+		}
+		httpClient := &http.Client{Transport: transport}
+
+		switch name {
+		case "gemini":
+			clients = append(clients, NewGeminiClient(cfg, httpClient))
+		case "openai":
+			clients = append(clients, NewOpenAIClient(cfg, httpClient))
+		case "fake":
+			fake, err := LoadFakeLLMClient(cfg.LLMFakeResponsesPath)
+			if err != nil {
+				return nil, err
+			}
+			clients = append(clients, fake)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown LLM provider: %s", name)
+		}
+	}
+
+	if len(clients) == 0 {
+		return nil, errors.New("no LLM providers configured")
+	}
+	return NewLLMGateway(clients, cfg.LLMFailoverCooldown), nil
+}
+
+// LLMGateway sends each request to the first healthy backend and fails over
+// to the next one on error. A backend that is down, per isProviderDown, is
+// skipped for Cooldown so a provider outage does not slow down every request; when all backends are
+// cooling down they are tried anyway. The gateway keeps token totals per
+// provider and model.
+type LLMGateway struct {
+	Clients  []LLMClient
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	downUntil map[int]time.Time
+	usage     map[string]*TokenUsage
+}
+
+func NewLLMGateway(clients []LLMClient, cooldown time.Duration) *LLMGateway {
+	return &LLMGateway{
+		Clients:   clients,
+		Cooldown:  cooldown,
+		downUntil: map[int]time.Time{},
+		usage:     map[string]*TokenUsage{},
+	}
+}
+
+func (g *LLMGateway) Name() string {
+	names := make([]string, len(g.Clients))
+	for i, c := range g.Clients {
+		names[i] = c.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (g *LLMGateway) Generate(ctx context.Context, request LLMRequest) (*LLMResponse, error) {
+	var errs []string
+	for _, i := range g.order() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		client := g.Clients[i]
+		response, err := client.Generate(ctx, request)
+		g.record(i, response, err)
+		if err == nil {
+			return response, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("LLM provider %s failed, failing over: %v", client.Name(), err)
+		errs = append(errs, fmt.Sprintf("%s: %v", client.Name(), err))
+	}
+	return nil, fmt.Errorf("all LLM providers failed: %s", strings.Join(errs, "; "))
+}
+
+// order returns the positions of the healthy backends first, then those
+// cooling down.
+func (g *LLMGateway) order() []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	var healthy, cooling []int
+	for i := range g.Clients {
+		if now.Before(g.downUntil[i]) {
+			cooling = append(cooling, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	return append(healthy, cooling...)
+}
+
+func (g *LLMGateway) record(i int, response *LLMResponse, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	provider := g.Clients[i].Name()
+	model := ""
+	if response != nil {
+		model = response.Model
+	}
+	key := provider + "/" + model
+	usage, ok := g.usage[key]
+	if !ok {
+		usage = &TokenUsage{Provider: provider, Model: model}
+		g.usage[key] = usage
+	}
+	usage.Calls++
+	if err != nil {
+		usage.Failures++
+		if isProviderDown(err) {
+			g.downUntil[i] = time.Now().Add(g.Cooldown)
+		}
+		return
+	}
+	delete(g.downUntil, i)
+	usage.PromptTokens += response.PromptTokens
+	usage.OutputTokens += response.OutputTokens
+}
+
+// Usage returns the token totals since start-up, ordered by provider and
+// model. Failed calls are counted under an empty model name.
+func (g *LLMGateway) Usage() []TokenUsage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	usage := make([]TokenUsage, 0, len(g.usage))
+	for _, u := range g.usage {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Provider != usage[j].Provider {
+			return usage[i].Provider < usage[j].Provider
+		}
+		return usage[i].Model < usage[j].Model
+	})
+	return usage
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	if len(apiErr.Body) > 500 {
+		apiErr.Body = apiErr.Body[:500]
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// isRetryable reports whether an error from an API call is transient.
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isProviderDown reports whether an error means the provider itself is
+// failing: a transport error, a server error or a rate limit. Blocked
+// answers and other client errors are about the request, so they do not put
+// the provider into cooldown.
+func isProviderDown(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetries calls fn until it succeeds, fails permanently, the retries
+// are used up or ctx is done. The delay doubles after every attempt unless
+// the server asks for a specific Retry-After.
+func withRetries[T any](ctx context.Context, maxRetries int, backoff time.Duration, fn func() (T, error)) (T, error) {
+	delay := backoff
+	for attempt := 0; ; attempt++ {
+		result, err := fn()
+		if err == nil || attempt >= maxRetries || !isRetryable(err) || ctx.Err() != nil {
+			return result, err
+		}
+
+		wait := delay
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			wait = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// decodeJSONAnswer unmarshals a model answer, tolerating Markdown code
+// fences around the JSON.
+func decodeJSONAnswer(text string, v interface{}) error {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+	return json.Unmarshal([]byte(text), v)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingClient fails every call with err.
+type failingClient struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (c *failingClient) Name() string {
+	return c.name
+}
+
+func (c *failingClient) Generate(ctx context.Context, request LLMRequest) (*LLMResponse, error) {
+	c.calls++
+	return nil, c.err
+}
+
+func TestLLMGatewayFallback(t *testing.T) {
+	primary := NewFakeLLMClient(FakeLLMResponse{Status: 503})
+	secondary := NewFakeLLMClient(FakeLLMResponse{Text: `{"name": "Jane Doe"}`})
+	gateway := NewLLMGateway([]LLMClient{primary, secondary}, time.Minute)
+
+	response, err := gateway.Generate(context.Background(), LLMRequest{User: "Jane Doe"})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if response.Text != `{"name": "Jane Doe"}` {
+		t.Errorf("Generate = %q, want the secondary's answer", response.Text)
+	}
+
+	// The failed primary cools down, so the secondary is asked first
+	if _, err := gateway.Generate(context.Background(), LLMRequest{User: "Jane Doe"}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if calls := len(primary.Calls()); calls != 1 {
+		t.Errorf("primary called %d times while cooling down, want 1", calls)
+	}
+
+	usage := gateway.Usage()
+	if len(usage) != 2 || usage[0].Failures != 1 || usage[1].Calls != 2 || usage[1].PromptTokens == 0 {
+		t.Errorf("Usage = %+v", usage)
+	}
+}
+
+func TestLLMGatewayAllFail(t *testing.T) {
+	gateway := NewLLMGateway([]LLMClient{
+		NewFakeLLMClient(FakeLLMResponse{Status: 500}),
+		NewFakeLLMClient(FakeLLMResponse{Blocked: "SAFETY"}),
+	}, time.Minute)
+
+	_, err := gateway.Generate(context.Background(), LLMRequest{User: "Jane Doe"})
+	if err == nil || !strings.Contains(err.Error(), "all LLM providers failed") {
+		t.Errorf("Generate = %v, want every provider to fail", err)
+	}
+}
+
+func TestLLMGatewayCooldown(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		down bool
+	}{
+		{"server error", &APIError{StatusCode: 503}, true},
+		{"rate limit", &APIError{StatusCode: 429}, true},
+		{"transport error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"timeout", fmt.Errorf("request: %w", context.DeadlineExceeded), true},
+		{"blocked", &BlockedError{Reason: "SAFETY"}, false},
+		{"bad request", &APIError{StatusCode: 400}, false},
+		{"invalid answer", errors.New("invalid Gemini response"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &failingClient{name: "primary", err: tt.err}
+			secondary := NewFakeLLMClient(FakeLLMResponse{Text: "{}"})
+			gateway := NewLLMGateway([]LLMClient{primary, secondary}, time.Minute)
+
+			for i := 0; i < 2; i++ {
+				if _, err := gateway.Generate(context.Background(), LLMRequest{User: "Jane Doe"}); err != nil {
+					t.Fatalf("Generate: %v", err)
+				}
+			}
+			// A provider that is down is skipped on the second call
+			want := 2
+			if tt.down {
+				want = 1
+			}
+			if primary.calls != want {
+				t.Errorf("primary called %d times, want %d", primary.calls, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/GolangAssignment/internal/models"
+)
+
+// LLMParser asks a language model to extract resume fields from the text,
+// using a versioned prompt template. Results are cached by file content and
+// prompt version, and every call is metered. Once a budget is used up, the
+// Offline parser is used instead.
+type LLMParser struct {
+	Client  LLMClient
+	Prompt  *PromptTemplate
+	Cache   *ParseCache
+	Meter   *UsageMeter
+	Offline ResumeParser
+}
+
+func NewLLMParser(client LLMClient, prompt *PromptTemplate, cache *ParseCache, meter *UsageMeter, offline ResumeParser) *LLMParser {
+	return &LLMParser{Client: client, Prompt: prompt, Cache: cache, Meter: meter, Offline: offline}
+}
+
+func (p *LLMParser) Name() string {
+	return "llm"
+}
+
+// resumeSchema constrains the model's answer to the ResumeData JSON shape.
+var resumeSchema = &LLMSchema{
+	Type: "object",
+	Properties: map[string]*LLMSchema{
+		"name":  {Type: "string"},
+		"email": {Type: "string"},
+		"phone": {Type: "string"},
+		"education_entries": {Type: "array", Items: &LLMSchema{
+			Type: "object",
+			Properties: map[string]*LLMSchema{
+				"institution": {Type: "string"},
+				"degree":      {Type: "string"},
+				"field":       {Type: "string"},
+				"start_date":  {Type: "string"},
+				"end_date":    {Type: "string"},
+			},
+			Required: []string{"institution"},
+		}},
+		"experience_entries": {Type: "array", Items: &LLMSchema{
+			Type: "object",
+			Properties: map[string]*LLMSchema{
+				"company":     {Type: "string"},
+				"title":       {Type: "string"},
+				"start_date":  {Type: "string"},
+				"end_date":    {Type: "string"},
+				"description": {Type: "string"},
+			},
+			Required: []string{"company", "title"},
+		}},
+		"skill_entries": {Type: "array", Items: &LLMSchema{
+			Type: "object",
+			Properties: map[string]*LLMSchema{
+				"name":  {Type: "string"},
+				"level": {Type: "string"},
+				"years": {Type: "integer"},
+			},
+			Required: []string{"name"},
+		}},
+	},
+	Required: []string{"name", "email", "phone", "education_entries", "experience_entries", "skill_entries"},
+}
+
+func (p *LLMParser) Parse(ctx context.Context, filePath, text string) (*ResumeData, error) {
+	if text == "" {
+		return nil, fmt.Errorf("no text extracted from file")
+	}
+
+	info := ParseInfoFrom(ctx)
+	if cached := p.Cache.Get(info.ContentHash, p.Prompt.ID()); cached != nil {
+		log.Printf("Resume version %d parsed from cache", info.ResumeVersionID)
+		return cached, nil
+	}
+	if err := p.Meter.CheckBudget(info.Organization); err != nil {
+		if p.Offline == nil {
+			return nil, err
+		}
+		log.Printf("%v; parsing resume version %d with the %s parser", err, info.ResumeVersionID, p.Offline.Name())
+		return p.Offline.Parse(ctx, filePath, text)
+	}
+
+	system, user, err := p.Prompt.Render(struct{ Resume string }{SanitizePromptText(text)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prompt %s: %v", p.Prompt.ID(), err)
+	}
+
+	result, err := p.Client.Generate(ctx, LLMRequest{
+		System:          system,
+		User:            user,
+		Schema:          resumeSchema,
+		Temperature:     0.1,
+		MaxOutputTokens: 4096,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := models.LLMUsage{
+		Organization:  info.Organization,
+		Purpose:       UsagePurposeResumeParse,
+		Provider:      result.Provider,
+		ModelName:     result.Model,
+		PromptVersion: p.Prompt.ID(),
+		PromptTokens:  result.PromptTokens,
+		OutputTokens:  result.OutputTokens,
+	}
+	if info.ResumeVersionID != 0 {
+		usage.ResumeVersionID = &info.ResumeVersionID
+	}
+	if err := p.Meter.Record(&usage); err != nil {
+		log.Printf("Failed to record LLM usage: %v", err)
+	}
+
+	var parsedData ResumeData
+	if err := decodeJSONAnswer(result.Text, &parsedData); err != nil {
+		if result.Partial {
+			return nil, fmt.Errorf("incomplete %s answer (finish reason %s): %v", result.Provider, result.FinishReason, err)
+		}
+		return nil, fmt.Errorf("invalid %s answer: %v", result.Provider, err)
+	}
+	if result.Partial {
+		log.Printf("%s answer stopped early (%s) but was complete JSON", result.Provider, result.FinishReason)
+	}
+	log.Printf("Resume parsed by %s/%s using %d prompt and %d output tokens", result.Provider, result.Model, result.PromptTokens, result.OutputTokens)
+
+	parsedData.PromptVersion = p.Prompt.ID()
+	parsedData.Summarize()
+	p.Cache.Put(info.ContentHash, p.Prompt.ID(), result, &parsedData)
+	return &parsedData, nil
+}
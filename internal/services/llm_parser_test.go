@@ -0,0 +1,48 @@
+package services
+
+import (
+	"flag"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GolangAssignment/internal/config"
+)
+
+var record = flag.Bool("record", false, "call the real LLM APIs for requests without a fixture and record the responses")
+
+// newFixtureParser builds the LLM parser for one provider, replaying the
+// recorded responses in testdata/llm/<provider>. With -record, requests
+// without a fixture go to the API, using the keys in the environment.
+func newFixtureParser(t *testing.T, provider string) ResumeParser {
+	t.Helper()
+	cfg := config.Config{
+		ResumeParsers:   []string{"llm"},
+		LLMProviders:    []string{provider},
+		LLMMaxRetries:   3,
+		LLMRetryBackoff: time.Millisecond,
+		LLMFixturesDir:  filepath.Join("testdata", "llm"),
+		LLMFixturesMode: FixturesReplay,
+		GeminiModel:     "gemini-1.5-pro",
+		OpenAIModel:     "gpt-4o-mini",
+	}
+	if *record {
+		env := config.FromEnv()
+		cfg.LLMFixturesMode = FixturesRecord
+		cfg.GeminiAPIKey = env.GeminiAPIKey
+		cfg.OpenAIAPIKey = env.OpenAIAPIKey
+	}
+	parser, err := NewResumeParser(cfg, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parser
+}
+
+func TestGeminiParserGolden(t *testing.T) {
+	checkGolden(t, newFixtureParser(t, "gemini"), ".gemini.golden.json")
+}
+
+func TestOpenAIParserGolden(t *testing.T) {
+	checkGolden(t, newFixtureParser(t, "openai"), ".openai.golden.json")
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/config"
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+)
+
+// UsagePurposeResumeParse marks LLM calls made to parse a resume.
+const UsagePurposeResumeParse = "resume_parse"
+
+// ModelPrice is the cost of a model in USD per million tokens.
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// defaultModelPrices are list prices for the default models. Providers
+// report versioned names such as gemini-1.5-pro-002, which match by prefix.
+var defaultModelPrices = map[string]ModelPrice{
+	"gemini-1.5-pro":   {Input: 1.25, Output: 5.00},
+	"gemini-1.5-flash": {Input: 0.075, Output: 0.30},
+	"gpt-4o":           {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":      {Input: 0.15, Output: 0.60},
+	"fake":             {},
+}
+
+// ParseModelPrices reads model=input/output pairs, comma separated, on top
+// of the default prices.
+func ParseModelPrices(spec string) (map[string]ModelPrice, error) {
+	prices := map[string]ModelPrice{}
+	for model, price := range defaultModelPrices {
+		prices[model] = price
+	}
+	for _, item := range strings.Split(spec, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		model, rates, ok := strings.Cut(item, "=")
+		input, output, ok2 := strings.Cut(rates, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid model price %q, want model=input/output", item)
+		}
+		var price ModelPrice
+		var err error
+		if price.Input, err = strconv.ParseFloat(strings.TrimSpace(input), 64); err != nil {
+			return nil, fmt.Errorf("invalid input price in %q", item)
+		}
+		if price.Output, err = strconv.ParseFloat(strings.TrimSpace(output), 64); err != nil {
+			return nil, fmt.Errorf("invalid output price in %q", item)
+		}
+		prices[strings.TrimSpace(model)] = price
+	}
+	return prices, nil
+}
+
+// BudgetError reports that an LLM budget has been used up.
+type BudgetError struct {
+	Scope string
+	Limit float64
+	Spent float64
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s LLM budget of $%.2f used up ($%.2f spent)", e.Scope, e.Limit, e.Spent)
+}
+
+// BudgetStatus is the spend against one budget in its current period.
+type BudgetStatus struct {
+	Scope        string  `json:"scope"`
+	Organization string  `json:"organization,omitempty"`
+	LimitUSD     float64 `json:"limit_usd"`
+	SpentUSD     float64 `json:"spent_usd"`
+	RemainingUSD float64 `json:"remaining_usd"`
+}
+
+// UsageMeter records the tokens and cost of every LLM call and enforces the
+// daily, monthly and per-organization daily budgets. Calls without an
+// organization, such as applicants' own uploads, count toward the global
+// budgets only. A zero budget is unlimited. All methods are no-ops on a nil
+// meter.
+type UsageMeter struct {
+	DB             *gorm.DB
+	Prices         map[string]ModelPrice
+	DailyBudget    float64
+	MonthlyBudget  float64
+	OrgDailyBudget float64
+}
+
+func NewUsageMeter(db *gorm.DB, cfg config.Config) (*UsageMeter, error) {
+	prices, err := ParseModelPrices(cfg.LLMPrices)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageMeter{
+		DB:             db,
+		Prices:         prices,
+		DailyBudget:    cfg.LLMDailyBudget,
+		MonthlyBudget:  cfg.LLMMonthlyBudget,
+		OrgDailyBudget: cfg.LLMOrgDailyBudget,
+	}, nil
+}
+
+// Cost prices a call by the longest model name that prefixes model.
+// Unknown models cost nothing, so budgets cannot stop them; they are logged.
+func (m *UsageMeter) Cost(model string, promptTokens, outputTokens int) float64 {
+	var price ModelPrice
+	matched := ""
+	for name, p := range m.Prices {
+		if strings.HasPrefix(model, name) && len(name) > len(matched) {
+			price, matched = p, name
+		}
+	}
+	if matched == "" {
+		log.Printf("No price configured for LLM model %q; set LLM_PRICES", model)
+	}
+	return (float64(promptTokens)*price.Input + float64(outputTokens)*price.Output) / 1e6
+}
+
+// Record prices and stores one call.
+func (m *UsageMeter) Record(usage *models.LLMUsage) error {
+	if m == nil {
+		return nil
+	}
+	usage.Day = dayOf(time.Now())
+	usage.CostUSD = m.Cost(usage.ModelName, usage.PromptTokens, usage.OutputTokens)
+	return m.DB.Create(usage).Error
+}
+
+// CheckBudget returns a BudgetError when a budget that applies to calls for
+// organization is used up. When the spend cannot be read it returns that
+// error instead, so that budgets fail closed.
+func (m *UsageMeter) CheckBudget(organization string) error {
+	if m == nil {
+		return nil
+	}
+	now := time.Now()
+	statuses, err := m.globalBudgets(now)
+	if err != nil {
+		return fmt.Errorf("failed to check LLM budgets: %w", err)
+	}
+	if organization != "" {
+		org, err := m.orgBudget(now, organization)
+		if err != nil {
+			return fmt.Errorf("failed to check LLM budgets: %w", err)
+		}
+		statuses = append(statuses, org...)
+	}
+	for _, status := range statuses {
+		if status.RemainingUSD <= 0 {
+			return &BudgetError{Scope: status.Scope, Limit: status.LimitUSD, Spent: status.SpentUSD}
+		}
+	}
+	return nil
+}
+
+// Budgets reports the configured budgets with their spend so far. The
+// organization budget is reported for every organization with spend today.
+func (m *UsageMeter) Budgets() ([]BudgetStatus, error) {
+	if m == nil {
+		return nil, nil
+	}
+	now := time.Now()
+	statuses, err := m.globalBudgets(now)
+	if err != nil {
+		return nil, err
+	}
+	if m.OrgDailyBudget > 0 {
+		var organizations []string
+		err := m.DB.Model(&models.LLMUsage{}).Where("day = ? AND organization <> ''", dayOf(now)).
+			Distinct().Order("organization").Pluck("organization", &organizations).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range organizations {
+			status, err := m.orgBudget(now, org)
+			if err != nil {
+				return nil, err
+			}
+			statuses = append(statuses, status...)
+		}
+	}
+	return statuses, nil
+}
+
+func (m *UsageMeter) globalBudgets(now time.Time) ([]BudgetStatus, error) {
+	today := dayOf(now)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	daily, err := m.budget("daily", "", m.DailyBudget, today)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := m.budget("monthly", "", m.MonthlyBudget, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	return append(daily, monthly...), nil
+}
+
+func (m *UsageMeter) orgBudget(now time.Time, organization string) ([]BudgetStatus, error) {
+	return m.budget("organization daily", organization, m.OrgDailyBudget, dayOf(now))
+}
+
+// budget returns the status of one budget, or nothing if it is unlimited.
+func (m *UsageMeter) budget(scope, organization string, limit float64, from time.Time) ([]BudgetStatus, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	spent, err := m.spent(from, organization)
+	if err != nil {
+		return nil, err
+	}
+	return []BudgetStatus{{
+		Scope:        scope,
+		Organization: organization,
+		LimitUSD:     limit,
+		SpentUSD:     spent,
+		RemainingUSD: limit - spent,
+	}}, nil
+}
+
+// spent sums the cost since from, for one organization or for everyone.
+func (m *UsageMeter) spent(from time.Time, organization string) (float64, error) {
+	query := m.DB.Model(&models.LLMUsage{}).Where("day >= ?", from)
+	if organization != "" {
+		query = query.Where("organization = ?", organization)
+	}
+	var total float64
+	err := query.Select("COALESCE(SUM(cost_usd), 0)").Scan(&total).Error
+	return total, err
+}
+
+func dayOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
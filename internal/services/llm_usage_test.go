@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+)
+
+func TestParseModelPrices(t *testing.T) {
+	prices, err := ParseModelPrices(" llama-3-70b=0/0 , gpt-4o=2/8,my-model = 0.5 / 1.5 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ModelPrice{
+		"llama-3-70b":    {},
+		"gpt-4o":         {Input: 2, Output: 8},
+		"my-model":       {Input: 0.5, Output: 1.5},
+		"gpt-4o-mini":    defaultModelPrices["gpt-4o-mini"],
+		"gemini-1.5-pro": defaultModelPrices["gemini-1.5-pro"],
+	}
+	for model, price := range want {
+		if prices[model] != price {
+			t.Errorf("price of %s = %+v, want %+v", model, prices[model], price)
+		}
+	}
+	if defaultModelPrices["gpt-4o"].Input != 2.5 {
+		t.Error("ParseModelPrices changed the defaults")
+	}
+
+	for _, spec := range []string{"gpt-4o", "gpt-4o=1", "gpt-4o=x/1", "gpt-4o=1/y", "=1/2/3"} {
+		if _, err := ParseModelPrices(spec); err == nil {
+			t.Errorf("ParseModelPrices(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestUsageMeterCost(t *testing.T) {
+	meter := &UsageMeter{Prices: defaultModelPrices}
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"gpt-4o", 2.50 + 10.00},
+		// The longest matching name wins, whatever the map order
+		{"gpt-4o-mini", 0.15 + 0.60},
+		{"gpt-4o-mini-2024-07-18", 0.15 + 0.60},
+		{"gpt-4o-2024-08-06", 2.50 + 10.00},
+		{"gemini-1.5-pro-002", 1.25 + 5.00},
+		{"gemini-1.5-flash-8b", 0.075 + 0.30},
+		{"unknown-model", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 10; i++ {
+			if got := meter.Cost(tt.model, 1e6, 1e6); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("Cost(%q) = %v, want %v", tt.model, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestUsageMeterCheckBudget(t *testing.T) {
+	db := testdb.Open(t, &models.LLMUsage{})
+	meter := &UsageMeter{DB: db, Prices: map[string]ModelPrice{"m": {Input: 1, Output: 0}}, DailyBudget: 10, OrgDailyBudget: 2}
+
+	if err := meter.CheckBudget("acme"); err != nil {
+		t.Fatalf("CheckBudget with no spend = %v", err)
+	}
+	// $3 for acme is over its budget but within the global one
+	if err := meter.Record(&models.LLMUsage{Organization: "acme", ModelName: "m", PromptTokens: 3e6}); err != nil {
+		t.Fatal(err)
+	}
+	var budgetErr *BudgetError
+	if err := meter.CheckBudget("acme"); !errors.As(err, &budgetErr) || budgetErr.Scope != "organization daily" {
+		t.Errorf("CheckBudget(acme) = %v, want the organization budget used up", err)
+	}
+	if err := meter.CheckBudget("initech"); err != nil {
+		t.Errorf("CheckBudget(initech) = %v, want nil", err)
+	}
+	if err := meter.Record(&models.LLMUsage{ModelName: "m", PromptTokens: 7e6}); err != nil {
+		t.Fatal(err)
+	}
+	if err := meter.CheckBudget(""); !errors.As(err, &budgetErr) || budgetErr.Scope != "daily" || budgetErr.Spent != 10 {
+		t.Errorf("CheckBudget = %v, want the daily budget used up at $10", err)
+	}
+
+	var nilMeter *UsageMeter
+	if err := nilMeter.CheckBudget("acme"); err != nil {
+		t.Errorf("nil meter CheckBudget = %v", err)
+	}
+}
+
+func TestUsageMeterFailsClosed(t *testing.T) {
+	// Without the usage table the spend cannot be read
+	db := testdb.Open(t)
+	meter := &UsageMeter{DB: db, Prices: defaultModelPrices, DailyBudget: 10}
+
+	err := meter.CheckBudget("")
+	var budgetErr *BudgetError
+	if err == nil || errors.As(err, &budgetErr) {
+		t.Fatalf("CheckBudget = %v, want the database error", err)
+	}
+	if _, err := meter.Budgets(); err == nil {
+		t.Error("Budgets succeeded without the usage table")
+	}
+
+	// The parser falls back to the offline parser rather than spending
+	client := NewFakeLLMClient(FakeLLMResponse{Text: "{}"})
+	prompt, err := LoadPrompt("resume_extraction", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := NewLLMParser(client, prompt, nil, meter, NewLocalParser([]string{"Go"}))
+	data, err := parser.Parse(context.Background(), "resume.txt", "Jane Doe\njane@example.com\nSkills\nGo\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.Calls()) != 0 || data.Email != "jane@example.com" {
+		t.Errorf("model called %d times, parsed email %q; want the offline parser", len(client.Calls()), data.Email)
+	}
+}
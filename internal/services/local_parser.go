@@ -0,0 +1,449 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+//go:embed data/skills.txt
+var defaultSkillsDictionary string
+
+// LocalParser extracts resume fields with heuristics and needs no network
+// access. It recognises contact details, the candidate's name, section
+// headings, date ranges and skills from a dictionary.
+type LocalParser struct {
+	Skills []string
+}
+
+// NewLocalParser creates a parser matching skills against the given
+// dictionary. A nil dictionary uses the built-in default list.
+func NewLocalParser(skills []string) *LocalParser {
+	if skills == nil {
+		skills = parseSkillsDictionary(defaultSkillsDictionary)
+	}
+	return &LocalParser{Skills: skills}
+}
+
+// LoadSkillsDictionary reads a skills dictionary file with one skill per
+// line. Blank lines and lines starting with # are ignored. An empty path
+// returns the built-in default list.
+func LoadSkillsDictionary(path string) ([]string, error) {
+	if path == "" {
+		return parseSkillsDictionary(defaultSkillsDictionary), nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSkillsDictionary(string(content)), nil
+}
+
+func parseSkillsDictionary(content string) []string {
+	var skills []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		skills = append(skills, line)
+	}
+	return skills
+}
+
+func (p *LocalParser) Name() string {
+	return "local"
+}
+
+// Section names recognised as resume headings.
+const (
+	sectionHeader     = "header"
+	sectionSummary    = "summary"
+	sectionEducation  = "education"
+	sectionExperience = "experience"
+	sectionSkills     = "skills"
+	sectionProjects   = "projects"
+	sectionOther      = "other"
+)
+
+var sectionHeadings = map[string]string{
+	"summary":                 sectionSummary,
+	"profile":                 sectionSummary,
+	"objective":               sectionSummary,
+	"about me":                sectionSummary,
+	"education":               sectionEducation,
+	"academic background":     sectionEducation,
+	"qualifications":          sectionEducation,
+	"experience":              sectionExperience,
+	"work experience":         sectionExperience,
+	"professional experience": sectionExperience,
+	"employment history":      sectionExperience,
+	"work history":            sectionExperience,
+	"skills":                  sectionSkills,
+	"technical skills":        sectionSkills,
+	"core competencies":       sectionSkills,
+	"projects":                sectionProjects,
+	"certifications":          sectionOther,
+	"languages":               sectionOther,
+	"interests":               sectionOther,
+	"awards":                  sectionOther,
+	"references":              sectionOther,
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+|00)?\(?\d[\d\s().\-]{6,}\d`)
+
+	monthPattern = `(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?`
+	datePattern  = `(?:` + monthPattern + `\s+\d{4}|\d{1,2}/\d{4}|\d{4})`
+	// dateRangePattern matches ranges such as "Jan 2019 - Present",
+	// "03/2018 – 05/2020" and "2016 to 2020".
+	dateRangePattern = regexp.MustCompile(`(?i)(` + datePattern + `)\s*(?:-|–|—|to)\s*(` + datePattern + `|present|current|now)`)
+)
+
+// DateRange is a start/end pair found in a resume entry. End is "Present"
+// for ongoing positions.
+type DateRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// resumeEntry is a block of lines within a section, typically one job or
+// one degree. Lines holds the title lines; Details holds bullets and
+// descriptive sentences.
+type resumeEntry struct {
+	Lines   []string
+	Details []string
+	Dates   *DateRange
+}
+
+// heuristicResult is the intermediate output of the heuristic parser.
+type heuristicResult struct {
+	Name     string
+	Email    string
+	Phone    string
+	Sections map[string][]string
+	Entries  map[string][]resumeEntry
+	Skills   []string
+}
+
+func (p *LocalParser) Parse(_ context.Context, _, text string) (*ResumeData, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("no text extracted from file")
+	}
+
+	result := p.analyze(text)
+	data := &ResumeData{
+		Name:  result.Name,
+		Email: result.Email,
+		Phone: result.Phone,
+	}
+	for _, e := range result.Entries[sectionEducation] {
+		data.EducationEntries = append(data.EducationEntries, educationRecord(e))
+	}
+	for _, e := range result.Entries[sectionExperience] {
+		data.ExperienceEntries = append(data.ExperienceEntries, experienceRecord(e))
+	}
+	for _, skill := range result.Skills {
+		data.SkillEntries = append(data.SkillEntries, SkillRecord{Name: skill})
+	}
+	data.Summarize()
+	return data, nil
+}
+
+func (p *LocalParser) analyze(text string) *heuristicResult {
+	result := &heuristicResult{
+		Email:    emailPattern.FindString(text),
+		Phone:    findPhone(text),
+		Sections: map[string][]string{},
+		Entries:  map[string][]resumeEntry{},
+	}
+
+	current := sectionHeader
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if section, ok := headingSection(line); ok {
+			current = section
+			continue
+		}
+		result.Sections[current] = append(result.Sections[current], line)
+	}
+
+	result.Name = findName(result.Sections[sectionHeader], result.Email, result.Phone)
+	for _, section := range []string{sectionEducation, sectionExperience} {
+		result.Entries[section] = splitEntries(result.Sections[section])
+	}
+	result.Skills = p.matchSkills(text, result.Sections[sectionSkills])
+	return result
+}
+
+// headingSection reports whether a line is a section heading and which
+// section it starts.
+func headingSection(line string) (string, bool) {
+	if len(line) > 40 {
+		return "", false
+	}
+	key := strings.ToLower(strings.TrimRight(line, ":"))
+	key = strings.Join(strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+	section, ok := sectionHeadings[key]
+	return section, ok
+}
+
+// findPhone returns the first phone-like match with 7 to 15 digits, the
+// range allowed by E.164.
+func findPhone(text string) string {
+	for _, candidate := range phonePattern.FindAllString(text, -1) {
+		digits := 0
+		for _, r := range candidate {
+			if unicode.IsDigit(r) {
+				digits++
+			}
+		}
+		// Skip matches that are really date ranges such as "2019 - 2021".
+		if dateRangePattern.MatchString(candidate) {
+			continue
+		}
+		if digits >= 7 && digits <= 15 {
+			return strings.TrimSpace(candidate)
+		}
+	}
+	return ""
+}
+
+// findName picks the first header line that looks like a personal name:
+// two to four words made of letters, not containing contact details.
+func findName(header []string, email, phone string) string {
+	for _, line := range header {
+		switch strings.ToLower(line) {
+		case "curriculum vitae", "resume", "résumé", "cv":
+			continue
+		}
+		if (email != "" && strings.Contains(line, email)) || (phone != "" && strings.Contains(line, phone)) {
+			continue
+		}
+		words := strings.Fields(line)
+		if len(words) < 2 || len(words) > 4 {
+			continue
+		}
+		valid := true
+		for _, w := range words {
+			for _, r := range w {
+				if !unicode.IsLetter(r) && r != '.' && r != '-' && r != '\'' {
+					valid = false
+				}
+			}
+			if !unicode.IsUpper([]rune(w)[0]) {
+				valid = false
+			}
+		}
+		if valid {
+			return line
+		}
+	}
+	return ""
+}
+
+// splitEntries groups section lines into entries. A line containing a date
+// range starts a new entry unless the current entry has no dates yet, in
+// which case the range belongs to it (e.g. a title line followed by dates).
+// Once an entry is dated, bullets and sentences are treated as its details
+// and any other line starts the next entry.
+func splitEntries(lines []string) []resumeEntry {
+	var entries []resumeEntry
+	for _, line := range lines {
+		match := dateRangePattern.FindStringSubmatch(line)
+		if match != nil {
+			dates := &DateRange{Start: match[1], End: normalizeEnd(match[2])}
+			rest := strings.Trim(strings.TrimSpace(dateRangePattern.ReplaceAllString(line, "")), "|,()-–— ")
+			if len(entries) > 0 && entries[len(entries)-1].Dates == nil {
+				last := &entries[len(entries)-1]
+				last.Dates = dates
+				if rest != "" {
+					last.Lines = append(last.Lines, rest)
+				}
+				continue
+			}
+			entry := resumeEntry{Dates: dates}
+			if rest != "" {
+				entry.Lines = append(entry.Lines, rest)
+			}
+			entries = append(entries, entry)
+			continue
+		}
+
+		isBullet := strings.IndexAny(line, "•-*·") == 0
+		text := strings.TrimSpace(strings.TrimLeft(line, "•-*· "))
+		if len(entries) > 0 {
+			last := &entries[len(entries)-1]
+			if isBullet || (last.Dates != nil && isSentence(text)) {
+				last.Details = append(last.Details, text)
+				continue
+			}
+			if last.Dates == nil {
+				last.Lines = append(last.Lines, text)
+				continue
+			}
+		}
+		entries = append(entries, resumeEntry{Lines: []string{text}})
+	}
+	return entries
+}
+
+// isSentence reports whether a line reads like prose rather than a title.
+func isSentence(line string) bool {
+	return strings.HasSuffix(line, ".") || len(strings.Fields(line)) > 8
+}
+
+func normalizeEnd(end string) string {
+	switch strings.ToLower(end) {
+	case "present", "current", "now":
+		return "Present"
+	}
+	return end
+}
+
+var (
+	titleSeparators = regexp.MustCompile(`\s+(?:at|@)\s+|\s*[|,]\s*|\s+[-–—]\s+`)
+	degreePattern   = regexp.MustCompile(`(?i)\b(?:b\.?\s?(?:tech|sc|s|a|e|eng)\.?|m\.?\s?(?:tech|sc|s|a|e|eng|ba)\.?|ph\.?\s?d\.?|mba|bachelor|master|doctor|diploma|associate|grado|licenciatura)`)
+	fieldPattern    = regexp.MustCompile(`(?i)^(.*)\s+(?:in|en)\s+(.+)$`)
+)
+
+// splitTitleLine splits "Title, Company", "Title at Company" or
+// "Title | Company" into its two parts.
+func splitTitleLine(lines []string) (string, string) {
+	if len(lines) == 0 {
+		return "", ""
+	}
+	if len(lines) > 1 {
+		return lines[0], lines[1]
+	}
+	parts := titleSeparators.Split(lines[0], 2)
+	if len(parts) == 2 {
+		return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	}
+	return lines[0], ""
+}
+
+func experienceRecord(e resumeEntry) ExperienceRecord {
+	title, company := splitTitleLine(e.Lines)
+	record := ExperienceRecord{
+		Title:       title,
+		Company:     company,
+		Description: strings.Join(e.Details, "\n"),
+	}
+	if e.Dates != nil {
+		record.StartDate = NormalizeResumeDate(e.Dates.Start)
+		record.EndDate = NormalizeResumeDate(e.Dates.End)
+	}
+	return record
+}
+
+func educationRecord(e resumeEntry) EducationRecord {
+	first, second := splitTitleLine(e.Lines)
+	degree, institution := first, second
+	if !degreePattern.MatchString(first) && degreePattern.MatchString(second) {
+		degree, institution = second, first
+	}
+
+	record := EducationRecord{Degree: degree, Institution: institution}
+	if !degreePattern.MatchString(degree) {
+		record.Degree, record.Institution = "", joinNonEmpty(", ", first, second)
+	} else if m := fieldPattern.FindStringSubmatch(degree); m != nil {
+		record.Degree, record.Field = m[1], m[2]
+	} else if loc := degreePattern.FindStringIndex(degree); loc[0] == 0 && strings.TrimSpace(degree[loc[1]:]) != "" {
+		// "M.S. Statistics": the words after the abbreviation are the field.
+		record.Degree, record.Field = strings.TrimSpace(degree[:loc[1]]), strings.TrimSpace(degree[loc[1]:])
+	}
+	if e.Dates != nil {
+		record.StartDate = NormalizeResumeDate(e.Dates.Start)
+		record.EndDate = NormalizeResumeDate(e.Dates.End)
+	}
+	return record
+}
+
+var monthNumbers = map[string]string{
+	"jan": "01", "feb": "02", "mar": "03", "apr": "04", "may": "05", "jun": "06",
+	"jul": "07", "aug": "08", "sep": "09", "oct": "10", "nov": "11", "dec": "12",
+}
+
+var (
+	monthYearPattern   = regexp.MustCompile(`(?i)^([a-z]{3})[a-z]*\.?\s+(\d{4})$`)
+	numericDatePattern = regexp.MustCompile(`^(\d{1,2})/(\d{4})$`)
+)
+
+// NormalizeResumeDate converts "Jan 2021", "01/2021" and "2021" to "2021-01"
+// or "2021". Ongoing markers such as "Present" become "". Unrecognised
+// values are returned trimmed.
+func NormalizeResumeDate(value string) string {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "present", "current", "now", "":
+		return ""
+	}
+	if m := monthYearPattern.FindStringSubmatch(value); m != nil {
+		if month, ok := monthNumbers[strings.ToLower(m[1])]; ok {
+			return m[2] + "-" + month
+		}
+	}
+	if m := numericDatePattern.FindStringSubmatch(value); m != nil {
+		month := m[1]
+		if len(month) == 1 {
+			month = "0" + month
+		}
+		return m[2] + "-" + month
+	}
+	return value
+}
+
+// matchSkills returns dictionary skills found in the skills section or, if
+// the resume has none, anywhere in the text. Matching is case-insensitive
+// and respects word boundaries so "Go" does not match "Google".
+func (p *LocalParser) matchSkills(text string, skillsSection []string) []string {
+	haystack := text
+	if len(skillsSection) > 0 {
+		haystack = strings.Join(skillsSection, "\n")
+	}
+	lower := strings.ToLower(haystack)
+
+	var found []string
+	for _, skill := range p.Skills {
+		if containsWord(lower, strings.ToLower(skill)) {
+			found = append(found, skill)
+		}
+	}
+	return found
+}
+
+func containsWord(haystack, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(haystack[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		if isBoundary(haystack, i-1) && isBoundary(haystack, end) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isBoundary(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := rune(s[i])
+	return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '+' && c != '#'
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current parser output")
+
+// checkGolden parses every resume in testdata/resumes and compares the result
+// with <name><suffix>. With -update the golden files are rewritten instead.
+func checkGolden(t *testing.T, parser ResumeParser, suffix string) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("testdata", "resumes", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no resumes found in testdata/resumes")
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parser.Parse(context.Background(), file, string(text))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			golden := strings.TrimSuffix(file, ".txt") + suffix
+			if *update {
+				out, _ := json.MarshalIndent(got, "", "  ")
+				if err := os.WriteFile(golden, append(out, '\n'), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			content, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run go test with -update): %v", err)
+			}
+			var want ResumeData
+			if err := json.Unmarshal(content, &want); err != nil {
+				t.Fatalf("invalid golden file %s: %v", golden, err)
+			}
+
+			// Compare what would be stored, so empty and missing lists match
+			out, _ := json.Marshal(got)
+			got = &ResumeData{}
+			if err := json.Unmarshal(out, got); err != nil {
+				t.Fatal(err)
+			}
+
+			gotFields, wantFields := reflect.ValueOf(*got), reflect.ValueOf(want)
+			for i := 0; i < gotFields.NumField(); i++ {
+				if !reflect.DeepEqual(gotFields.Field(i).Interface(), wantFields.Field(i).Interface()) {
+					t.Errorf("%s:\n got:  %v\n want: %v", gotFields.Type().Field(i).Name, gotFields.Field(i).Interface(), wantFields.Field(i).Interface())
+				}
+			}
+		})
+	}
+}
+
+func TestLocalParserGolden(t *testing.T) {
+	skills, err := LoadSkillsDictionary("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, NewLocalParser(skills), ".golden.json")
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ScanResult is the verdict of a malware scan.
+type ScanResult struct {
+	Clean     bool
+	Signature string
+}
+
+// MalwareScanner inspects uploaded files before they are stored.
+type MalwareScanner interface {
+	Scan(ctx context.Context, r io.Reader) (ScanResult, error)
+}
+
+// NewMalwareScanner returns a ClamAV client when address is set and a
+// scanner that accepts everything otherwise.
+func NewMalwareScanner(address string, timeout time.Duration) MalwareScanner {
+	if address == "" {
+		return NoopScanner{}
+	}
+	return &ClamAVScanner{Address: address, Timeout: timeout, ChunkSize: 64 << 10}
+}
+
+// NoopScanner accepts every file. It is used when no scanner is configured.
+type NoopScanner struct{}
+
+func (NoopScanner) Scan(context.Context, io.Reader) (ScanResult, error) {
+	return ScanResult{Clean: true}, nil
+}
+
+// ClamAVScanner sends files to a clamd daemon with the INSTREAM command.
+// Address is host:port for TCP or a path for a unix socket, optionally
+// prefixed with "unix:" or "tcp:".
+type ClamAVScanner struct {
+	Address   string
+	Timeout   time.Duration
+	ChunkSize int
+}
+
+func (s *ClamAVScanner) dial(ctx context.Context) (net.Conn, error) {
+	network, address := "tcp", s.Address
+	switch {
+	case strings.HasPrefix(address, "unix:"):
+		network, address = "unix", strings.TrimPrefix(address, "unix:")
+	case strings.HasPrefix(address, "tcp:"):
+		address = strings.TrimPrefix(address, "tcp:")
+	case strings.HasPrefix(address, "/"):
+		network = "unix"
+	}
+	dialer := net.Dialer{Timeout: s.Timeout}
+	return dialer.DialContext(ctx, network, address)
+}
+
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (ScanResult, error) {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	defer conn.Close()
+	if s.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(s.Timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return ScanResult{}, err
+	}
+
+	// Stream the file as length-prefixed chunks, ending with a zero length
+	chunk := make([]byte, s.ChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, werr := conn.Write(size); werr != nil {
+				return ScanResult{}, werr
+			}
+			if _, werr := conn.Write(chunk[:n]); werr != nil {
+				return ScanResult{}, werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ScanResult{}, err
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return ScanResult{}, err
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil && len(reply) == 0 {
+		return ScanResult{}, err
+	}
+	return parseClamAVReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// parseClamAVReply interprets replies such as "stream: OK" and
+// "stream: Eicar-Test-Signature FOUND".
+func parseClamAVReply(reply string) (ScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case reply == "OK":
+		return ScanResult{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return ScanResult{Clean: false, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	}
+	return ScanResult{}, fmt.Errorf("clamd: %s", reply)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eicar is the standard antivirus test file.
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// startClamdStub serves the clamd INSTREAM command on a local port. Streams
+// containing the EICAR string are reported infected. With hang set the stub
+// reads the stream but never replies.
+func startClamdStub(t *testing.T, hang bool) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(t, conn, hang)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func serveClamd(t *testing.T, conn net.Conn, hang bool) {
+	defer conn.Close()
+	command := make([]byte, len("zINSTREAM\x00"))
+	if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+		t.Errorf("unexpected command %q: %v", command, err)
+		return
+	}
+
+	var stream bytes.Buffer
+	size := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(conn, size); err != nil {
+			t.Errorf("reading chunk size: %v", err)
+			return
+		}
+		n := binary.BigEndian.Uint32(size)
+		if n == 0 {
+			break
+		}
+		if _, err := io.CopyN(&stream, conn, int64(n)); err != nil {
+			t.Errorf("reading chunk: %v", err)
+			return
+		}
+	}
+
+	if hang {
+		time.Sleep(time.Second)
+		return
+	}
+	reply := "stream: OK\x00"
+	if strings.Contains(stream.String(), "EICAR-STANDARD-ANTIVIRUS-TEST-FILE") {
+		reply = "stream: Eicar-Test-Signature FOUND\x00"
+	}
+	conn.Write([]byte(reply))
+}
+
+func TestClamAVScanner(t *testing.T) {
+	address := startClamdStub(t, false)
+	// A small chunk size splits the files over several chunks
+	scanner := &ClamAVScanner{Address: address, Timeout: 5 * time.Second, ChunkSize: 16}
+
+	tests := []struct {
+		name      string
+		content   string
+		clean     bool
+		signature string
+	}{
+		{"clean", "Jane Doe\nSoftware Engineer\n", true, ""},
+		{"infected", eicar, false, "Eicar-Test-Signature"},
+		{"empty", "", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := scanner.Scan(context.Background(), strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if result.Clean != tt.clean || result.Signature != tt.signature {
+				t.Errorf("Scan = %+v, want clean %v, signature %q", result, tt.clean, tt.signature)
+			}
+		})
+	}
+}
+
+func TestClamAVScannerTimeout(t *testing.T) {
+	address := startClamdStub(t, true)
+	scanner := &ClamAVScanner{Address: address, Timeout: 100 * time.Millisecond, ChunkSize: 1024}
+
+	start := time.Now()
+	if _, err := scanner.Scan(context.Background(), strings.NewReader("resume")); err == nil {
+		t.Fatal("Scan succeeded, want a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("Scan took %v, want it to give up after the timeout", elapsed)
+	}
+}
+
+func TestParseClamAVReply(t *testing.T) {
+	if _, err := parseClamAVReply("stream: INSTREAM size limit exceeded. ERROR"); err == nil {
+		t.Error("error reply was not reported as an error")
+	}
+}
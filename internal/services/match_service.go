@@ -0,0 +1,468 @@
+package services
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+)
+
+// Component weights used when scoring a candidate against a job. Only the
+// criteria a job sets take part, and their weights are scaled to sum to 100.
+const (
+	weightRequiredSkills  = 50.0
+	weightPreferredSkills = 20.0
+	weightExperience      = 20.0
+	weightEducation       = 10.0
+)
+
+// MatchComponent is the contribution of one criterion to a match score.
+// Fit is how well the candidate meets it, from 0 to 1; Points is Fit times
+// the criterion's share of the score.
+type MatchComponent struct {
+	Criterion string  `json:"criterion"`
+	Weight    float64 `json:"weight"`
+	Fit       float64 `json:"fit"`
+	Points    float64 `json:"points"`
+	Detail    string  `json:"detail"`
+}
+
+// MatchResult explains how well a candidate fits a job. Score runs from 0 to
+// 100 and is the sum of the component points.
+type MatchResult struct {
+	Score                  float64               `json:"score"`
+	MeetsRequirements      bool                  `json:"meets_requirements"`
+	MatchedSkills          []string              `json:"matched_skills"`
+	MissingSkills          []string              `json:"missing_skills"`
+	MatchedPreferredSkills []string              `json:"matched_preferred_skills"`
+	MissingPreferredSkills []string              `json:"missing_preferred_skills"`
+	ExperienceYears        float64               `json:"experience_years"`
+	EducationLevel         models.EducationLevel `json:"education_level"`
+	Components             []MatchComponent      `json:"components"`
+}
+
+// ScoreCandidate compares a profile with the criteria of a job, whose Skills
+// must be loaded. Skills match when the taxonomy gives them the same
+// canonical name. now dates ongoing positions.
+func ScoreCandidate(job *models.Job, profile *models.Profile, skills *SkillTaxonomy, now time.Time) MatchResult {
+	result := MatchResult{
+		MatchedSkills:          []string{},
+		MissingSkills:          []string{},
+		MatchedPreferredSkills: []string{},
+		MissingPreferredSkills: []string{},
+		ExperienceYears:        ExperienceYears(profile.Experiences, now),
+		EducationLevel:         HighestEducationLevel(profile.Educations),
+		MeetsRequirements:      true,
+	}
+
+	has := map[string]bool{}
+	for _, skill := range profileSkillNames(profile) {
+		has[skills.Key(skill)] = true
+	}
+	var required, preferred int
+	for _, skill := range job.Skills {
+		matched := has[skills.Key(skill.Name)]
+		switch {
+		case skill.Required && matched:
+			result.MatchedSkills = append(result.MatchedSkills, skill.Name)
+		case skill.Required:
+			result.MissingSkills = append(result.MissingSkills, skill.Name)
+			result.MeetsRequirements = false
+		case matched:
+			result.MatchedPreferredSkills = append(result.MatchedPreferredSkills, skill.Name)
+		default:
+			result.MissingPreferredSkills = append(result.MissingPreferredSkills, skill.Name)
+		}
+		if skill.Required {
+			required++
+		} else {
+			preferred++
+		}
+	}
+
+	var components []MatchComponent
+	if required > 0 {
+		components = append(components, MatchComponent{
+			Criterion: "required_skills",
+			Weight:    weightRequiredSkills,
+			Fit:       float64(len(result.MatchedSkills)) / float64(required),
+			Detail:    strconv.Itoa(len(result.MatchedSkills)) + " of " + strconv.Itoa(required) + " required skills",
+		})
+	}
+	if preferred > 0 {
+		components = append(components, MatchComponent{
+			Criterion: "preferred_skills",
+			Weight:    weightPreferredSkills,
+			Fit:       float64(len(result.MatchedPreferredSkills)) / float64(preferred),
+			Detail:    strconv.Itoa(len(result.MatchedPreferredSkills)) + " of " + strconv.Itoa(preferred) + " preferred skills",
+		})
+	}
+	if job.MinExperienceYears > 0 {
+		if result.ExperienceYears < float64(job.MinExperienceYears) {
+			result.MeetsRequirements = false
+		}
+		components = append(components, MatchComponent{
+			Criterion: "experience",
+			Weight:    weightExperience,
+			Fit:       math.Min(result.ExperienceYears/float64(job.MinExperienceYears), 1),
+			Detail:    strconv.FormatFloat(result.ExperienceYears, 'f', 1, 64) + " of " + strconv.Itoa(job.MinExperienceYears) + " years",
+		})
+	}
+	if want := job.EducationLevel.Rank(); want > 0 {
+		if result.EducationLevel.Rank() < want {
+			result.MeetsRequirements = false
+		}
+		detail := "no degree found"
+		if result.EducationLevel != models.EducationNone {
+			detail = string(result.EducationLevel)
+		}
+		components = append(components, MatchComponent{
+			Criterion: "education",
+			Weight:    weightEducation,
+			Fit:       math.Min(float64(result.EducationLevel.Rank())/float64(want), 1),
+			Detail:    detail + ", " + string(job.EducationLevel) + " wanted",
+		})
+	}
+
+	var total float64
+	for _, c := range components {
+		total += c.Weight
+	}
+	for i := range components {
+		components[i].Weight = round1(components[i].Weight * 100 / total)
+		components[i].Fit = math.Round(components[i].Fit*100) / 100
+		components[i].Points = round1(components[i].Weight * components[i].Fit)
+		result.Score += components[i].Points
+	}
+	result.Score = round1(result.Score)
+	result.Components = components
+	if result.Components == nil {
+		result.Components = []MatchComponent{}
+	}
+	return result
+}
+
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
+
+// NormalizeSkillName makes skill names comparable: case and spacing are
+// ignored, so "Node.js" matches "node.js " but not "NodeJS". Synonyms are
+// resolved by SkillTaxonomy.
+func NormalizeSkillName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// profileSkillNames returns the skills of a profile, falling back to the
+// flat Skills string for profiles without structured records.
+func profileSkillNames(profile *models.Profile) []string {
+	var names []string
+	for _, s := range profile.ProfileSkills {
+		names = append(names, s.Name)
+	}
+	if len(names) == 0 {
+		for _, s := range strings.Split(profile.Skills, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				names = append(names, s)
+			}
+		}
+	}
+	return names
+}
+
+// ExperienceYears totals the time covered by experience entries, counting
+// overlapping positions once. Entries without a parseable start date are
+// ignored; a missing end date means the position is current.
+func ExperienceYears(entries []models.ExperienceEntry, now time.Time) float64 {
+	type span struct{ start, end int }
+	current := now.Year()*12 + int(now.Month()) - 1
+	var spans []span
+	for _, e := range entries {
+		start, ok := resumeMonth(e.StartDate, false)
+		if !ok {
+			continue
+		}
+		end, ok := resumeMonth(e.EndDate, true)
+		if !ok || end > current {
+			end = current
+		}
+		if end >= start {
+			spans = append(spans, span{start, end + 1})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	months, covered := 0, math.MinInt
+	for _, s := range spans {
+		if s.start < covered {
+			s.start = covered
+		}
+		if s.end > s.start {
+			months += s.end - s.start
+			covered = s.end
+		}
+	}
+	return round1(float64(months) / 12)
+}
+
+// resumeMonth converts a "YYYY" or "YYYY-MM" date to a month count. A bare
+// year is read as January, or December when it ends a range.
+func resumeMonth(date string, end bool) (int, bool) {
+	year, month, hasMonth := strings.Cut(NormalizeResumeDate(date), "-")
+	y, err := strconv.Atoi(year)
+	if err != nil || len(year) != 4 {
+		return 0, false
+	}
+	m := 1
+	if end {
+		m = 12
+	}
+	if hasMonth {
+		if m, err = strconv.Atoi(month); err != nil || m < 1 || m > 12 {
+			return 0, false
+		}
+	}
+	return y*12 + m - 1, true
+}
+
+var degreeWord = regexp.MustCompile(`[A-Za-z][A-Za-z.]*`)
+
+// degreeLevels maps the words degrees are written with, dots removed, to
+// the level they denote.
+var degreeLevels = map[string]models.EducationLevel{
+	"phd": models.EducationDoctorate, "dphil": models.EducationDoctorate, "doctor": models.EducationDoctorate,
+	"doctorate": models.EducationDoctorate, "edd": models.EducationDoctorate,
+	"master": models.EducationMaster, "masters": models.EducationMaster, "msc": models.EducationMaster,
+	"mba": models.EducationMaster, "mtech": models.EducationMaster, "meng": models.EducationMaster,
+	"mphil": models.EducationMaster, "mca": models.EducationMaster,
+	"bachelor": models.EducationBachelor, "bachelors": models.EducationBachelor, "bsc": models.EducationBachelor,
+	"btech": models.EducationBachelor, "beng": models.EducationBachelor, "bba": models.EducationBachelor,
+	"bca": models.EducationBachelor, "grado": models.EducationBachelor, "licenciatura": models.EducationBachelor,
+	"diploma": models.EducationDiploma, "associate": models.EducationDiploma, "associates": models.EducationDiploma,
+	"hnd": models.EducationDiploma,
+}
+
+// degreeAbbreviations are degrees spelled like common words, as in "Master
+// of Arts in Education, Be Well Institute". They only count as the first
+// word of a degree, written in capitals or with dots: "MS", "B.E.".
+var degreeAbbreviations = map[string]models.EducationLevel{
+	"ms": models.EducationMaster, "ma": models.EducationMaster, "me": models.EducationMaster,
+	"bs": models.EducationBachelor, "ba": models.EducationBachelor, "be": models.EducationBachelor,
+}
+
+// EducationLevelOf infers the level of a degree such as "B.Sc." or
+// "Master of Science". When a degree names several levels, as in "BS/MS
+// Computer Science", the highest one is returned.
+func EducationLevelOf(degree string) models.EducationLevel {
+	highest := models.EducationNone
+	for _, loc := range degreeWord.FindAllStringIndex(degree, -1) {
+		raw := degree[loc[0]:loc[1]]
+		word := strings.ToLower(strings.ReplaceAll(raw, ".", ""))
+		level, ok := degreeLevels[word]
+		if !ok && (strings.Contains(raw, ".") || raw == strings.ToUpper(raw)) {
+			// First or after a slash, as in "BS/MS"
+			if before := strings.TrimSpace(degree[:loc[0]]); before == "" || strings.HasSuffix(before, "/") {
+				level = degreeAbbreviations[word]
+			}
+		}
+		if level.Rank() > highest.Rank() {
+			highest = level
+		}
+	}
+	return highest
+}
+
+// HighestEducationLevel returns the highest level among education entries.
+func HighestEducationLevel(entries []models.EducationEntry) models.EducationLevel {
+	highest := models.EducationNone
+	for _, e := range entries {
+		if level := EducationLevelOf(e.Degree); level.Rank() > highest.Rank() {
+			highest = level
+		}
+	}
+	return highest
+}
+
+// RankedCandidate is a candidate with their match against a job.
+// ApplicationID and Stage are set for applicants to the job.
+type RankedCandidate struct {
+	ApplicantID   uint                    `json:"applicant_id"`
+	ApplicationID uint                    `json:"application_id,omitempty"`
+	Stage         models.ApplicationStage `json:"stage,omitempty"`
+	Name          string                  `json:"name"`
+	Email         string                  `json:"email"`
+	Match         MatchResult             `json:"match"`
+}
+
+// CandidateMatcher ranks candidates by how well they fit a job.
+type CandidateMatcher struct {
+	DB     *gorm.DB
+	Skills *SkillTaxonomy
+}
+
+func NewCandidateMatcher(db *gorm.DB, skills *SkillTaxonomy) *CandidateMatcher {
+	return &CandidateMatcher{DB: db, Skills: skills}
+}
+
+// LoadJob fetches a job with its skills.
+func (m *CandidateMatcher) LoadJob(jobID interface{}) (*models.Job, error) {
+	var job models.Job
+	if err := m.DB.Preload("Skills").First(&job, jobID).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// RankApplicants scores everyone who applied to job, best first.
+// Applicants without a profile score as an empty profile.
+func (m *CandidateMatcher) RankApplicants(job *models.Job) ([]RankedCandidate, error) {
+	var applications []models.Application
+	if err := m.DB.Preload("Applicant").Where("job_id = ?", job.ID).Order("id").
+		Find(&applications).Error; err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, len(applications))
+	for i, a := range applications {
+		userIDs[i] = a.ApplicantID
+	}
+	profiles, err := m.loadProfiles(m.DB.Where("user_id IN ?", userIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ranked := make([]RankedCandidate, 0, len(applications))
+	for _, a := range applications {
+		profile := profiles[a.ApplicantID]
+		if profile == nil {
+			profile = &models.Profile{}
+		}
+		ranked = append(ranked, RankedCandidate{
+			ApplicantID:   a.ApplicantID,
+			ApplicationID: a.ID,
+			Stage:         a.Stage,
+			Name:          a.Applicant.Name,
+			Email:         a.Applicant.Email,
+			Match:         ScoreCandidate(job, profile, m.Skills, now),
+		})
+	}
+	sortRanked(ranked)
+	return ranked, nil
+}
+
+// SuggestCandidates scores applicants who have not applied to job and
+// returns up to limit of them scoring at least minScore, best first. When
+// the job lists skills only profiles with at least one of them are scored.
+func (m *CandidateMatcher) SuggestCandidates(job *models.Job, minScore float64, limit int) ([]RankedCandidate, error) {
+	query := m.DB.
+		Where("user_id IN (?)", m.DB.Model(&models.User{}).Select("id").Where("user_type = ?", "Applicant")).
+		Where("user_id NOT IN (?)", m.DB.Model(&models.Application{}).Select("applicant_id").Where("job_id = ?", job.ID))
+	if len(job.Skills) > 0 {
+		var names []string
+		for _, s := range job.Skills {
+			names = append(names, m.Skills.Variants(s.Name, false)...)
+		}
+		query = query.Where("id IN (?)", m.DB.Model(&models.ProfileSkill{}).Select("profile_id").
+			Where("LOWER(name) IN ?", names))
+	}
+	profiles, err := m.loadProfiles(query)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(profiles))
+	for userID := range profiles {
+		userIDs = append(userIDs, userID)
+	}
+	var users []models.User
+	if err := m.DB.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ranked := []RankedCandidate{}
+	for _, u := range users {
+		match := ScoreCandidate(job, profiles[u.ID], m.Skills, now)
+		if match.Score < minScore {
+			continue
+		}
+		ranked = append(ranked, RankedCandidate{ApplicantID: u.ID, Name: u.Name, Email: u.Email, Match: match})
+	}
+	sortRanked(ranked)
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, nil
+}
+
+// loadProfiles fetches the profiles matched by query with their entries,
+// keyed by user.
+func (m *CandidateMatcher) loadProfiles(query *gorm.DB) (map[uint]*models.Profile, error) {
+	var profiles []models.Profile
+	if err := query.Preload("Educations").Preload("Experiences").Preload("ProfileSkills").
+		Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	byUser := make(map[uint]*models.Profile, len(profiles))
+	for i := range profiles {
+		byUser[profiles[i].UserID] = &profiles[i]
+	}
+	return byUser, nil
+}
+
+// sortRanked orders candidates by score, then those meeting every
+// requirement, then by who applied or registered first.
+func sortRanked(ranked []RankedCandidate) {
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Match.Score != b.Match.Score {
+			return a.Match.Score > b.Match.Score
+		}
+		if a.Match.MeetsRequirements != b.Match.MeetsRequirements {
+			return a.Match.MeetsRequirements
+		}
+		if a.ApplicationID != b.ApplicationID {
+			return a.ApplicationID < b.ApplicationID
+		}
+		return a.ApplicantID < b.ApplicantID
+	})
+}
+
+// ReplaceJobSkills sets the required and preferred skills of a job. Names
+// are deduplicated ignoring case; a skill listed as both is required.
+func ReplaceJobSkills(tx *gorm.DB, job *models.Job, required, preferred []string) error {
+	if err := tx.Unscoped().Where("job_id = ?", job.ID).Delete(&models.JobSkill{}).Error; err != nil {
+		return err
+	}
+
+	job.Skills = nil
+	index := map[string]int{}
+	add := func(names []string, isRequired bool) {
+		for _, name := range names {
+			name = strings.Join(strings.Fields(name), " ")
+			key := NormalizeSkillName(name)
+			if key == "" {
+				continue
+			}
+			if i, ok := index[key]; ok {
+				job.Skills[i].Required = job.Skills[i].Required || isRequired
+				continue
+			}
+			index[key] = len(job.Skills)
+			job.Skills = append(job.Skills, models.JobSkill{JobID: job.ID, Name: name, Required: isRequired})
+		}
+	}
+	add(required, true)
+	add(preferred, false)
+
+	if len(job.Skills) == 0 {
+		return nil
+	}
+	return tx.Create(&job.Skills).Error
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestExperienceYears(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		entries [][2]string
+		want    float64
+	}{
+		{"none", nil, 0},
+		{"months", [][2]string{{"2019-01", "2020-12"}}, 2},
+		{"bare years span whole years", [][2]string{{"2019", "2020"}}, 2},
+		{"month names", [][2]string{{"March 2020", "Feb 2021"}}, 1},
+		{"overlapping", [][2]string{{"2018-01", "2020-12"}, {"2020-01", "2021-12"}}, 4},
+		{"contained", [][2]string{{"2019-01", "2019-06"}, {"2018-01", "2021-12"}}, 4},
+		{"gap", [][2]string{{"2015-01", "2015-12"}, {"2017-01", "2017-06"}}, 1.5},
+		{"current", [][2]string{{"2023-07", ""}}, 1},
+		{"present", [][2]string{{"2023-07", "Present"}}, 1},
+		{"current overlapping a past position", [][2]string{{"2022-07", "2023-12"}, {"2023-07", ""}}, 2},
+		{"future end", [][2]string{{"2024-01", "2030-12"}}, 0.5},
+		{"no start", [][2]string{{"", "2020-12"}, {"soon", "2020-12"}}, 0},
+		{"end before start", [][2]string{{"2021-01", "2020-01"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []models.ExperienceEntry
+			for _, e := range tt.entries {
+				entries = append(entries, models.ExperienceEntry{StartDate: e[0], EndDate: e[1]})
+			}
+			if got := ExperienceYears(entries, now); got != tt.want {
+				t.Errorf("ExperienceYears = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEducationLevelOf(t *testing.T) {
+	tests := []struct {
+		degree string
+		want   models.EducationLevel
+	}{
+		{"", models.EducationNone},
+		{"High School", models.EducationNone},
+		{"B.Sc.", models.EducationBachelor},
+		{"B.Sc.(Hons) Physics", models.EducationBachelor},
+		{"Bachelor of Arts", models.EducationBachelor},
+		{"B.E. Mechanical", models.EducationBachelor},
+		{"BS Computer Science", models.EducationBachelor},
+		{"Master of Science", models.EducationMaster},
+		{"M.S.", models.EducationMaster},
+		{"MBA", models.EducationMaster},
+		{"Ph.D. Chemistry", models.EducationDoctorate},
+		{"Diploma in Business", models.EducationDiploma},
+		{"Associate of Arts", models.EducationDiploma},
+		// The highest level named wins
+		{"BS/MS Computer Science", models.EducationMaster},
+		{"Bachelor and Master of Engineering", models.EducationMaster},
+		{"Diploma, then Ph.D.", models.EducationDoctorate},
+		// Abbreviations spelled like words only count on their own
+		{"Certificate: Be Me", models.EducationNone},
+		{"Ms Excel course", models.EducationNone},
+		{"Coursework in ML, MS Office", models.EducationNone},
+		{"Certificate in Arts and Media", models.EducationNone},
+	}
+	for _, tt := range tests {
+		if got := EducationLevelOf(tt.degree); got != tt.want {
+			t.Errorf("EducationLevelOf(%q) = %q, want %q", tt.degree, got, tt.want)
+		}
+	}
+}
+
+func TestScoreCandidate(t *testing.T) {
+	skills := &SkillTaxonomy{}
+	skills.index([]models.Skill{{Model: gorm.Model{ID: 1}, Name: "JavaScript", Synonyms: []models.SkillSynonym{{Name: "js"}}}})
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+	// Two years of experience and a master's degree
+	profile := &models.Profile{
+		ProfileSkills: []models.ProfileSkill{{Name: "Go"}, {Name: "JS"}},
+		Experiences:   []models.ExperienceEntry{{StartDate: "2022-07"}},
+		Educations:    []models.EducationEntry{{Degree: "B.Sc."}, {Degree: "M.Sc."}},
+	}
+	jobSkills := func(required []string, preferred ...string) []models.JobSkill {
+		var s []models.JobSkill
+		for _, name := range required {
+			s = append(s, models.JobSkill{Name: name, Required: true})
+		}
+		for _, name := range preferred {
+			s = append(s, models.JobSkill{Name: name})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name    string
+		job     models.Job
+		profile *models.Profile
+		score   float64
+		meets   bool
+		// The weight and points of each component, in order
+		components [][2]float64
+		missing    []string
+	}{
+		{
+			name: "no criteria", job: models.Job{}, profile: profile,
+			score: 0, meets: true, components: [][2]float64{}, missing: []string{},
+		},
+		{
+			name: "required skills alone carry the whole score", job: models.Job{Skills: jobSkills([]string{"Go", "javascript", "Rust", "Docker"})},
+			profile: profile, score: 50, meets: false, components: [][2]float64{{100, 50}}, missing: []string{"Rust", "Docker"},
+		},
+		{
+			name: "every criterion",
+			job: models.Job{Skills: jobSkills([]string{"Go", "JavaScript"}, "Kubernetes"),
+				MinExperienceYears: 4, EducationLevel: models.EducationBachelor},
+			profile: profile, score: 70, meets: false,
+			components: [][2]float64{{50, 50}, {20, 0}, {20, 10}, {10, 10}}, missing: []string{},
+		},
+		{
+			name:    "experience and education rescaled",
+			job:     models.Job{MinExperienceYears: 2, EducationLevel: models.EducationDoctorate},
+			profile: profile, score: 91.7, meets: false,
+			components: [][2]float64{{66.7, 66.7}, {33.3, 25}}, missing: []string{},
+		},
+		{
+			name: "flat skills of an unparsed profile", job: models.Job{Skills: jobSkills([]string{"Go"}, "Docker")},
+			profile: &models.Profile{Skills: "go, Docker"}, score: 100, meets: true,
+			components: [][2]float64{{71.4, 71.4}, {28.6, 28.6}}, missing: []string{},
+		},
+		{
+			name: "empty profile", job: models.Job{Skills: jobSkills([]string{"Go"}), MinExperienceYears: 1, EducationLevel: models.EducationDiploma},
+			profile: &models.Profile{}, score: 0, meets: false,
+			components: [][2]float64{{62.5, 0}, {25, 0}, {12.5, 0}}, missing: []string{"Go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScoreCandidate(&tt.job, tt.profile, skills, now)
+			components := [][2]float64{}
+			for _, c := range got.Components {
+				components = append(components, [2]float64{c.Weight, c.Points})
+			}
+			if got.Score != tt.score || got.MeetsRequirements != tt.meets || !reflect.DeepEqual(components, tt.components) {
+				t.Errorf("ScoreCandidate = %v, meets %v, components %v; want %v, %v, %v",
+					got.Score, got.MeetsRequirements, components, tt.score, tt.meets, tt.components)
+			}
+			if !reflect.DeepEqual(got.MissingSkills, tt.missing) {
+				t.Errorf("missing skills = %v, want %v", got.MissingSkills, tt.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// extractTextFromODT reads the text of an OpenDocument text file from its
+// content.xml part.
+func extractTextFromODT(content []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", err
+	}
+
+	var body []byte
+	for _, file := range archive.File {
+		if file.Name == "content.xml" {
+			if body, err = readZipFile(file, 32<<20); err != nil {
+				return "", err
+			}
+			break
+		}
+	}
+	if body == nil {
+		return "", fmt.Errorf("content.xml not found")
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var buf strings.Builder
+	skip := 0
+	// Text only counts inside paragraphs and headings, where runs of white
+	// space collapse to one space. Spaces, tabs and line breaks the author
+	// typed are elements of their own.
+	paragraphs := 0
+	// Cells of a table row are separated by tabs, and paragraphs within a
+	// cell by spaces.
+	cells, cellParagraphs, rowCells := 0, 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skip > 0 || isODTSkipped(t.Name) {
+				skip++
+				continue
+			}
+			switch t.Name.Local {
+			case "p", "h":
+				if cells > 0 && cellParagraphs > 0 {
+					buf.WriteString(" ")
+				}
+				paragraphs++
+				cellParagraphs++
+			case "tab":
+				buf.WriteString("\t")
+			case "line-break":
+				buf.WriteString("\n")
+			case "s":
+				count := 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "c" {
+						if n, err := strconv.Atoi(attr.Value); err == nil && n > 0 {
+							count = n
+						}
+					}
+				}
+				buf.WriteString(strings.Repeat(" ", count))
+			case "list-item":
+				buf.WriteString("- ")
+			case "table-row":
+				rowCells = 0
+			case "table-cell":
+				if rowCells > 0 {
+					buf.WriteString("\t")
+				}
+				rowCells++
+				cells++
+				cellParagraphs = 0
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			switch t.Name.Local {
+			case "p", "h":
+				paragraphs--
+				if cells == 0 {
+					buf.WriteString("\n")
+				}
+			case "table-cell":
+				cells--
+			case "table-row":
+				buf.WriteString("\n")
+			}
+		case xml.CharData:
+			if skip == 0 && paragraphs > 0 {
+				buf.WriteString(odtSpaces.ReplaceAllString(string(t), " "))
+			}
+		}
+	}
+	return buf.String(), nil
+}
+
+var odtSpaces = regexp.MustCompile(`[ \t\r\n]+`)
+
+// isODTSkipped reports elements whose text is not part of the document body,
+// such as comments and the style definitions.
+func isODTSkipped(name xml.Name) bool {
+	switch name.Local {
+	case "annotation", "tracked-changes", "automatic-styles", "font-face-decls", "scripts", "note-citation":
+		return true
+	}
+	return false
+}
+
+var odtPagesPattern = regexp.MustCompile(`meta:page-count="(\d+)"`)
+
+// inspectODT rejects OpenDocument files that are encrypted or carry Basic
+// macros or scripts.
+func inspectODT(content []byte) (int, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return 0, rejectUpload("File is not a readable ODT document")
+	}
+
+	pages := 0
+	for _, file := range archive.File {
+		switch {
+		case strings.HasPrefix(file.Name, "Basic/") || strings.HasPrefix(file.Name, "Scripts/"):
+			return 0, rejectUpload("Documents containing macros are not accepted")
+		case file.Name == "META-INF/manifest.xml":
+			data, err := readZipFile(file, 1<<20)
+			if err == nil && bytes.Contains(data, []byte("encryption-data")) {
+				return 0, rejectUpload("Password-protected documents are not accepted")
+			}
+		case file.Name == "meta.xml":
+			data, err := readZipFile(file, 1<<20)
+			if err == nil {
+				if m := odtPagesPattern.FindSubmatch(data); m != nil {
+					pages, _ = strconv.Atoi(string(m[1]))
+				}
+			}
+		}
+	}
+	return pages, nil
+}
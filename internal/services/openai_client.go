@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GolangAssignment/internal/config"
+)
+
+// Structured output modes for OpenAI-compatible servers. Not every
+// self-hosted server supports JSON schemas, so the mode is configurable.
+const (
+	OpenAIJSONSchema = "json_schema"
+	OpenAIJSONObject = "json_object"
+	OpenAIPlainText  = "text"
+)
+
+// OpenAIClient calls an OpenAI-compatible /v1/chat/completions endpoint,
+// such as OpenAI itself or a self-hosted model server. It implements
+// LLMClient.
+type OpenAIClient struct {
+	APIKey string
+	// BaseURL includes the version, e.g. https://api.openai.com/v1.
+	BaseURL        string
+	Model          string
+	ResponseFormat string
+	// Timeout bounds each attempt; retries get a fresh timeout.
+	Timeout      time.Duration
+	MaxRetries   int
+	RetryBackoff time.Duration
+	HTTPClient   *http.Client
+}
+
+func NewOpenAIClient(cfg config.Config, httpClient *http.Client) *OpenAIClient {
+	return &OpenAIClient{
+		APIKey:         cfg.OpenAIAPIKey,
+		BaseURL:        strings.TrimRight(cfg.OpenAIAPIURL, "/"),
+		Model:          cfg.OpenAIModel,
+		ResponseFormat: cfg.OpenAIResponseFormat,
+		Timeout:        cfg.LLMTimeout,
+		MaxRetries:     cfg.LLMMaxRetries,
+		RetryBackoff:   cfg.LLMRetryBackoff,
+		HTTPClient:     httpClient,
+	}
+}
+
+func (c *OpenAIClient) Name() string {
+	return "openai"
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Refusal string `json:"refusal,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema *struct {
+		Name   string     `json:"name"`
+		Schema *LLMSchema `json:"schema"`
+	} `json:"json_schema,omitempty"`
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	Temperature    float64               `json:"temperature"`
+	MaxTokens      int                   `json:"max_tokens,omitempty"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// Generate sends the prompt, retrying rate limits, server errors and
+// timeouts with exponential backoff.
+func (c *OpenAIClient) Generate(ctx context.Context, prompt LLMRequest) (*LLMResponse, error) {
+	request := openAIRequest{
+		Model:       c.Model,
+		Temperature: prompt.Temperature,
+		MaxTokens:   prompt.MaxOutputTokens,
+	}
+	if prompt.System != "" {
+		request.Messages = append(request.Messages, openAIMessage{Role: "system", Content: prompt.System})
+	}
+	request.Messages = append(request.Messages, openAIMessage{Role: "user", Content: prompt.User})
+
+	if prompt.Schema != nil {
+		switch c.ResponseFormat {
+		case OpenAIJSONSchema, "":
+			format := &openAIResponseFormat{Type: OpenAIJSONSchema}
+			format.JSONSchema = &struct {
+				Name   string     `json:"name"`
+				Schema *LLMSchema `json:"schema"`
+			}{Name: "response", Schema: prompt.Schema}
+			request.ResponseFormat = format
+		case OpenAIJSONObject:
+			request.ResponseFormat = &openAIResponseFormat{Type: OpenAIJSONObject}
+		}
+	}
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return withRetries(ctx, c.MaxRetries, c.RetryBackoff, func() (*LLMResponse, error) {
+		return c.generateOnce(ctx, payload)
+	})
+}
+
+func (c *OpenAIClient) generateOnce(ctx context.Context, payload []byte) (*LLMResponse, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, body)
+	}
+
+	var response openAIResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid chat completion response: %v", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, errors.New("chat completion returned no choices")
+	}
+
+	choice := response.Choices[0]
+	if choice.Message.Refusal != "" {
+		return nil, &BlockedError{Reason: choice.Message.Refusal}
+	}
+	if choice.FinishReason == "content_filter" && strings.TrimSpace(choice.Message.Content) == "" {
+		return nil, &BlockedError{Reason: choice.FinishReason}
+	}
+
+	result := &LLMResponse{
+		Text:         choice.Message.Content,
+		FinishReason: choice.FinishReason,
+		Partial:      choice.FinishReason != "" && choice.FinishReason != "stop",
+		Provider:     c.Name(),
+		Model:        response.Model,
+		PromptTokens: response.Usage.PromptTokens,
+		OutputTokens: response.Usage.CompletionTokens,
+	}
+	if result.Model == "" {
+		result.Model = c.Model
+	}
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ParseCache stores LLM parse results by file content hash and prompt
+// version, so re-uploads of the same file do not pay for another call. All
+// methods are no-ops on a nil cache.
+type ParseCache struct {
+	DB *gorm.DB
+}
+
+func NewParseCache(db *gorm.DB) *ParseCache {
+	return &ParseCache{DB: db}
+}
+
+// Get returns the cached result, or nil.
+func (c *ParseCache) Get(contentHash, promptVersion string) *ResumeData {
+	if c == nil || contentHash == "" {
+		return nil
+	}
+	var entry models.ParseCacheEntry
+	err := c.DB.Where("content_hash = ? AND prompt_version = ?", contentHash, promptVersion).First(&entry).Error
+	if err != nil {
+		return nil
+	}
+	var data ResumeData
+	if err := json.Unmarshal([]byte(entry.ParsedData), &data); err != nil {
+		log.Printf("Ignoring invalid parse cache entry %d: %v", entry.ID, err)
+		return nil
+	}
+	c.DB.Model(&entry).UpdateColumn("hits", gorm.Expr("hits + 1"))
+	return &data
+}
+
+// Put stores a result. An existing entry for the same key is kept.
+func (c *ParseCache) Put(contentHash, promptVersion string, response *LLMResponse, data *ResumeData) {
+	if c == nil || contentHash == "" {
+		return
+	}
+	parsed, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	entry := models.ParseCacheEntry{
+		ContentHash:   contentHash,
+		PromptVersion: promptVersion,
+		Provider:      response.Provider,
+		ModelName:     response.Model,
+		ParsedData:    string(parsed),
+	}
+	if err := c.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+		log.Printf("Failed to cache parse result: %v", err)
+	}
+}
@@ -0,0 +1,404 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unipdf/v3/core"
+	"github.com/unidoc/unipdf/v3/extractor"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+// PDF text comes as positioned marks with no reading order beyond the
+// content stream. The layout pass groups marks into lines, splits pages into
+// columns at the widest vertical gutter, and classifies lines into headings,
+// list items and paragraphs by font size, weight and bullets.
+//
+// Coordinates are PDF user space: y grows upwards.
+
+// textGlyph is one positioned text mark.
+type textGlyph struct {
+	Text   string
+	X0, X1 float64
+	Y0, Y1 float64
+	Size   float64
+	Bold   bool
+}
+
+// layoutLine is a run of glyphs on one baseline with no wide gaps.
+type layoutLine struct {
+	Text   string
+	X0, X1 float64
+	Y0, Y1 float64
+	Size   float64
+	Bold   bool
+	Column int
+}
+
+// extractPDFDocument extracts the structured text of a PDF.
+func extractPDFDocument(content []byte) (*Document, error) {
+	pdfReader, err := model.NewPdfReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("error creating PDF reader: %v", err)
+	}
+
+	numPages, err := pdfReader.GetNumPages()
+	if err != nil {
+		return nil, fmt.Errorf("error getting number of pages: %v", err)
+	}
+
+	pages := make([][]textGlyph, numPages)
+	for i := 0; i < numPages; i++ {
+		page, err := pdfReader.GetPage(i + 1)
+		if err != nil {
+			return nil, fmt.Errorf("error getting page %d: %v", i+1, err)
+		}
+		if pages[i], err = pdfPageGlyphs(page); err != nil {
+			return nil, fmt.Errorf("error extracting text from page %d: %v", i+1, err)
+		}
+	}
+
+	return layoutDocument(pages), nil
+}
+
+// extractTextFromPDF extracts the text of a PDF in reading order.
+func extractTextFromPDF(content []byte) (string, error) {
+	doc, err := extractPDFDocument(content)
+	if err != nil {
+		return "", err
+	}
+	return doc.Text(), nil
+}
+
+func pdfPageGlyphs(page *model.PdfPage) ([]textGlyph, error) {
+	ex, err := extractor.New(page)
+	if err != nil {
+		return nil, err
+	}
+	pageText, _, _, err := ex.ExtractPageText()
+	if err != nil {
+		return nil, err
+	}
+
+	bold := map[*model.PdfFont]bool{}
+	var glyphs []textGlyph
+	for _, mark := range pageText.Marks().Elements() {
+		// Meta marks are spaces and line breaks inserted by the extractor;
+		// the layout pass derives its own from positions.
+		if mark.Meta || strings.TrimSpace(mark.Text) == "" {
+			continue
+		}
+		isBold, ok := bold[mark.Font]
+		if !ok {
+			isBold = fontIsBold(mark.Font)
+			bold[mark.Font] = isBold
+		}
+		glyphs = append(glyphs, textGlyph{
+			Text: mark.Text,
+			X0:   mark.BBox.Llx, X1: mark.BBox.Urx,
+			Y0: mark.BBox.Lly, Y1: mark.BBox.Ury,
+			Size: mark.FontSize,
+			Bold: isBold,
+		})
+	}
+	return glyphs, nil
+}
+
+// fontIsBold reports whether a font is bold by its name, weight or flags.
+func fontIsBold(font *model.PdfFont) bool {
+	if font == nil {
+		return false
+	}
+	name := strings.ToLower(font.BaseFont())
+	for _, weight := range []string{"bold", "black", "heavy", "semibold", "demi"} {
+		if strings.Contains(name, weight) {
+			return true
+		}
+	}
+	if descriptor := font.FontDescriptor(); descriptor != nil {
+		if weight, err := core.GetNumberAsFloat(core.TraceToDirectObject(descriptor.FontWeight)); err == nil && weight >= 600 {
+			return true
+		}
+		// ForceBold flag
+		if flags, ok := core.GetIntVal(core.TraceToDirectObject(descriptor.Flags)); ok && flags&(1<<18) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutDocument turns the glyphs of every page into blocks.
+func layoutDocument(pages [][]textGlyph) *Document {
+	body := bodyFontSize(pages)
+	doc := &Document{}
+	for i, glyphs := range pages {
+		if i > 0 {
+			doc.Blocks = append(doc.Blocks, Block{Role: BlockPageBreak, Page: i + 1, Column: -1})
+		}
+		lines := buildLines(glyphs)
+		doc.Blocks = append(doc.Blocks, classifyLines(orderColumns(lines, body), body, i+1)...)
+	}
+	return doc
+}
+
+// bodyFontSize is the font size covering the most characters.
+func bodyFontSize(pages [][]textGlyph) float64 {
+	counts := map[float64]int{}
+	for _, glyphs := range pages {
+		for _, g := range glyphs {
+			counts[math.Round(g.Size*2)/2] += len([]rune(g.Text))
+		}
+	}
+	body, best := 10.0, 0
+	for size, count := range counts {
+		if count > best || count == best && size < body {
+			body, best = size, count
+		}
+	}
+	return body
+}
+
+// buildLines groups glyphs sharing a baseline into lines, splitting a line
+// where the horizontal gap is wide enough to be a column gutter.
+func buildLines(glyphs []textGlyph) []layoutLine {
+	sorted := append([]textGlyph(nil), glyphs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Y0 > sorted[j].Y0 })
+
+	// Cluster by baseline
+	var rows [][]textGlyph
+	for _, g := range sorted {
+		n := len(rows)
+		if n > 0 {
+			first := rows[n-1][0]
+			if math.Abs(first.Y0-g.Y0) <= 0.4*math.Max(math.Min(first.Size, g.Size), 1) {
+				rows[n-1] = append(rows[n-1], g)
+				continue
+			}
+		}
+		rows = append(rows, []textGlyph{g})
+	}
+
+	var lines []layoutLine
+	for _, row := range rows {
+		sort.SliceStable(row, func(i, j int) bool { return row[i].X0 < row[j].X0 })
+
+		var current *layoutLine
+		var text strings.Builder
+		allBold := true
+		finish := func() {
+			if current != nil {
+				current.Text = strings.TrimSpace(text.String())
+				current.Bold = allBold
+				lines = append(lines, *current)
+			}
+		}
+		prevX1 := 0.0
+		for _, g := range row {
+			size := math.Max(g.Size, 1)
+			gap := g.X0 - prevX1
+			if current != nil && gap > 2.5*size {
+				finish()
+				current = nil
+			}
+			if current == nil {
+				current = &layoutLine{X0: g.X0, X1: g.X1, Y0: g.Y0, Y1: g.Y1, Size: g.Size}
+				text.Reset()
+				allBold = true
+			} else if gap > 0.2*size && !strings.HasSuffix(text.String(), " ") {
+				text.WriteString(" ")
+			}
+			text.WriteString(g.Text)
+			current.X1 = math.Max(current.X1, g.X1)
+			current.Y0 = math.Min(current.Y0, g.Y0)
+			current.Y1 = math.Max(current.Y1, g.Y1)
+			current.Size = math.Max(current.Size, g.Size)
+			allBold = allBold && g.Bold
+			prevX1 = g.X1
+		}
+		finish()
+	}
+	return lines
+}
+
+// gutterSteps caps the positions findGutter tries across a page.
+const gutterSteps = 2000
+
+// findGutter returns the x position of a vertical gutter splitting the page
+// into two columns, if there is one. The gutter is the least covered strip
+// in the middle half of the text area; it must be crossed by few lines and
+// have enough lines on either side.
+func findGutter(lines []layoutLine, body float64) (float64, bool) {
+	if len(lines) < 6 {
+		return 0, false
+	}
+	left, right := math.Inf(1), math.Inf(-1)
+	for _, l := range lines {
+		left, right = math.Min(left, l.X0), math.Max(right, l.X1)
+	}
+	width := right - left
+	if width <= 0 {
+		return 0, false
+	}
+
+	// Scan in steps of a point, or coarser on very wide pages, so a bogus
+	// text matrix cannot make the scan run away
+	step := math.Max(1, width*0.5/gutterSteps)
+	bestX, bestCount, bestRun := 0.0, len(lines)+1, 0.0
+	runStart, runCount := math.NaN(), -1
+	for i := 0; i <= gutterSteps; i++ {
+		x := left + width*0.25 + float64(i)*step
+		if x > left+width*0.75 {
+			break
+		}
+		count := 0
+		for _, l := range lines {
+			if l.X0 < x && l.X1 > x {
+				count++
+			}
+		}
+		if count != runCount {
+			runStart, runCount = x, count
+		}
+		run := x - runStart
+		if count < bestCount || count == bestCount && run > bestRun {
+			bestX, bestCount, bestRun = runStart+run/2, count, run
+		}
+	}
+
+	if bestRun < body || float64(bestCount) > 0.15*float64(len(lines)) {
+		return 0, false
+	}
+	leftLines, rightLines := 0, 0
+	for _, l := range lines {
+		switch {
+		case l.X1 <= bestX:
+			leftLines++
+		case l.X0 >= bestX:
+			rightLines++
+		}
+	}
+	if leftLines < 3 || rightLines < 3 {
+		return 0, false
+	}
+	return bestX, true
+}
+
+// orderColumns puts lines in reading order. On two-column pages the left
+// column is read before the right; lines crossing the gutter, such as a
+// full-width name header, flush the columns collected above them.
+func orderColumns(lines []layoutLine, body float64) []layoutLine {
+	gutter, ok := findGutter(lines, body)
+	if !ok {
+		return lines
+	}
+
+	var ordered, leftCol, rightCol []layoutLine
+	flush := func() {
+		ordered = append(ordered, leftCol...)
+		ordered = append(ordered, rightCol...)
+		leftCol, rightCol = nil, nil
+	}
+	for _, l := range lines {
+		switch {
+		case l.X1 <= gutter:
+			l.Column = 0
+			leftCol = append(leftCol, l)
+		case l.X0 >= gutter:
+			l.Column = 1
+			rightCol = append(rightCol, l)
+		default:
+			l.Column = -1
+			flush()
+			ordered = append(ordered, l)
+		}
+	}
+	flush()
+	return ordered
+}
+
+// classifyLines groups ordered lines into blocks.
+func classifyLines(lines []layoutLine, body float64, page int) []Block {
+	var blocks []Block
+	var prev *layoutLine
+	var itemX float64
+
+	for i := range lines {
+		line := &lines[i]
+		if line.Text == "" {
+			continue
+		}
+
+		// A new column, or a vertical gap over about one blank line, ends
+		// the current paragraph or list item.
+		separate := prev == nil || prev.Column != line.Column ||
+			prev.Y0-line.Y1 > 0.9*math.Max(line.Size, prev.Size) || line.Y1 > prev.Y1
+
+		if isLayoutHeading(line, body) {
+			level := 2
+			if line.Size >= body*1.4 {
+				level = 1
+			}
+			if n := len(blocks); n > 0 && !separate && blocks[n-1].Role == BlockHeading && blocks[n-1].Level == level {
+				// Headings wrapped over two lines
+				blocks[n-1].Text += " " + line.Text
+			} else {
+				blocks = append(blocks, Block{Role: BlockHeading, Text: strings.TrimRight(line.Text, ":"), Page: page, Column: line.Column, Level: level})
+			}
+			prev = line
+			continue
+		}
+
+		if item, ok := trimListMarker(line.Text); ok {
+			blocks = append(blocks, Block{Role: BlockListItem, Text: item, Page: page, Column: line.Column})
+			itemX = line.X0
+			prev = line
+			continue
+		}
+
+		n := len(blocks)
+		switch {
+		case n > 0 && !separate && blocks[n-1].Role == BlockListItem && line.X0 > itemX+0.5:
+			// Wrapped list item, indented past its bullet
+			blocks[n-1].Text += " " + line.Text
+		case n > 0 && !separate && blocks[n-1].Role == BlockParagraph:
+			blocks[n-1].Text += "\n" + line.Text
+		default:
+			blocks = append(blocks, Block{Role: BlockParagraph, Text: line.Text, Page: page, Column: line.Column})
+		}
+		prev = line
+	}
+	return blocks
+}
+
+// isLayoutHeading reports whether a line is set apart as a heading: larger
+// than body text, or bold or in capitals while short and not a sentence.
+func isLayoutHeading(line *layoutLine, body float64) bool {
+	runes := []rune(line.Text)
+	if len(runes) > 60 || strings.HasSuffix(line.Text, ".") || strings.HasSuffix(line.Text, ",") {
+		return false
+	}
+	if _, isItem := trimListMarker(line.Text); isItem {
+		return false
+	}
+	hasLetter := false
+	for _, r := range runes {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+			break
+		}
+	}
+	if !hasLetter {
+		return false
+	}
+	if line.Size >= body*1.2 {
+		return true
+	}
+	if line.Bold && len(strings.Fields(line.Text)) <= 6 {
+		return true
+	}
+	return looksLikeHeading(line.Text)
+}
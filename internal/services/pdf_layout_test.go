@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// columnLines lays out n lines in each of two columns split at x = 300.
+func columnLines(n int) []layoutLine {
+	var lines []layoutLine
+	for i := 0; i < n; i++ {
+		y := 700 - float64(i)*14
+		lines = append(lines,
+			layoutLine{Text: "left", X0: 50, X1: 270, Y0: y, Y1: y + 10, Size: 10},
+			layoutLine{Text: "right", X0: 330, X1: 560, Y0: y, Y1: y + 10, Size: 10})
+	}
+	return lines
+}
+
+func TestFindGutter(t *testing.T) {
+	x, ok := findGutter(columnLines(5), 10)
+	if !ok || x <= 270 || x >= 330 {
+		t.Errorf("findGutter = %v, %v; want a gutter between 270 and 330", x, ok)
+	}
+
+	// A single full-width column has no gutter
+	var single []layoutLine
+	for i := 0; i < 8; i++ {
+		single = append(single, layoutLine{X0: 50, X1: 560, Size: 10})
+	}
+	if _, ok := findGutter(single, 10); ok {
+		t.Error("findGutter found a gutter in a single column")
+	}
+}
+
+func TestFindGutterWidePage(t *testing.T) {
+	lines := columnLines(5)
+	// A stray mark far off the page must not make the scan run away
+	lines = append(lines, layoutLine{Text: ".", X0: 1e15, X1: 1e15 + 1, Size: 10})
+
+	start := time.Now()
+	findGutter(lines, 10)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("findGutter took %v", elapsed)
+	}
+}
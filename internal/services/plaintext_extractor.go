@@ -0,0 +1,98 @@
+package services
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// extractTextFromPlain decodes a plain text file. UTF-16 files are
+// recognised by their byte order mark; anything that is not valid UTF-8 is
+// assumed to be Windows-1252, the usual encoding of older text resumes.
+func extractTextFromPlain(content []byte) (string, error) {
+	return normalizeNewlines(decodeText(content)), nil
+}
+
+func decodeText(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xEF, 0xBB, 0xBF}):
+		return string(content[3:])
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return decodeUTF16(content[2:], false)
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return decodeUTF16(content[2:], true)
+	case utf8.Valid(content):
+		return string(content)
+	}
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(content)
+	if err != nil {
+		return strings.ToValidUTF8(string(content), "")
+	}
+	return string(decoded)
+}
+
+func decodeUTF16(content []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(content)/2)
+	for i := 0; i+1 < len(content); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(content[i])<<8|uint16(content[i+1]))
+		} else {
+			units = append(units, uint16(content[i+1])<<8|uint16(content[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func normalizeNewlines(text string) string {
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+}
+
+var (
+	markdownImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	markdownEmphasis = regexp.MustCompile(`(\*\*|__|\*|~~|` + "`" + `)([^*~` + "`" + `\n]+)(\*\*|__|\*|~~|` + "`" + `)`)
+	markdownHeading  = regexp.MustCompile(`^#{1,6}\s+`)
+	markdownBullet   = regexp.MustCompile(`^(\s*)[*+]\s+`)
+	markdownQuote    = regexp.MustCompile(`^>\s?`)
+	markdownRule     = regexp.MustCompile(`^\s*([-*_]\s*){3,}$`)
+)
+
+// extractTextFromMarkdown strips Markdown syntax, keeping link targets so
+// profile URLs survive.
+func extractTextFromMarkdown(content []byte) (string, error) {
+	text, _ := extractTextFromPlain(content)
+
+	var out []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			if markdownRule.MatchString(line) {
+				out = append(out, "")
+				continue
+			}
+			line = markdownHeading.ReplaceAllString(line, "")
+			line = markdownQuote.ReplaceAllString(line, "")
+			line = markdownBullet.ReplaceAllString(line, "$1- ")
+			line = markdownImage.ReplaceAllString(line, "$1")
+			line = markdownLink.ReplaceAllStringFunc(line, func(link string) string {
+				m := markdownLink.FindStringSubmatch(link)
+				if m[1] == m[2] || strings.HasPrefix(m[2], "#") {
+					return m[1]
+				}
+				return m[1] + " (" + m[2] + ")"
+			})
+			line = markdownEmphasis.ReplaceAllString(line, "$2")
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n"), nil
+}
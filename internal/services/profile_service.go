@@ -0,0 +1,597 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidProfile wraps validation errors in profile edits.
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// parsedProfileFields are the profile fields a resume parse fills.
+var parsedProfileFields = []string{
+	models.ProfileFieldName, models.ProfileFieldEmail, models.ProfileFieldPhone,
+	models.ProfileFieldEducations, models.ProfileFieldExperiences, models.ProfileFieldSkills,
+}
+
+var profileDatePattern = regexp.MustCompile(`^\d{4}(-(0[1-9]|1[0-2]))?$`)
+
+// Profile fields a merge fills from the merged candidate keep the source
+// they had, so a field the merged candidate entered stays theirs.
+func init() {
+	registerMergeStep(mergeStep{merge: mergeFieldSources, undo: restoreFieldSources})
+}
+
+// SaveParsedProfile creates or updates the profile of a user from parsed
+// resume data, replacing its education, experience and skill records.
+// Fields the applicant edited themselves are kept.
+func SaveParsedProfile(db *gorm.DB, userID uint, filePath, resumeHash string, data *ResumeData) (*models.Profile, error) {
+	data.Summarize()
+
+	var profile models.Profile
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(models.Profile{UserID: userID}).FirstOrInit(&profile).Error; err != nil {
+			return err
+		}
+		sources, err := ProfileFieldSources(tx, profile.ID)
+		if err != nil {
+			return err
+		}
+
+		profile.ResumeFilePath = filePath
+		profile.ResumeHash = resumeHash
+		var fields []string
+		for _, field := range parsedProfileFields {
+			if sources[field] != models.FieldSourceUser {
+				fields = append(fields, field)
+			}
+		}
+		return applyProfileFields(tx, &profile, data, fields, models.FieldSourceParsed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ProfileFieldSources returns the recorded source of each profile field.
+// Fields never written have no entry.
+func ProfileFieldSources(db *gorm.DB, profileID uint) (map[string]models.FieldSource, error) {
+	sources := map[string]models.FieldSource{}
+	if profileID == 0 {
+		return sources, nil
+	}
+	var rows []models.ProfileFieldSource
+	if err := db.Where("profile_id = ?", profileID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		sources[row.Field] = row.Source
+	}
+	return sources, nil
+}
+
+// applyProfileFields copies the given fields from data to the profile,
+// saves it and records where they came from. data must be summarized.
+func applyProfileFields(tx *gorm.DB, profile *models.Profile, data *ResumeData, fields []string, source models.FieldSource) error {
+	var sections []string
+	for _, field := range fields {
+		switch field {
+		case models.ProfileFieldName:
+			profile.Name = data.Name
+		case models.ProfileFieldEmail:
+			profile.Email = data.Email
+		case models.ProfileFieldPhone:
+			profile.Phone = data.Phone
+		case models.ProfileFieldEducations:
+			profile.Education = data.Education
+			sections = append(sections, field)
+		case models.ProfileFieldExperiences:
+			profile.Experience = data.Experience
+			sections = append(sections, field)
+		case models.ProfileFieldSkills:
+			profile.Skills = data.Skills
+			sections = append(sections, field)
+		}
+	}
+	if err := tx.Save(profile).Error; err != nil {
+		return err
+	}
+	if err := replaceProfileEntries(tx, profile, data, sections); err != nil {
+		return err
+	}
+	return setProfileFieldSources(tx, profile.ID, fields, source)
+}
+
+// setProfileFieldSources records the source of profile fields.
+func setProfileFieldSources(tx *gorm.DB, profileID uint, fields []string, source models.FieldSource) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	rows := make([]models.ProfileFieldSource, len(fields))
+	for i, field := range fields {
+		rows[i] = models.ProfileFieldSource{ProfileID: profileID, Field: field, Source: source}
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "profile_id"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source", "updated_at"}),
+	}).Create(&rows).Error
+}
+
+// mergeFieldSources records the survivor's field sources, then gives fields
+// filled from the merged profile the source they had there.
+func mergeFieldSources(tx *gorm.DB, run *mergeRun) error {
+	var survivorSources []models.ProfileFieldSource
+	if run.SurvivorProfileID != 0 {
+		if err := tx.Where("profile_id = ?", run.SurvivorProfileID).Find(&survivorSources).Error; err != nil {
+			return err
+		}
+	}
+	if err := run.Snapshot.put("survivor_field_sources", survivorSources); err != nil {
+		return err
+	}
+	if run.MergedProfileID == 0 || len(run.Filled) == 0 {
+		return nil
+	}
+
+	sources, err := ProfileFieldSources(tx, run.MergedProfileID)
+	if err != nil {
+		return err
+	}
+	bySource := map[models.FieldSource][]string{}
+	for _, field := range run.Filled {
+		if source, ok := sources[field]; ok {
+			bySource[source] = append(bySource[source], field)
+		}
+	}
+	for source, fields := range bySource {
+		if err := setProfileFieldSources(tx, run.SurvivorProfileID, fields, source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreFieldSources puts the survivor's field sources back to what they
+// were.
+func restoreFieldSources(tx *gorm.DB, run *mergeRun) error {
+	var survivorSources []models.ProfileFieldSource
+	if err := run.Snapshot.get("survivor_field_sources", &survivorSources); err != nil {
+		return err
+	}
+	var profileIDs []uint
+	if err := tx.Model(&models.Profile{}).Where("user_id = ?", run.SurvivorID).Pluck("id", &profileIDs).Error; err != nil {
+		return err
+	}
+	if len(profileIDs) > 0 {
+		if err := tx.Unscoped().Where("profile_id IN ?", profileIDs).Delete(&models.ProfileFieldSource{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(survivorSources) == 0 {
+		return nil
+	}
+	return tx.Create(&survivorSources).Error
+}
+
+// allProfileSections are the sections of structured profile records.
+var allProfileSections = []string{models.ProfileFieldEducations, models.ProfileFieldExperiences, models.ProfileFieldSkills}
+
+// replaceProfileEntries swaps the structured records of the given sections
+// of a profile for the ones in data.
+func replaceProfileEntries(tx *gorm.DB, profile *models.Profile, data *ResumeData, sections []string) error {
+	for _, section := range sections {
+		switch section {
+		case models.ProfileFieldEducations:
+			if err := tx.Unscoped().Where("profile_id = ?", profile.ID).Delete(&models.EducationEntry{}).Error; err != nil {
+				return err
+			}
+			profile.Educations = nil
+			for _, e := range data.EducationEntries {
+				profile.Educations = append(profile.Educations, models.EducationEntry{
+					ProfileID:   profile.ID,
+					Institution: e.Institution,
+					Degree:      e.Degree,
+					Field:       e.Field,
+					StartDate:   e.StartDate,
+					EndDate:     e.EndDate,
+				})
+			}
+			if len(profile.Educations) > 0 {
+				if err := tx.Create(&profile.Educations).Error; err != nil {
+					return err
+				}
+			}
+
+		case models.ProfileFieldExperiences:
+			if err := tx.Unscoped().Where("profile_id = ?", profile.ID).Delete(&models.ExperienceEntry{}).Error; err != nil {
+				return err
+			}
+			profile.Experiences = nil
+			for _, e := range data.ExperienceEntries {
+				profile.Experiences = append(profile.Experiences, models.ExperienceEntry{
+					ProfileID:   profile.ID,
+					Company:     e.Company,
+					Title:       e.Title,
+					StartDate:   e.StartDate,
+					EndDate:     e.EndDate,
+					Description: e.Description,
+				})
+			}
+			if len(profile.Experiences) > 0 {
+				if err := tx.Create(&profile.Experiences).Error; err != nil {
+					return err
+				}
+			}
+
+		case models.ProfileFieldSkills:
+			if err := tx.Unscoped().Where("profile_id = ?", profile.ID).Delete(&models.ProfileSkill{}).Error; err != nil {
+				return err
+			}
+			profile.ProfileSkills = nil
+			seen := map[string]bool{}
+			for _, s := range data.SkillEntries {
+				name := strings.TrimSpace(s.Name)
+				if name == "" || seen[strings.ToLower(name)] {
+					continue
+				}
+				seen[strings.ToLower(name)] = true
+				profile.ProfileSkills = append(profile.ProfileSkills, models.ProfileSkill{
+					ProfileID: profile.ID,
+					Name:      name,
+					Level:     s.Level,
+					Years:     s.Years,
+				})
+			}
+			if len(profile.ProfileSkills) > 0 {
+				if err := tx.Create(&profile.ProfileSkills).Error; err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ProfileUpdate is an applicant's edit of their own profile. Nil fields are
+// left alone; an empty string or list clears the field. Edited fields are
+// marked as entered by the user. Reset hands parsed fields back to the
+// parser, restoring them from the primary resume.
+type ProfileUpdate struct {
+	Name         *string             `json:"name"`
+	Email        *string             `json:"email"`
+	Phone        *string             `json:"phone"`
+	GitHubURL    *string             `json:"github_url"`
+	LinkedInURL  *string             `json:"linkedin_url"`
+	PortfolioURL *string             `json:"portfolio_url"`
+	Educations   *[]EducationRecord  `json:"educations"`
+	Experiences  *[]ExperienceRecord `json:"experiences"`
+	Skills       *[]SkillRecord      `json:"skills"`
+	Reset        []string            `json:"reset"`
+}
+
+// UpdateProfile applies an applicant's edit to their profile, creating it
+// if they have not uploaded a resume yet.
+func UpdateProfile(db *gorm.DB, userID uint, update *ProfileUpdate) (*models.Profile, error) {
+	data, fields, err := update.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var profile models.Profile
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(models.Profile{UserID: userID}).FirstOrInit(&profile).Error; err != nil {
+			return err
+		}
+
+		// Links are never parsed, so they are set directly
+		var links []string
+		setLink := func(dst *string, value *string, field string) {
+			if value != nil {
+				*dst = *value
+				links = append(links, field)
+			}
+		}
+		setLink(&profile.GitHubURL, update.GitHubURL, models.ProfileFieldGitHubURL)
+		setLink(&profile.LinkedInURL, update.LinkedInURL, models.ProfileFieldLinkedInURL)
+		setLink(&profile.PortfolioURL, update.PortfolioURL, models.ProfileFieldPortfolioURL)
+		if err := applyProfileFields(tx, &profile, data, fields, models.FieldSourceUser); err != nil {
+			return err
+		}
+		if err := setProfileFieldSources(tx, profile.ID, links, models.FieldSourceUser); err != nil {
+			return err
+		}
+
+		if len(update.Reset) == 0 {
+			return nil
+		}
+		parsed, err := primaryResumeData(tx, userID)
+		if err != nil {
+			return err
+		}
+		return applyProfileFields(tx, &profile, parsed, update.Reset, models.FieldSourceParsed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// validate cleans up the edit and returns it as resume data, with the
+// parsed fields it changes.
+func (u *ProfileUpdate) validate() (*ResumeData, []string, error) {
+	data := &ResumeData{}
+	var fields []string
+	if u.Name != nil {
+		data.Name = strings.Join(strings.Fields(*u.Name), " ")
+		fields = append(fields, models.ProfileFieldName)
+	}
+	if u.Email != nil {
+		data.Email = strings.TrimSpace(*u.Email)
+		if address, err := mail.ParseAddress(data.Email); data.Email != "" && (err != nil || address.Address != data.Email) {
+			return nil, nil, fmt.Errorf("%w: email is not a valid email address", ErrInvalidProfile)
+		}
+		fields = append(fields, models.ProfileFieldEmail)
+	}
+	if u.Phone != nil {
+		data.Phone = strings.TrimSpace(*u.Phone)
+		fields = append(fields, models.ProfileFieldPhone)
+	}
+
+	links := []struct {
+		name string
+		link *string
+	}{{"github_url", u.GitHubURL}, {"linkedin_url", u.LinkedInURL}, {"portfolio_url", u.PortfolioURL}}
+	for _, l := range links {
+		if l.link == nil {
+			continue
+		}
+		*l.link = strings.TrimSpace(*l.link)
+		if err := checkProfileLink(l.name, *l.link); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if u.Educations != nil {
+		for i := range *u.Educations {
+			e := &(*u.Educations)[i]
+			if err := normalizeProfileDates(&e.StartDate, &e.EndDate); err != nil {
+				return nil, nil, err
+			}
+			if strings.TrimSpace(e.Institution) == "" && strings.TrimSpace(e.Degree) == "" {
+				return nil, nil, fmt.Errorf("%w: educations need an institution or degree", ErrInvalidProfile)
+			}
+		}
+		data.EducationEntries = *u.Educations
+		fields = append(fields, models.ProfileFieldEducations)
+	}
+	if u.Experiences != nil {
+		for i := range *u.Experiences {
+			e := &(*u.Experiences)[i]
+			if err := normalizeProfileDates(&e.StartDate, &e.EndDate); err != nil {
+				return nil, nil, err
+			}
+			if strings.TrimSpace(e.Company) == "" && strings.TrimSpace(e.Title) == "" {
+				return nil, nil, fmt.Errorf("%w: experiences need a company or title", ErrInvalidProfile)
+			}
+		}
+		data.ExperienceEntries = *u.Experiences
+		fields = append(fields, models.ProfileFieldExperiences)
+	}
+	if u.Skills != nil {
+		for _, skill := range *u.Skills {
+			if skill.Years < 0 {
+				return nil, nil, fmt.Errorf("%w: skill years cannot be negative", ErrInvalidProfile)
+			}
+		}
+		data.SkillEntries = *u.Skills
+		fields = append(fields, models.ProfileFieldSkills)
+	}
+
+	for _, field := range u.Reset {
+		if !containsString(parsedProfileFields, field) {
+			return nil, nil, fmt.Errorf("%w: only %s can be reset", ErrInvalidProfile, strings.Join(parsedProfileFields, ", "))
+		}
+		if containsString(fields, field) {
+			return nil, nil, fmt.Errorf("%w: %s cannot be both edited and reset", ErrInvalidProfile, field)
+		}
+	}
+
+	data.Summarize()
+	return data, fields, nil
+}
+
+// checkProfileLink validates a profile link. GitHub and LinkedIn links must
+// point at those sites.
+func checkProfileLink(name, link string) error {
+	if link == "" {
+		return nil
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s must be an http or https URL", ErrInvalidProfile, name)
+	}
+	host := strings.ToLower(u.Hostname())
+	site := map[string]string{"github_url": "github.com", "linkedin_url": "linkedin.com"}[name]
+	if site != "" && host != site && !strings.HasSuffix(host, "."+site) {
+		return fmt.Errorf("%w: %s must be a %s URL", ErrInvalidProfile, name, site)
+	}
+	return nil
+}
+
+// normalizeProfileDates brings entered dates to the "YYYY" or "YYYY-MM"
+// form of parsed ones.
+func normalizeProfileDates(dates ...*string) error {
+	for _, date := range dates {
+		*date = NormalizeResumeDate(*date)
+		if *date != "" && !profileDatePattern.MatchString(*date) {
+			return fmt.Errorf("%w: dates must look like 2021 or 2021-03, got %q", ErrInvalidProfile, *date)
+		}
+	}
+	return nil
+}
+
+// primaryResumeData returns the parsed data of a user's primary resume, or
+// empty data if they have none.
+func primaryResumeData(db *gorm.DB, userID uint) (*ResumeData, error) {
+	data := &ResumeData{}
+	var version models.ResumeVersion
+	err := db.Select("id", "parsed_data").Where("user_id = ? AND is_primary = ?", userID, true).Limit(1).Find(&version).Error
+	if err != nil || version.ParsedData == "" {
+		return data, err
+	}
+	if err := json.Unmarshal([]byte(version.ParsedData), data); err != nil {
+		return nil, err
+	}
+	data.Summarize()
+	return data, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateLegacyProfiles fills the structured records of profiles that only
+// have the old flat Education, Experience and Skills strings, and marks them
+// migrated. It is safe to run repeatedly: profiles that are marked or already
+// have records are skipped.
+func MigrateLegacyProfiles(db *gorm.DB) error {
+	var profiles []models.Profile
+	migrated := 0
+	err := db.Where(`legacy_migrated = ? AND (education <> '' OR experience <> '' OR skills <> '')
+		AND NOT EXISTS (SELECT 1 FROM education_entries e WHERE e.profile_id = profiles.id)
+		AND NOT EXISTS (SELECT 1 FROM experience_entries e WHERE e.profile_id = profiles.id)
+		AND NOT EXISTS (SELECT 1 FROM profile_skills s WHERE s.profile_id = profiles.id)`, false).
+		FindInBatches(&profiles, 100, func(_ *gorm.DB, _ int) error {
+			for i := range profiles {
+				profile := &profiles[i]
+				data := ParseLegacyProfile(profile.Education, profile.Experience, profile.Skills)
+				if err := db.Transaction(func(tx *gorm.DB) error {
+					if err := replaceProfileEntries(tx, profile, data, allProfileSections); err != nil {
+						return err
+					}
+					return tx.Model(&models.Profile{}).Where("id = ?", profile.ID).Update("legacy_migrated", true).Error
+				}); err != nil {
+					return err
+				}
+			}
+			migrated += len(profiles)
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d legacy profiles to structured records", migrated)
+	}
+	return nil
+}
+
+var (
+	// legacyExperiencePattern matches items written by the old API Layer
+	// integration, e.g. "Acme ([2019 2021])".
+	legacyExperiencePattern = regexp.MustCompile(`([^\[\]()]+?)\s*\(\[?([^\[\]()]*)\]?\)`)
+	legacyDatesPattern      = regexp.MustCompile(`^(.*?)\s+(?:-|–)\s+(.*)$`)
+	singleDatePattern       = regexp.MustCompile(`(?i)` + datePattern + `|present|current`)
+)
+
+// ParseLegacyProfile converts the flat strings stored by earlier versions
+// into structured records. It understands Go's "%v" slice output, e.g.
+// "[Go Docker]", and the "Title, Company (start - end); ..." summaries.
+func ParseLegacyProfile(education, experience, skills string) *ResumeData {
+	data := &ResumeData{}
+
+	for _, item := range splitLegacyList(education) {
+		head, dates := cutDates(item)
+		record := educationRecord(resumeEntry{Lines: []string{head}})
+		record.StartDate, record.EndDate = splitDates(dates)
+		data.EducationEntries = append(data.EducationEntries, record)
+	}
+
+	if matches := legacyExperiencePattern.FindAllStringSubmatch(strings.Trim(experience, "[]"), -1); strings.HasPrefix(experience, "[") && matches != nil {
+		for _, m := range matches {
+			fields := singleDatePattern.FindAllString(m[2], -1)
+			record := ExperienceRecord{Company: strings.TrimSpace(m[1])}
+			if len(fields) > 0 {
+				record.StartDate = NormalizeResumeDate(fields[0])
+			}
+			if len(fields) > 1 {
+				record.EndDate = NormalizeResumeDate(fields[len(fields)-1])
+			}
+			data.ExperienceEntries = append(data.ExperienceEntries, record)
+		}
+	} else {
+		for _, item := range splitLegacyList(experience) {
+			head, dates := cutDates(item)
+			title, company := splitTitleLine([]string{head})
+			start, end := splitDates(dates)
+			data.ExperienceEntries = append(data.ExperienceEntries, ExperienceRecord{
+				Title:     title,
+				Company:   company,
+				StartDate: start,
+				EndDate:   end,
+			})
+		}
+	}
+
+	var names []string
+	if strings.HasPrefix(skills, "[") && !strings.Contains(skills, ",") {
+		names = strings.Fields(strings.Trim(skills, "[]"))
+	} else {
+		names = strings.Split(strings.Trim(skills, "[]"), ",")
+	}
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			data.SkillEntries = append(data.SkillEntries, SkillRecord{Name: name})
+		}
+	}
+
+	return data
+}
+
+// splitLegacyList splits a flat field on ";" or newlines, stripping the
+// brackets of Go slice output.
+func splitLegacyList(value string) []string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		value = value[1 : len(value)-1]
+	}
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// cutDates splits "text (dates)" into its two parts.
+func cutDates(item string) (string, string) {
+	if open := strings.LastIndex(item, "("); open > 0 && strings.HasSuffix(item, ")") {
+		return strings.TrimSpace(item[:open]), item[open+1 : len(item)-1]
+	}
+	return item, ""
+}
+
+func splitDates(dates string) (string, string) {
+	m := legacyDatesPattern.FindStringSubmatch(dates)
+	if m == nil {
+		return NormalizeResumeDate(dates), ""
+	}
+	return NormalizeResumeDate(m[1]), NormalizeResumeDate(m[2])
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+)
+
+func TestParseLegacyProfile(t *testing.T) {
+	tests := []struct {
+		name                          string
+		education, experience, skills string
+		want                          ResumeData
+	}{
+		{
+			name:       "slice output",
+			experience: "[Acme (2019 2021)]",
+			skills:     "[Go Docker]",
+			want: ResumeData{
+				ExperienceEntries: []ExperienceRecord{{Company: "Acme", StartDate: "2019", EndDate: "2021"}},
+				SkillEntries:      []SkillRecord{{Name: "Go"}, {Name: "Docker"}},
+			},
+		},
+		{
+			name:       "slice output with an ongoing position",
+			experience: "[Acme (2019 2021) Initech (2021 present)]",
+			want: ResumeData{
+				ExperienceEntries: []ExperienceRecord{
+					{Company: "Acme", StartDate: "2019", EndDate: "2021"},
+					{Company: "Initech", StartDate: "2021"},
+				},
+			},
+		},
+		{
+			name:       "summaries",
+			education:  "B.Sc. Computer Science, MIT (2015 - 2019)",
+			experience: "Software Engineer, Acme (2019-03 - Present); Intern, Initech (2018)",
+			skills:     "Go, PostgreSQL",
+			want: ResumeData{
+				EducationEntries: []EducationRecord{{Institution: "MIT", Degree: "B.Sc.", Field: "Computer Science", StartDate: "2015", EndDate: "2019"}},
+				ExperienceEntries: []ExperienceRecord{
+					{Company: "Acme", Title: "Software Engineer", StartDate: "2019-03"},
+					{Company: "Initech", Title: "Intern", StartDate: "2018"},
+				},
+				SkillEntries: []SkillRecord{{Name: "Go"}, {Name: "PostgreSQL"}},
+			},
+		},
+		{
+			name:      "empty slices",
+			education: "[]", experience: "[]", skills: "[]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLegacyProfile(tt.education, tt.experience, tt.skills); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseLegacyProfile = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateLegacyProfiles(t *testing.T) {
+	db := testdb.Open(t, &models.Profile{}, &models.EducationEntry{}, &models.ExperienceEntry{}, &models.ProfileSkill{})
+	legacy := models.Profile{UserID: 1, Experience: "[Acme (2019 2021)]", Skills: "[Go]"}
+	empty := models.Profile{UserID: 2, Education: "[]", Experience: "[]", Skills: "[]"}
+	mustCreate(t, db, &legacy)
+	mustCreate(t, db, &empty)
+
+	for run := 1; run <= 2; run++ {
+		if err := MigrateLegacyProfiles(db); err != nil {
+			t.Fatal(err)
+		}
+		var experiences []models.ExperienceEntry
+		db.Where("profile_id = ?", legacy.ID).Find(&experiences)
+		if len(experiences) != 1 || experiences[0].Company != "Acme" {
+			t.Errorf("run %d: experiences = %+v, want Acme once", run, experiences)
+		}
+	}
+
+	var pending int64
+	db.Model(&models.Profile{}).Where("legacy_migrated = ?", false).Count(&pending)
+	if pending != 0 {
+		t.Errorf("%d profiles left to migrate, want 0", pending)
+	}
+}
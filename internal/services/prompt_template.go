@@ -0,0 +1,134 @@
+package services
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed prompts/*.tmpl
+var promptFiles embed.FS
+
+// ResumeExtractionPrompt is the template used by the LLM parser.
+const ResumeExtractionPrompt = "resume_extraction"
+
+// maxPromptResumeChars bounds the resume text sent to a model. Real resumes
+// are far shorter; the cap stops padded files from running up token costs.
+const maxPromptResumeChars = 30000
+
+// PromptTemplate is one version of a prompt, stored as
+// prompts/<name>.<version>.tmpl. The file defines a "system" and a "user"
+// template.
+type PromptTemplate struct {
+	Name     string
+	Version  string
+	template *template.Template
+}
+
+// ID identifies the template in stored parse results, e.g.
+// resume_extraction@v1.
+func (p *PromptTemplate) ID() string {
+	return p.Name + "@" + p.Version
+}
+
+// LoadPrompt returns the given version of a prompt template, or the latest
+// version when version is empty.
+func LoadPrompt(name, version string) (*PromptTemplate, error) {
+	if version == "" {
+		versions := PromptVersions(name)
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("no prompt templates named %s", name)
+		}
+		version = versions[len(versions)-1]
+	}
+
+	file := path.Join("prompts", name+"."+version+".tmpl")
+	tmpl, err := template.New(path.Base(file)).Option("missingkey=error").ParseFS(promptFiles, file)
+	if err != nil {
+		return nil, fmt.Errorf("unknown prompt %s@%s: %v", name, version, err)
+	}
+	for _, part := range []string{"system", "user"} {
+		if tmpl.Lookup(part) == nil {
+			return nil, fmt.Errorf("prompt %s@%s does not define %q", name, version, part)
+		}
+	}
+	return &PromptTemplate{Name: name, Version: version, template: tmpl}, nil
+}
+
+// PromptVersions lists the available versions of a prompt, oldest first.
+func PromptVersions(name string) []string {
+	files, _ := promptFiles.ReadDir("prompts")
+	var versions []string
+	for _, f := range files {
+		version, ok := strings.CutPrefix(f.Name(), name+".")
+		if ok && strings.HasSuffix(version, ".tmpl") {
+			versions = append(versions, strings.TrimSuffix(version, ".tmpl"))
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+	return versions
+}
+
+// versionLess orders v2 before v10.
+func versionLess(a, b string) bool {
+	na, nb := strings.TrimLeft(a, "v"), strings.TrimLeft(b, "v")
+	if len(na) != len(nb) {
+		return len(na) < len(nb)
+	}
+	return na < nb
+}
+
+// Render fills the template and returns the system and user messages.
+func (p *PromptTemplate) Render(data interface{}) (string, string, error) {
+	var system, user strings.Builder
+	if err := p.template.ExecuteTemplate(&system, "system", data); err != nil {
+		return "", "", err
+	}
+	if err := p.template.ExecuteTemplate(&user, "user", data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(system.String()), strings.TrimSpace(user.String()), nil
+}
+
+// delimiterTag matches anything that could open or close a tag the prompt
+// templates use to fence off untrusted text.
+var delimiterTag = regexp.MustCompile(`(?i)<\s*/?\s*(resume|system|user|assistant|instructions?)\b[^>]*>`)
+
+// SanitizePromptText prepares untrusted text for a prompt template. It drops
+// control and invisible formatting characters, which can hide instructions
+// from a human reviewer, and defuses tags that would close the delimiters
+// around the text. The result is capped at a fixed length.
+func SanitizePromptText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return r
+		case r == '\r':
+			return -1
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, text)
+
+	text = delimiterTag.ReplaceAllStringFunc(text, func(tag string) string {
+		return "[" + strings.Trim(tag, "<>") + "]"
+	})
+
+	if len(text) > maxPromptResumeChars {
+		cut := maxPromptResumeChars
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return strings.TrimSpace(text)
+}
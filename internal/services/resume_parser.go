@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/GolangAssignment/internal/config"
+)
+
+// ResumeData represents the structured data extracted from a resume. The
+// flat Education, Experience and Skills strings are human-readable summaries
+// of the structured records; parsers fill the records and call Summarize.
+type ResumeData struct {
+	Education  string `json:"education"`
+	Email      string `json:"email"`
+	Experience string `json:"experience"`
+	Name       string `json:"name"`
+	Phone      string `json:"phone"`
+	Skills     string `json:"skills"`
+
+	EducationEntries  []EducationRecord  `json:"education_entries,omitempty"`
+	ExperienceEntries []ExperienceRecord `json:"experience_entries,omitempty"`
+	SkillEntries      []SkillRecord      `json:"skill_entries,omitempty"`
+
+	// PromptVersion identifies the prompt template an LLM parser used.
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+type EducationRecord struct {
+	Institution string `json:"institution"`
+	Degree      string `json:"degree"`
+	Field       string `json:"field"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+}
+
+type ExperienceRecord struct {
+	Company     string `json:"company"`
+	Title       string `json:"title"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Description string `json:"description"`
+}
+
+type SkillRecord struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	Years int    `json:"years"`
+}
+
+// Summarize fills any empty flat field from the structured records.
+func (d *ResumeData) Summarize() {
+	if d.Education == "" {
+		var parts []string
+		for _, e := range d.EducationEntries {
+			parts = append(parts, joinNonEmpty(", ", joinNonEmpty(" in ", e.Degree, e.Field), e.Institution)+formatDates(e.StartDate, e.EndDate))
+		}
+		d.Education = strings.Join(parts, "; ")
+	}
+	if d.Experience == "" {
+		var parts []string
+		for _, e := range d.ExperienceEntries {
+			parts = append(parts, joinNonEmpty(", ", e.Title, e.Company)+formatDates(e.StartDate, e.EndDate))
+		}
+		d.Experience = strings.Join(parts, "; ")
+	}
+	if d.Skills == "" {
+		var names []string
+		for _, s := range d.SkillEntries {
+			names = append(names, s.Name)
+		}
+		d.Skills = strings.Join(names, ", ")
+	}
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func formatDates(start, end string) string {
+	if start == "" && end == "" {
+		return ""
+	}
+	if start == "" {
+		return " (" + end + ")"
+	}
+	if end == "" {
+		end = "Present"
+	}
+	return " (" + start + " - " + end + ")"
+}
+
+// ParseInfo describes the resume being parsed, for parsers that cache or
+// bill their work.
+type ParseInfo struct {
+	ResumeVersionID uint
+	ContentHash     string
+	Organization    string
+}
+
+type parseInfoKey struct{}
+
+// WithParseInfo attaches info to ctx for the parser.
+func WithParseInfo(ctx context.Context, info ParseInfo) context.Context {
+	return context.WithValue(ctx, parseInfoKey{}, info)
+}
+
+// ParseInfoFrom returns the ParseInfo attached to ctx, if any.
+func ParseInfoFrom(ctx context.Context) ParseInfo {
+	info, _ := ctx.Value(parseInfoKey{}).(ParseInfo)
+	return info
+}
+
+// ResumeParser turns a resume into structured ResumeData. Implementations
+// receive both the stored file and its extracted text and use whichever they
+// need.
+type ResumeParser interface {
+	Name() string
+	Parse(ctx context.Context, filePath, text string) (*ResumeData, error)
+}
+
+// NewResumeParser builds the parser chain named in cfg.ResumeParsers. A
+// single parser is returned as is; several are wrapped in a FallbackParser
+// that tries them in order. LLM results are kept in cache and LLM usage is
+// metered against the budgets; either may be nil, as in the offline tools.
+func NewResumeParser(cfg config.Config, cache *ParseCache, meter *UsageMeter) (ResumeParser, error) {
+	skills, err := LoadSkillsDictionary(cfg.SkillsDictionaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load skills dictionary: %v", err)
+	}
+	local := NewLocalParser(skills)
+
+	var parsers []ResumeParser
+	for _, name := range cfg.ResumeParsers {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "llm", "gemini":
+			client, err := NewLLMClient(cfg)
+			if err != nil {
+				return nil, err
+			}
+			prompt, err := LoadPrompt(ResumeExtractionPrompt, cfg.ResumePromptVersion)
+			if err != nil {
+				return nil, err
+			}
+			parsers = append(parsers, NewLLMParser(client, prompt, cache, meter, local))
+		case "apilayer":
+			parsers = append(parsers, NewAPILayerParser(cfg.APIKey, cfg.APILayerURL))
+		case "local":
+			parsers = append(parsers, local)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown resume parser: %s", name)
+		}
+	}
+
+	switch len(parsers) {
+	case 0:
+		return nil, errors.New("no resume parsers configured")
+	case 1:
+		return parsers[0], nil
+	}
+	return &FallbackParser{Parsers: parsers}, nil
+}
+
+// FallbackParser tries each parser in order and returns the first successful
+// result.
+type FallbackParser struct {
+	Parsers []ResumeParser
+}
+
+func (f *FallbackParser) Name() string {
+	names := make([]string, len(f.Parsers))
+	for i, p := range f.Parsers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *FallbackParser) Parse(ctx context.Context, filePath, text string) (*ResumeData, error) {
+	var errs []string
+	for _, p := range f.Parsers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := p.Parse(ctx, filePath, text)
+		if err == nil && data != nil {
+			return data, nil
+		}
+		if err == nil {
+			err = errors.New("no data returned")
+		}
+		log.Printf("Resume parser %s failed, trying next: %v", p.Name(), err)
+		errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+	}
+	return nil, fmt.Errorf("all resume parsers failed: %s", strings.Join(errs, "; "))
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Progress values reported on a ResumeJob while it is processed.
+const (
+	ProgressQueued     = "queued"
+	ProgressExtracting = "extracting"
+	ProgressParsing    = "parsing"
+	ProgressSaving     = "saving"
+	ProgressDone       = "done"
+)
+
+// staleJobTimeout is how long a job may stay in processing before it is
+// assumed abandoned by a crashed worker and picked up again.
+const staleJobTimeout = 10 * time.Minute
+
+// maxRetryBackoff caps the exponential backoff between attempts.
+const maxRetryBackoff = time.Hour
+
+// ResumePipeline processes uploaded resumes in the background. Jobs are
+// stored in the resume_jobs table so they survive restarts, and claimed by
+// workers with SELECT ... FOR UPDATE SKIP LOCKED so several replicas can
+// share the queue.
+type ResumePipeline struct {
+	DB           *gorm.DB
+	Store        BlobStore
+	Parser       ResumeParser
+	Skills       *SkillTaxonomy
+	Workers      int
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	PollInterval time.Duration
+}
+
+func init() {
+	registerMergeTable(mergeTable{name: "resume_jobs", model: &models.ResumeJob{}, column: "user_id"})
+}
+
+func NewResumePipeline(db *gorm.DB, store BlobStore, parser ResumeParser, skills *SkillTaxonomy, workers, maxAttempts int, retryBackoff time.Duration) *ResumePipeline {
+	return &ResumePipeline{
+		DB:           db,
+		Store:        store,
+		Parser:       parser,
+		Skills:       skills,
+		Workers:      workers,
+		MaxAttempts:  maxAttempts,
+		RetryBackoff: retryBackoff,
+		PollInterval: 2 * time.Second,
+	}
+}
+
+// Enqueue records a new job for an uploaded resume version. LLM calls made
+// for the job are billed to organization, which may be empty.
+func (p *ResumePipeline) Enqueue(version *models.ResumeVersion, organization string) (*models.ResumeJob, error) {
+	job := models.ResumeJob{
+		UserID:          version.UserID,
+		ResumeVersionID: version.ID,
+		FilePath:        version.FilePath,
+		Organization:    organization,
+		Status:          models.ResumeJobQueued,
+		Progress:        ProgressQueued,
+		MaxAttempts:     p.MaxAttempts,
+		NextRunAt:       time.Now(),
+	}
+	if err := p.DB.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Start launches the worker pool. Workers stop when ctx is cancelled.
+func (p *ResumePipeline) Start(ctx context.Context) {
+	for i := 0; i < p.Workers; i++ {
+		go p.work(ctx, i+1)
+	}
+	log.Printf("Resume pipeline started with %d workers", p.Workers)
+}
+
+func (p *ResumePipeline) work(ctx context.Context, id int) {
+	for {
+		job, err := p.claim()
+		if err != nil {
+			log.Printf("Resume worker %d: failed to claim job: %v", id, err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.PollInterval):
+			}
+			continue
+		}
+		p.process(ctx, job)
+	}
+}
+
+// claim locks the next runnable job and marks it as processing. Abandoned
+// jobs that have no attempts left are moved to the dead letter instead.
+func (p *ResumePipeline) claim() (*models.ResumeJob, error) {
+	var job models.ResumeJob
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		stale := now.Add(-staleJobTimeout)
+		result := tx.Model(&models.ResumeJob{}).
+			Where("status = ? AND locked_at < ? AND attempts >= max_attempts", models.ResumeJobProcessing, stale).
+			Updates(map[string]interface{}{
+				"status":     models.ResumeJobDead,
+				"last_error": "worker stopped during the last attempt",
+				"locked_at":  nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Moved %d abandoned resume jobs to dead letter", result.RowsAffected)
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND next_run_at <= ?) OR (status = ? AND locked_at < ? AND attempts < max_attempts)",
+				models.ResumeJobQueued, now, models.ResumeJobProcessing, stale).
+			Order("next_run_at").First(&job).Error
+		if err != nil {
+			return err
+		}
+		job.Status = models.ResumeJobProcessing
+		job.Attempts++
+		job.LockedAt = &now
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_at": now,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (p *ResumePipeline) process(ctx context.Context, job *models.ResumeJob) {
+	err := p.runSafely(ctx, job)
+	if err == nil {
+		now := time.Now()
+		if err := p.DB.Model(job).Updates(map[string]interface{}{
+			"status":       models.ResumeJobCompleted,
+			"progress":     ProgressDone,
+			"last_error":   "",
+			"completed_at": now,
+			"locked_at":    nil,
+		}).Error; err != nil {
+			log.Printf("Resume job %d completed but could not be marked: %v", job.ID, err)
+			return
+		}
+		log.Printf("Resume job %d completed for user %d", job.ID, job.UserID)
+		return
+	}
+
+	updates := map[string]interface{}{"last_error": err.Error(), "locked_at": nil}
+	if job.Attempts >= job.MaxAttempts {
+		updates["status"] = models.ResumeJobDead
+		log.Printf("Resume job %d moved to dead letter after %d attempts: %v", job.ID, job.Attempts, err)
+	} else {
+		updates["status"] = models.ResumeJobQueued
+		updates["next_run_at"] = time.Now().Add(p.backoff(job.Attempts))
+		log.Printf("Resume job %d attempt %d failed, will retry: %v", job.ID, job.Attempts, err)
+	}
+	if err := p.DB.Model(job).Updates(updates).Error; err != nil {
+		log.Printf("Resume job %d failed but could not be marked: %v", job.ID, err)
+	}
+}
+
+// runSafely runs a job and turns a panic, such as one from an extractor
+// given a malformed file, into a failed attempt instead of a dead worker.
+func (p *ResumePipeline) runSafely(ctx context.Context, job *models.ResumeJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Resume job %d panicked: %v\n%s", job.ID, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return p.run(ctx, job)
+}
+
+// backoff returns the delay before the next attempt: RetryBackoff doubled
+// for every failed attempt, capped at maxRetryBackoff.
+func (p *ResumePipeline) backoff(attempts int) time.Duration {
+	delay := p.RetryBackoff
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+// setProgress records how far a job got. Progress is informational, so a
+// failure is logged rather than failing the job.
+func (p *ResumePipeline) setProgress(job *models.ResumeJob, progress string) {
+	if err := p.DB.Model(job).Update("progress", progress).Error; err != nil {
+		log.Printf("Resume job %d: failed to record progress %s: %v", job.ID, progress, err)
+	}
+}
+
+// run extracts and parses one resume version, stores the results on the
+// version and, if it should be, makes it the applicant's primary resume.
+func (p *ResumePipeline) run(ctx context.Context, job *models.ResumeJob) error {
+	var version models.ResumeVersion
+	if err := p.DB.First(&version, job.ResumeVersionID).Error; err != nil {
+		return fmt.Errorf("resume version %d not found: %v", job.ResumeVersionID, err)
+	}
+
+	p.setProgress(job, ProgressExtracting)
+	filePath, cleanup, err := p.localCopy(ctx, version.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to fetch resume: %v", err)
+	}
+	defer cleanup()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	doc, err := ExtractDocument(content, version.FileName)
+	if err != nil {
+		return err
+	}
+	resumeText := doc.Text()
+	if resumeText == "" {
+		return fmt.Errorf("no text extracted from file")
+	}
+
+	p.setProgress(job, ProgressParsing)
+	ctx = WithParseInfo(ctx, ParseInfo{
+		ResumeVersionID: version.ID,
+		ContentHash:     version.ContentHash,
+		Organization:    job.Organization,
+	})
+	parsedData, err := p.Parser.Parse(ctx, filePath, resumeText)
+	if err != nil {
+		return fmt.Errorf("%s: %v", p.Parser.Name(), err)
+	}
+	if parsedData == nil {
+		return fmt.Errorf("no data extracted from resume")
+	}
+	parsedData.Summarize()
+	p.Skills.NormalizeResume(parsedData)
+
+	p.setProgress(job, ProgressSaving)
+	parsedJSON, err := json.Marshal(parsedData)
+	if err != nil {
+		return err
+	}
+	docJSON, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	err = p.DB.Model(&version).Updates(map[string]interface{}{
+		"extracted_text": resumeText,
+		"document":       string(docJSON),
+		"parsed_data":    string(parsedJSON),
+		"parser_name":    p.Parser.Name(),
+		"prompt_version": parsedData.PromptVersion,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	promote, err := shouldPromote(p.DB, &version)
+	if err != nil || !promote {
+		return err
+	}
+	return SetPrimaryResume(p.DB, &version, parsedData)
+}
+
+// shouldPromote reports whether a freshly parsed version becomes the primary
+// resume: when the applicant has none yet, or when it is the newest resume
+// they uploaded themselves and they have not picked a newer one. Referred
+// resumes never replace the applicant's choice.
+func shouldPromote(db *gorm.DB, version *models.ResumeVersion) (bool, error) {
+	var primary models.ResumeVersion
+	if err := db.Select("id").Where("user_id = ? AND is_primary = ?", version.UserID, true).Limit(1).Find(&primary).Error; err != nil {
+		return false, err
+	}
+	if primary.ID == 0 || primary.ID == version.ID {
+		return true, nil
+	}
+	if version.Referred || primary.ID > version.ID {
+		return false, nil
+	}
+
+	var newer int64
+	err := db.Model(&models.ResumeVersion{}).
+		Where("user_id = ? AND id > ? AND referred = ?", version.UserID, version.ID, false).
+		Count(&newer).Error
+	return newer == 0, err
+}
+
+// localCopy makes a stored resume available as a local file for the
+// extractors. Paths written before blob storage are used in place until they
+// are migrated.
+func (p *ResumePipeline) localCopy(ctx context.Context, key string) (string, func(), error) {
+	path, cleanup, err := DownloadToTemp(ctx, p.Store, key)
+	if errors.Is(err, ErrBlobNotFound) {
+		if _, statErr := os.Stat(key); statErr == nil {
+			return key, func() {}, nil
+		}
+	}
+	return path, cleanup, err
+}
+
+// SetPrimaryResume marks a parsed version as the applicant's primary resume
+// and refreshes the profile from its parsed data.
+func SetPrimaryResume(db *gorm.DB, version *models.ResumeVersion, data *ResumeData) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ResumeVersion{}).
+			Where("user_id = ? AND id <> ?", version.UserID, version.ID).
+			Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(version).Update("is_primary", true).Error; err != nil {
+			return err
+		}
+		_, err := SaveParsedProfile(tx, version.UserID, version.FilePath, version.ContentHash, data)
+		return err
+	})
+}
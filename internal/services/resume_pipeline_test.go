@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+)
+
+func TestResumePipelineBackoff(t *testing.T) {
+	p := &ResumePipeline{RetryBackoff: time.Minute}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{7, maxRetryBackoff},
+		{100, maxRetryBackoff},
+	}
+	for _, tt := range tests {
+		if got := p.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// newPipelineFixture returns a pipeline whose jobs fail, since they point at
+// a resume version that does not exist.
+func newPipelineFixture(t *testing.T) *ResumePipeline {
+	t.Helper()
+	db := testdb.Open(t, &models.ResumeJob{}, &models.ResumeVersion{})
+	return NewResumePipeline(db, nil, nil, nil, 1, 3, time.Minute)
+}
+
+func TestResumePipelineRetriesThenDeadLetters(t *testing.T) {
+	p := newPipelineFixture(t)
+	job, err := p.Enqueue(&models.ResumeVersion{UserID: 1, FilePath: "missing.pdf"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		// Make the job runnable again without waiting out the backoff
+		p.DB.Model(job).Update("next_run_at", time.Now().Add(-time.Second))
+		claimed, err := p.claim()
+		if err != nil || claimed == nil {
+			t.Fatalf("attempt %d: claim = %v, %v", attempt, claimed, err)
+		}
+		started := time.Now()
+		p.process(context.Background(), claimed)
+
+		var got models.ResumeJob
+		p.DB.First(&got, job.ID)
+		if got.Attempts != attempt || got.LastError == "" || got.LockedAt != nil {
+			t.Fatalf("attempt %d: job = %d attempts, error %q, locked %v", attempt, got.Attempts, got.LastError, got.LockedAt)
+		}
+		if attempt < 3 {
+			wait := p.backoff(attempt)
+			if got.Status != models.ResumeJobQueued || got.NextRunAt.Before(started.Add(wait-time.Second)) {
+				t.Errorf("attempt %d: job %s next run at %v, want queued after %v", attempt, got.Status, got.NextRunAt, wait)
+			}
+		} else if got.Status != models.ResumeJobDead {
+			t.Errorf("after the last attempt job is %s, want dead", got.Status)
+		}
+	}
+
+	if claimed, err := p.claim(); err != nil || claimed != nil {
+		t.Errorf("claim after dead letter = %v, %v, want nothing", claimed, err)
+	}
+}
+
+func TestResumePipelineStaleJobs(t *testing.T) {
+	p := newPipelineFixture(t)
+	abandoned := time.Now().Add(-2 * staleJobTimeout)
+	retry := models.ResumeJob{UserID: 1, FilePath: "a.pdf", Status: models.ResumeJobProcessing, Attempts: 1, MaxAttempts: 3,
+		NextRunAt: abandoned, LockedAt: &abandoned}
+	exhausted := models.ResumeJob{UserID: 1, FilePath: "b.pdf", Status: models.ResumeJobProcessing, Attempts: 3, MaxAttempts: 3,
+		NextRunAt: abandoned, LockedAt: &abandoned}
+	mustCreate(t, p.DB, &retry)
+	mustCreate(t, p.DB, &exhausted)
+
+	claimed, err := p.claim()
+	if err != nil || claimed == nil || claimed.ID != retry.ID || claimed.Attempts != 2 {
+		t.Fatalf("claim = %+v, %v, want job %d on attempt 2", claimed, err, retry.ID)
+	}
+	if claimed, err := p.claim(); err != nil || claimed != nil {
+		t.Errorf("second claim = %+v, %v, want nothing", claimed, err)
+	}
+
+	var got models.ResumeJob
+	p.DB.First(&got, exhausted.ID)
+	if got.Status != models.ResumeJobDead || got.Attempts != 3 || got.LockedAt != nil {
+		t.Errorf("exhausted job = %s after %d attempts, locked %v, want dead after 3", got.Status, got.Attempts, got.LockedAt)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+)
+
+// ResumeKeyPrefix is the BlobStore prefix under which resumes are stored.
+const ResumeKeyPrefix = "resumes"
+
+// A merged candidate's resume versions move to the survivor, who keeps their
+// own primary resume if they have one.
+func init() {
+	registerMergeStep(mergeStep{merge: demotePrimaryResume, undo: promotePrimaryResume})
+	registerMergeTable(mergeTable{name: "resume_versions", model: &models.ResumeVersion{}, column: "user_id"})
+}
+
+// StoreResume validates an uploaded resume, writes it to the blob store under
+// its content key and records it as a new version for the user. Identical
+// files share one blob, but every upload gets its own version. Referred marks
+// uploads made on the user's behalf. Rejected uploads are reported as
+// *UploadError.
+func StoreResume(ctx context.Context, store BlobStore, validator *UploadValidator, db *gorm.DB, userID uint, referred bool, header *multipart.FileHeader) (*models.ResumeVersion, error) {
+	if validator.MaxBytes > 0 && header.Size > validator.MaxBytes {
+		return nil, rejectUpload("File exceeds the maximum size of %d MB", validator.MaxBytes/(1<<20))
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := validator.Validate(ctx, header.Filename, content)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	contentType := upload.ContentType
+
+	key := ContentKey(ResumeKeyPrefix, hash, upload.FileName)
+	exists, err := store.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check resume blob: %v", err)
+	}
+	if !exists {
+		if err := store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), contentType); err != nil {
+			return nil, fmt.Errorf("failed to store resume: %v", err)
+		}
+	}
+
+	return CreateResumeVersion(db, userID, referred, upload.FileName, key, contentType, int64(len(content)), hash)
+}
+
+// CreateResumeVersion records a stored resume blob as a new version for the
+// user.
+func CreateResumeVersion(db *gorm.DB, userID uint, referred bool, fileName, key, contentType string, size int64, hash string) (*models.ResumeVersion, error) {
+	version := models.ResumeVersion{
+		UserID:      userID,
+		Referred:    referred,
+		FileName:    fileName,
+		FilePath:    key,
+		ContentType: contentType,
+		Size:        size,
+		ContentHash: hash,
+	}
+	if err := db.Create(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// PrimaryResumeVersionID returns the ID of the user's primary resume
+// version, or nil if the user has none.
+func PrimaryResumeVersionID(db *gorm.DB, userID uint) *uint {
+	var version models.ResumeVersion
+	if err := db.Select("id").Where("user_id = ? AND is_primary = ?", userID, true).First(&version).Error; err != nil {
+		return nil
+	}
+	return &version.ID
+}
+
+// demotePrimaryResume keeps the survivor's primary resume, if it has one, by
+// demoting the merged candidate's.
+func demotePrimaryResume(tx *gorm.DB, run *mergeRun) error {
+	var survivorPrimary int64
+	if err := tx.Model(&models.ResumeVersion{}).Where("user_id = ? AND is_primary = ?", run.SurvivorID, true).Count(&survivorPrimary).Error; err != nil {
+		return err
+	}
+	if survivorPrimary == 0 {
+		return nil
+	}
+	var demoted []uint
+	if err := tx.Model(&models.ResumeVersion{}).Where("user_id = ? AND is_primary = ?", run.MergedID, true).Pluck("id", &demoted).Error; err != nil {
+		return err
+	}
+	if len(demoted) > 0 {
+		if err := tx.Model(&models.ResumeVersion{}).Where("id IN ?", demoted).Update("is_primary", false).Error; err != nil {
+			return err
+		}
+	}
+	return run.Snapshot.put("demoted_versions", demoted)
+}
+
+func promotePrimaryResume(tx *gorm.DB, run *mergeRun) error {
+	var demoted []uint
+	if err := run.Snapshot.get("demoted_versions", &demoted); err != nil {
+		return err
+	}
+	if len(demoted) == 0 {
+		return nil
+	}
+	return tx.Model(&models.ResumeVersion{}).Where("id IN ?", demoted).Update("is_primary", true).Error
+}
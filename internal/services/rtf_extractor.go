@@ -0,0 +1,184 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// rtfSkippedDestinations are groups that hold metadata or embedded objects
+// rather than document text.
+var rtfSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"pict": true, "object": true, "header": true, "headerl": true, "headerr": true,
+	"headerf": true, "footer": true, "footerl": true, "footerr": true, "footerf": true,
+	"listtable": true, "listoverridetable": true, "rsidtbl": true, "generator": true,
+	"themedata": true, "colorschememapping": true, "datastore": true, "latentstyles": true,
+	"xmlnstbl": true, "fldinst": true, "filetbl": true, "revtbl": true, "pgdsctbl": true,
+	"mmathPr": true, "bkmkstart": true, "bkmkend": true, "footnote": true, "annotation": true,
+}
+
+// rtfState is the formatting state saved and restored with each group.
+type rtfState struct {
+	skip bool
+	// uc is the number of fallback characters that follow a \u escape.
+	uc int
+}
+
+// extractTextFromRTF extracts the text of an RTF document. Only the
+// features that carry text are interpreted: escapes, unicode characters,
+// paragraph and table breaks and the destinations to skip.
+func extractTextFromRTF(content []byte) (string, error) {
+	data := string(content)
+	decoder := charmap.Windows1252.NewDecoder()
+
+	var buf strings.Builder
+	state := rtfState{uc: 1}
+	var stack []rtfState
+	// pendingSkip counts fallback characters still to drop after \u.
+	pendingSkip := 0
+
+	emit := func(s string) {
+		if !state.skip {
+			buf.WriteString(s)
+		}
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch c {
+		case '{':
+			stack = append(stack, state)
+			i++
+			// {\*\dest ...} marks a destination unknown readers must skip
+			if strings.HasPrefix(data[i:], "\\*") {
+				state.skip = true
+			}
+			continue
+		case '}':
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			pendingSkip = 0
+			i++
+			continue
+		case '\r', '\n':
+			i++
+			continue
+		case '\\':
+		default:
+			if pendingSkip > 0 {
+				pendingSkip--
+			} else {
+				emit(string(c))
+			}
+			i++
+			continue
+		}
+
+		// Control symbol or control word
+		i++
+		if i >= len(data) {
+			break
+		}
+		c = data[i]
+		if !isASCIILetter(c) {
+			i++
+			switch c {
+			case '\\', '{', '}':
+				emitOrSkip(&pendingSkip, emit, string(c))
+			case '~':
+				emitOrSkip(&pendingSkip, emit, " ")
+			case '_':
+				emitOrSkip(&pendingSkip, emit, "-")
+			case '\n', '\r':
+				emit("\n")
+			case '\'':
+				if i+2 <= len(data) {
+					if b, err := strconv.ParseUint(data[i:i+2], 16, 8); err == nil {
+						decoded, _ := decoder.Bytes([]byte{byte(b)})
+						emitOrSkip(&pendingSkip, emit, string(decoded))
+					}
+					i += 2
+				}
+			}
+			continue
+		}
+
+		start := i
+		for i < len(data) && isASCIILetter(data[i]) {
+			i++
+		}
+		word := data[start:i]
+		paramStart := i
+		if i < len(data) && data[i] == '-' {
+			i++
+		}
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+		param, hasParam := 0, i > paramStart
+		if hasParam {
+			param, _ = strconv.Atoi(data[paramStart:i])
+		}
+		// A single space delimits the control word and is not text
+		if i < len(data) && data[i] == ' ' {
+			i++
+		}
+
+		switch word {
+		case "par", "line", "row", "sect", "page":
+			emit("\n")
+		case "tab", "cell":
+			emit("\t")
+		case "emdash":
+			emit("—")
+		case "endash":
+			emit("–")
+		case "bullet":
+			emit("•")
+		case "lquote", "rquote":
+			emit("'")
+		case "ldblquote", "rdblquote":
+			emit("\"")
+		case "uc":
+			if hasParam {
+				state.uc = param
+			}
+		case "u":
+			if hasParam {
+				if param < 0 {
+					param += 65536
+				}
+				emit(string(rune(param)))
+				pendingSkip = state.uc
+			}
+		case "bin":
+			// Skip binary data
+			if hasParam && param > 0 {
+				i += param
+			}
+		default:
+			if rtfSkippedDestinations[word] {
+				state.skip = true
+			}
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// emitOrSkip writes s unless it is the fallback of a preceding \u escape.
+func emitOrSkip(pendingSkip *int, emit func(string), s string) {
+	if *pendingSkip > 0 {
+		*pendingSkip--
+		return
+	}
+	emit(s)
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
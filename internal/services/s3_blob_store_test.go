@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// s3Stub is an in-memory stand-in for an S3-compatible server with
+// path-style addressing. It checks that requests are signed for the
+// expected region and that the payload hash matches the body.
+type s3Stub struct {
+	t       *testing.T
+	region  string
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newS3Stub(t *testing.T, region string) (*s3Stub, *httptest.Server) {
+	stub := &s3Stub{t: t, region: region, objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=key/") || !strings.Contains(auth, "/"+s.region+"/s3/aws4_request") {
+		s.t.Errorf("%s %s: unexpected Authorization %q", r.Method, r.URL.Path, auth)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(sum[:]) {
+		s.t.Errorf("%s %s: payload hash %q does not match body", r.Method, r.URL.Path, got)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[path] = body
+		s.types[path] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		content, ok := s.objects[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Header().Set("Content-Type", s.types[path])
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(content)
+		}
+	case http.MethodDelete:
+		delete(s.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3BlobStore(t *testing.T) {
+	stub, server := newS3Stub(t, "eu-west-1")
+	store := NewS3BlobStore(S3Options{
+		Endpoint:  server.URL,
+		Region:    "eu-west-1",
+		Bucket:    "resumes",
+		AccessKey: "key",
+		SecretKey: "secret",
+		PathStyle: true,
+	})
+	ctx := context.Background()
+	key := "resumes/ab/abcdef/Jane Doe (CV).pdf"
+
+	if err := store.Put(ctx, key, strings.NewReader("%PDF-1.4"), 8, "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, ok := stub.objects["/resumes/"+key]; !ok {
+		t.Fatalf("object not stored under the bucket path, have %v", stub.objects)
+	}
+
+	exists, err := store.Exists(ctx, key)
+	if err != nil || !exists {
+		t.Fatalf("Exists = %v, %v; want true", exists, err)
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	content, _ := io.ReadAll(r)
+	r.Close()
+	if string(content) != "%PDF-1.4" {
+		t.Errorf("Get = %q, want %q", content, "%PDF-1.4")
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrBlobNotFound", err)
+	}
+	if exists, err := store.Exists(ctx, key); err != nil || exists {
+		t.Errorf("Exists after Delete = %v, %v; want false", exists, err)
+	}
+	// Deleting a missing object is not an error
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of missing object: %v", err)
+	}
+}
+
+func TestS3BlobStoreServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+	}))
+	defer server.Close()
+
+	store := NewS3BlobStore(S3Options{Endpoint: server.URL, Bucket: "resumes", PathStyle: true})
+	err := store.Put(context.Background(), "a.pdf", strings.NewReader("x"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put = %v, want an AccessDenied error", err)
+	}
+}
+
+func TestNewS3BlobStoreDefaults(t *testing.T) {
+	store := NewS3BlobStore(S3Options{Bucket: "resumes"})
+	if store.Options.Region != "us-east-1" {
+		t.Errorf("Region = %q, want us-east-1", store.Options.Region)
+	}
+	if store.Options.Endpoint != "https://s3.us-east-1.amazonaws.com" {
+		t.Errorf("Endpoint = %q, want https://s3.us-east-1.amazonaws.com", store.Options.Endpoint)
+	}
+}
@@ -0,0 +1,675 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GolangAssignment/internal/models"
+	"gorm.io/gorm"
+)
+
+//go:embed data/skills_taxonomy.csv
+var defaultSkillTaxonomy []byte
+
+// ErrInvalidSkill is returned for skill input that cannot be applied, such
+// as an unknown parent or a parent that would create a cycle.
+var ErrInvalidSkill = errors.New("invalid skill")
+
+// SkillConflictError reports a skill name or synonym that already belongs
+// to another skill.
+type SkillConflictError struct {
+	Name  string
+	Skill string
+}
+
+func (e *SkillConflictError) Error() string {
+	return fmt.Sprintf("%q is already used by skill %s", e.Name, e.Skill)
+}
+
+// SkillSeed describes one skill in an import file. In CSV files synonyms
+// are separated by "|".
+type SkillSeed struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Parent   string   `json:"parent"`
+	Synonyms []string `json:"synonyms"`
+}
+
+// SkillUpdate changes the fields of a skill that are set. An empty Parent
+// removes the parent; Synonyms replaces all synonyms.
+type SkillUpdate struct {
+	Name     *string   `json:"name"`
+	Category *string   `json:"category"`
+	Parent   *string   `json:"parent"`
+	Synonyms *[]string `json:"synonyms"`
+}
+
+// SkillImportResult counts the changes made by an import. Skipped lists
+// entries that conflicted with the taxonomy.
+type SkillImportResult struct {
+	Created  int      `json:"created"`
+	Updated  int      `json:"updated"`
+	Synonyms int      `json:"synonyms"`
+	Skipped  []string `json:"skipped"`
+}
+
+// SkillTaxonomy normalizes skill names to the canonical names of the
+// managed taxonomy. Lookups use an in-memory copy that is reloaded after
+// every change, and by Start when another instance changed the taxonomy.
+// All lookups work on a nil taxonomy, which only tidies spacing.
+type SkillTaxonomy struct {
+	DB *gorm.DB
+
+	mu sync.RWMutex
+	// canonical maps the key of every name and synonym to a canonical name.
+	canonical map[string]string
+	// variants maps a canonical key to the keys of its name and synonyms.
+	variants map[string][]string
+	// children maps a canonical key to the canonical keys of its children.
+	children map[string][]string
+	// version identifies the stored taxonomy the copy was loaded from.
+	version string
+}
+
+func NewSkillTaxonomy(db *gorm.DB) *SkillTaxonomy {
+	return &SkillTaxonomy{DB: db}
+}
+
+// Seed imports the taxonomy file at path, or the built-in one when path is
+// empty, if the taxonomy is still empty. It then loads the taxonomy.
+func (t *SkillTaxonomy) Seed(path string) error {
+	var count int64
+	if err := t.DB.Model(&models.Skill{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		name, content := "skills_taxonomy.csv", defaultSkillTaxonomy
+		if path != "" {
+			var err error
+			if content, err = os.ReadFile(path); err != nil {
+				return err
+			}
+			name = path
+		}
+		seeds, err := ParseSkillSeeds(name, content)
+		if err != nil {
+			return err
+		}
+		result, err := t.Import(seeds)
+		if err != nil {
+			return err
+		}
+		log.Printf("Seeded skills taxonomy with %d skills and %d synonyms", result.Created, result.Synonyms)
+	}
+	return t.Load()
+}
+
+// Start reloads the taxonomy every interval when its stored version
+// changed, to pick up edits made through other instances.
+func (t *SkillTaxonomy) Start(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(interval)
+			if reloaded, err := t.Refresh(); err != nil {
+				log.Printf("Failed to refresh skills taxonomy: %v", err)
+			} else if reloaded {
+				log.Printf("Reloaded skills taxonomy changed by another instance")
+			}
+		}
+	}()
+}
+
+// Refresh reloads the taxonomy if it changed since it was loaded, and
+// reports whether it did.
+func (t *SkillTaxonomy) Refresh() (bool, error) {
+	version, err := t.storedVersion()
+	if err != nil {
+		return false, err
+	}
+	t.mu.RLock()
+	current := t.version == version
+	t.mu.RUnlock()
+	if current {
+		return false, nil
+	}
+	return true, t.Load()
+}
+
+// Load refreshes the in-memory copy of the taxonomy.
+func (t *SkillTaxonomy) Load() error {
+	// Read the version first, so a change made while loading is picked up
+	// by the next refresh
+	version, err := t.storedVersion()
+	if err != nil {
+		return err
+	}
+	var skills []models.Skill
+	if err := t.DB.Preload("Synonyms").Find(&skills).Error; err != nil {
+		return err
+	}
+	t.index(skills)
+	t.mu.Lock()
+	t.version = version
+	t.mu.Unlock()
+	return nil
+}
+
+// storedVersion summarizes the stored taxonomy by the number of skills and
+// synonyms and when they last changed. Skills and synonyms are deleted for
+// good, which changes the count, and every other change updates a row.
+func (t *SkillTaxonomy) storedVersion() (string, error) {
+	var parts []string
+	for _, model := range []interface{}{&models.Skill{}, &models.SkillSynonym{}} {
+		var row struct {
+			Count   int64
+			Updated string
+		}
+		if err := t.DB.Unscoped().Model(model).
+			Select("COUNT(*) AS count, COALESCE(CAST(MAX(updated_at) AS VARCHAR(64)), '') AS updated").
+			Scan(&row).Error; err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("%d@%s", row.Count, row.Updated))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// index builds the lookup maps from the skills and their synonyms.
+func (t *SkillTaxonomy) index(skills []models.Skill) {
+	canonical := map[string]string{}
+	variants := map[string][]string{}
+	children := map[string][]string{}
+	keyByID := map[uint]string{}
+	for _, skill := range skills {
+		key := NormalizeSkillName(skill.Name)
+		keyByID[skill.ID] = key
+		canonical[key] = skill.Name
+		variants[key] = append(variants[key], key)
+		for _, synonym := range skill.Synonyms {
+			canonical[synonym.Name] = skill.Name
+			variants[key] = append(variants[key], synonym.Name)
+		}
+	}
+	for _, skill := range skills {
+		if skill.ParentID != nil {
+			if parent, ok := keyByID[*skill.ParentID]; ok {
+				children[parent] = append(children[parent], keyByID[skill.ID])
+			}
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.canonical, t.variants, t.children = canonical, variants, children
+}
+
+// Canonical returns the canonical name of a skill, or the name with tidied
+// spacing when the taxonomy does not know it.
+func (t *SkillTaxonomy) Canonical(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if t == nil {
+		return name
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if canonical, ok := t.canonical[NormalizeSkillName(name)]; ok {
+		return canonical
+	}
+	return name
+}
+
+// Key returns the comparison key of a skill: the normalized canonical
+// name, so "JS" and "javascript" have the same key.
+func (t *SkillTaxonomy) Key(name string) string {
+	return NormalizeSkillName(t.Canonical(name))
+}
+
+// Normalize maps names to canonical names, dropping blanks and duplicates.
+func (t *SkillTaxonomy) Normalize(names []string) []string {
+	seen := map[string]bool{}
+	var normalized []string
+	for _, name := range names {
+		name = t.Canonical(name)
+		if key := NormalizeSkillName(name); key != "" && !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+// Variants returns the keys of every name a skill is known by, to match
+// stored names with LOWER(name) IN (...). With descendants it also covers
+// the more specific skills under it, so JavaScript finds React developers.
+func (t *SkillTaxonomy) Variants(name string, descendants bool) []string {
+	key := t.Key(name)
+	if t == nil {
+		return []string{key}
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.variants[key] == nil {
+		return []string{key}
+	}
+
+	var keys []string
+	seen := map[string]bool{}
+	queue := []string{key}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+		keys = append(keys, t.variants[current]...)
+		if descendants {
+			queue = append(queue, t.children[current]...)
+		}
+	}
+	return keys
+}
+
+// NormalizeResume replaces the parsed skill names of a resume with their
+// canonical names, merging entries that turn out to be the same skill.
+func (t *SkillTaxonomy) NormalizeResume(data *ResumeData) {
+	if len(data.SkillEntries) == 0 {
+		if data.Skills != "" {
+			data.Skills = strings.Join(t.Normalize(strings.Split(data.Skills, ",")), ", ")
+		}
+		return
+	}
+
+	var entries []SkillRecord
+	index := map[string]int{}
+	for _, entry := range data.SkillEntries {
+		entry.Name = t.Canonical(entry.Name)
+		key := NormalizeSkillName(entry.Name)
+		if key == "" {
+			continue
+		}
+		if i, ok := index[key]; ok {
+			if entry.Years > entries[i].Years {
+				entries[i].Years = entry.Years
+			}
+			if entries[i].Level == "" {
+				entries[i].Level = entry.Level
+			}
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, entry)
+	}
+	data.SkillEntries = entries
+	data.Skills = ""
+	data.Summarize()
+}
+
+// CreateSkill adds a skill to the taxonomy.
+func (t *SkillTaxonomy) CreateSkill(seed SkillSeed) (*models.Skill, error) {
+	var skill models.Skill
+	err := t.DB.Transaction(func(tx *gorm.DB) error {
+		skill = models.Skill{Name: strings.Join(strings.Fields(seed.Name), " "), Category: strings.TrimSpace(seed.Category)}
+		if skill.Name == "" {
+			return fmt.Errorf("%w: name is required", ErrInvalidSkill)
+		}
+		if err := checkSkillName(tx, skill.Name, 0); err != nil {
+			return err
+		}
+		if seed.Parent != "" {
+			parent, err := findSkill(tx, seed.Parent)
+			if err != nil {
+				return err
+			}
+			skill.ParentID = &parent.ID
+		}
+		if err := tx.Create(&skill).Error; err != nil {
+			return err
+		}
+		_, err := addSynonyms(tx, &skill, seed.Synonyms, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t.reload(skill.ID)
+}
+
+// UpdateSkill changes a skill. Renaming it keeps the old name as a synonym
+// unless the synonyms are replaced too.
+func (t *SkillTaxonomy) UpdateSkill(id uint, update SkillUpdate) (*models.Skill, error) {
+	err := t.DB.Transaction(func(tx *gorm.DB) error {
+		var skill models.Skill
+		if err := tx.First(&skill, id).Error; err != nil {
+			return err
+		}
+		oldName := skill.Name
+
+		if update.Name != nil {
+			skill.Name = strings.Join(strings.Fields(*update.Name), " ")
+			if skill.Name == "" {
+				return fmt.Errorf("%w: name is required", ErrInvalidSkill)
+			}
+			if err := checkSkillName(tx, skill.Name, skill.ID); err != nil {
+				return err
+			}
+			// The new name may have been one of the skill's synonyms
+			if err := tx.Unscoped().Where("skill_id = ? AND name = ?", skill.ID, NormalizeSkillName(skill.Name)).
+				Delete(&models.SkillSynonym{}).Error; err != nil {
+				return err
+			}
+		}
+		if update.Category != nil {
+			skill.Category = strings.TrimSpace(*update.Category)
+		}
+		if update.Parent != nil {
+			skill.ParentID = nil
+			if *update.Parent != "" {
+				parent, err := findSkill(tx, *update.Parent)
+				if err != nil {
+					return err
+				}
+				if err := checkSkillParent(tx, skill.ID, parent.ID); err != nil {
+					return err
+				}
+				skill.ParentID = &parent.ID
+			}
+		}
+		if err := tx.Select("Name", "Category", "ParentID").Save(&skill).Error; err != nil {
+			return err
+		}
+
+		synonyms := []string{}
+		if update.Synonyms != nil {
+			if err := tx.Unscoped().Where("skill_id = ?", skill.ID).Delete(&models.SkillSynonym{}).Error; err != nil {
+				return err
+			}
+			synonyms = *update.Synonyms
+		} else if NormalizeSkillName(oldName) != NormalizeSkillName(skill.Name) {
+			synonyms = []string{oldName}
+		}
+		_, err := addSynonyms(tx, &skill, synonyms, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t.reload(id)
+}
+
+// DeleteSkill removes a skill and its synonyms. Its children move up to
+// its parent.
+func (t *SkillTaxonomy) DeleteSkill(id uint) error {
+	err := t.DB.Transaction(func(tx *gorm.DB) error {
+		var skill models.Skill
+		if err := tx.First(&skill, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Skill{}).Where("parent_id = ?", skill.ID).
+			Update("parent_id", skill.ParentID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("skill_id = ?", skill.ID).Delete(&models.SkillSynonym{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&skill).Error
+	})
+	if err != nil {
+		return err
+	}
+	return t.Load()
+}
+
+// Import adds the seeds to the taxonomy. Existing skills, matched by
+// canonical name, get the seed's category and any new synonyms. Entries
+// that conflict with the taxonomy are skipped and reported.
+func (t *SkillTaxonomy) Import(seeds []SkillSeed) (*SkillImportResult, error) {
+	result := &SkillImportResult{Skipped: []string{}}
+	err := t.DB.Transaction(func(tx *gorm.DB) error {
+		imported := map[string]*models.Skill{}
+		for _, seed := range seeds {
+			name := strings.Join(strings.Fields(seed.Name), " ")
+			if name == "" {
+				continue
+			}
+
+			var skill models.Skill
+			err := tx.Where("LOWER(name) = ?", NormalizeSkillName(name)).First(&skill).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := checkSkillName(tx, name, 0); err != nil {
+					var conflict *SkillConflictError
+					if errors.As(err, &conflict) {
+						result.Skipped = append(result.Skipped, conflict.Error())
+						continue
+					}
+					return err
+				}
+				skill = models.Skill{Name: name, Category: strings.TrimSpace(seed.Category)}
+				if err := tx.Create(&skill).Error; err != nil {
+					return err
+				}
+				result.Created++
+			case err != nil:
+				return err
+			default:
+				if category := strings.TrimSpace(seed.Category); category != "" && category != skill.Category {
+					if err := tx.Model(&skill).Update("category", category).Error; err != nil {
+						return err
+					}
+					result.Updated++
+				}
+			}
+
+			added, err := addSynonyms(tx, &skill, seed.Synonyms, &result.Skipped)
+			if err != nil {
+				return err
+			}
+			result.Synonyms += added
+			imported[NormalizeSkillName(name)] = &skill
+		}
+
+		// Parents may appear later in the file, so they are linked last
+		for _, seed := range seeds {
+			skill := imported[NormalizeSkillName(seed.Name)]
+			if skill == nil || strings.TrimSpace(seed.Parent) == "" {
+				continue
+			}
+			parent, err := findSkill(tx, seed.Parent)
+			if err == nil {
+				err = checkSkillParent(tx, skill.ID, parent.ID)
+			}
+			if errors.Is(err, ErrInvalidSkill) {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("parent of %s: %v", skill.Name, err))
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err := tx.Model(skill).Update("parent_id", parent.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, t.Load()
+}
+
+// reload refreshes the taxonomy and returns a skill with its relations.
+func (t *SkillTaxonomy) reload(id uint) (*models.Skill, error) {
+	if err := t.Load(); err != nil {
+		return nil, err
+	}
+	var skill models.Skill
+	if err := t.DB.Preload("Synonyms").Preload("Parent").Preload("Children").First(&skill, id).Error; err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+// checkSkillName returns a SkillConflictError if name is the name or a
+// synonym of a skill other than exceptID.
+func checkSkillName(tx *gorm.DB, name string, exceptID uint) error {
+	key := NormalizeSkillName(name)
+	var skill models.Skill
+	err := tx.Where("LOWER(name) = ? AND id <> ?", key, exceptID).First(&skill).Error
+	if err == nil {
+		return &SkillConflictError{Name: name, Skill: skill.Name}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	var synonym models.SkillSynonym
+	err = tx.Where("name = ? AND skill_id <> ?", key, exceptID).First(&synonym).Error
+	if err == nil {
+		tx.First(&skill, synonym.SkillID)
+		return &SkillConflictError{Name: name, Skill: skill.Name}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// addSynonyms adds the synonyms a skill does not have yet and returns how
+// many were added. Conflicting synonyms fail the call, or are recorded in
+// skipped when it is not nil.
+func addSynonyms(tx *gorm.DB, skill *models.Skill, names []string, skipped *[]string) (int, error) {
+	added := 0
+	for _, name := range names {
+		key := NormalizeSkillName(name)
+		if key == "" || key == NormalizeSkillName(skill.Name) {
+			continue
+		}
+		if err := checkSkillName(tx, key, skill.ID); err != nil {
+			var conflict *SkillConflictError
+			if skipped != nil && errors.As(err, &conflict) {
+				*skipped = append(*skipped, fmt.Sprintf("synonym of %s: %v", skill.Name, err))
+				continue
+			}
+			return added, err
+		}
+		synonym := models.SkillSynonym{SkillID: skill.ID, Name: key}
+		result := tx.Where(synonym).FirstOrCreate(&synonym)
+		if result.Error != nil {
+			return added, result.Error
+		}
+		added += int(result.RowsAffected)
+	}
+	return added, nil
+}
+
+// findSkill looks a skill up by its name or a synonym.
+func findSkill(tx *gorm.DB, name string) (*models.Skill, error) {
+	key := NormalizeSkillName(name)
+	var skill models.Skill
+	err := tx.Where("LOWER(name) = ?", key).
+		Or("id IN (?)", tx.Model(&models.SkillSynonym{}).Select("skill_id").Where("name = ?", key)).
+		First(&skill).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: unknown skill %q", ErrInvalidSkill, name)
+	}
+	return &skill, err
+}
+
+// checkSkillParent makes sure that putting a skill under parentID does not
+// make it its own ancestor.
+func checkSkillParent(tx *gorm.DB, skillID, parentID uint) error {
+	for id, depth := parentID, 0; ; depth++ {
+		if id == skillID || depth > 100 {
+			return fmt.Errorf("%w: a skill cannot be placed under itself or its descendants", ErrInvalidSkill)
+		}
+		var parent models.Skill
+		if err := tx.Select("id", "parent_id").First(&parent, id).Error; err != nil {
+			return err
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		id = *parent.ParentID
+	}
+}
+
+// ParseSkillSeeds reads a skills taxonomy file. JSON files hold an array of
+// skills; CSV files need a header row with a name column and optional
+// category, parent and synonyms columns.
+func ParseSkillSeeds(filename string, content []byte) ([]SkillSeed, error) {
+	var seeds []SkillSeed
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		if err := json.Unmarshal(content, &seeds); err != nil {
+			return nil, fmt.Errorf("invalid skills JSON: %v", err)
+		}
+		return seeds, nil
+	case ".csv":
+	default:
+		return nil, fmt.Errorf("unsupported skills file %s, use .csv or .json", filename)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid skills CSV: %v", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("skills CSV has no name column")
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid skills CSV: %v", err)
+		}
+		seed := SkillSeed{
+			Name:     field(record, "name"),
+			Category: field(record, "category"),
+			Parent:   field(record, "parent"),
+		}
+		for _, synonym := range strings.Split(field(record, "synonyms"), "|") {
+			if synonym = strings.TrimSpace(synonym); synonym != "" {
+				seed.Synonyms = append(seed.Synonyms, synonym)
+			}
+		}
+		seeds = append(seeds, seed)
+	}
+	return seeds, nil
+}
+
+// Categories lists the categories in use, sorted.
+func (t *SkillTaxonomy) Categories() ([]string, error) {
+	var categories []string
+	err := t.DB.Model(&models.Skill{}).Where("category <> ''").Distinct().Pluck("category", &categories).Error
+	sort.Strings(categories)
+	return categories, err
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/GolangAssignment/internal/models"
+	"github.com/GolangAssignment/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// newTaxonomyFixture returns a taxonomy with JavaScript, its descendants
+// TypeScript, React and Next.js, and Go.
+func newTaxonomyFixture(t *testing.T) (*SkillTaxonomy, *gorm.DB) {
+	t.Helper()
+	db := testdb.Open(t, &models.Skill{}, &models.SkillSynonym{})
+	skills := NewSkillTaxonomy(db)
+	_, err := skills.Import([]SkillSeed{
+		{Name: "Next.js", Parent: "React"},
+		{Name: "JavaScript", Category: "Frontend", Synonyms: []string{"JS", "ECMAScript"}},
+		{Name: "TypeScript", Parent: "JavaScript", Synonyms: []string{"TS"}},
+		{Name: "React", Parent: "js", Synonyms: []string{"ReactJS"}},
+		{Name: "Go", Synonyms: []string{"Golang"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return skills, db
+}
+
+func TestSkillTaxonomyVariants(t *testing.T) {
+	skills, _ := newTaxonomyFixture(t)
+	tests := []struct {
+		name        string
+		descendants bool
+		want        []string
+	}{
+		{"JS", false, []string{"ecmascript", "javascript", "js"}},
+		{"JS", true, []string{"ecmascript", "javascript", "js", "next.js", "react", "reactjs", "ts", "typescript"}},
+		{"reactjs", true, []string{"next.js", "react", "reactjs"}},
+		{"Next.js", true, []string{"next.js"}},
+		{"golang", true, []string{"go", "golang"}},
+		{" Rust ", true, []string{"rust"}},
+	}
+	for _, tt := range tests {
+		got := skills.Variants(tt.name, tt.descendants)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Variants(%q, %v) = %v, want %v", tt.name, tt.descendants, got, tt.want)
+		}
+	}
+
+	var none *SkillTaxonomy
+	if got := none.Variants(" Node.JS ", true); !reflect.DeepEqual(got, []string{"node.js"}) {
+		t.Errorf("nil taxonomy Variants = %v, want [node.js]", got)
+	}
+}
+
+func TestSkillTaxonomyNormalizeResume(t *testing.T) {
+	skills, _ := newTaxonomyFixture(t)
+
+	data := &ResumeData{
+		Skills: "js, golang",
+		SkillEntries: []SkillRecord{
+			{Name: "js", Years: 2},
+			{Name: "Golang", Level: "advanced", Years: 3},
+			{Name: "JavaScript", Level: "expert", Years: 5},
+			{Name: "  "},
+			{Name: "go", Level: "beginner", Years: 1},
+			{Name: "Rust"},
+		},
+	}
+	skills.NormalizeResume(data)
+	want := []SkillRecord{
+		{Name: "JavaScript", Level: "expert", Years: 5},
+		{Name: "Go", Level: "advanced", Years: 3},
+		{Name: "Rust"},
+	}
+	if !reflect.DeepEqual(data.SkillEntries, want) || data.Skills != "JavaScript, Go, Rust" {
+		t.Errorf("NormalizeResume = %+v %q, want %+v", data.SkillEntries, data.Skills, want)
+	}
+
+	flat := &ResumeData{Skills: "js, golang, JavaScript,, Rust"}
+	skills.NormalizeResume(flat)
+	if flat.Skills != "JavaScript, Go, Rust" || flat.SkillEntries != nil {
+		t.Errorf("NormalizeResume of flat skills = %q %+v, want \"JavaScript, Go, Rust\"", flat.Skills, flat.SkillEntries)
+	}
+}
+
+func TestParseSkillSeeds(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     []SkillSeed
+		wantErr  bool
+	}{
+		{
+			name:     "csv",
+			filename: "skills.csv",
+			content:  "name,category,parent,synonyms\nJavaScript,Frontend,,JS|ECMAScript\nReact, Frontend ,JavaScript, ReactJS | |\n",
+			want: []SkillSeed{
+				{Name: "JavaScript", Category: "Frontend", Synonyms: []string{"JS", "ECMAScript"}},
+				{Name: "React", Category: "Frontend", Parent: "JavaScript", Synonyms: []string{"ReactJS"}},
+			},
+		},
+		{
+			name:     "csv columns in any order and case, short rows",
+			filename: "SKILLS.CSV",
+			content:  "Synonyms,NAME\ngolang,Go\n,Rust\n\"k8s|kube\"\n",
+			want: []SkillSeed{
+				{Name: "Go", Synonyms: []string{"golang"}},
+				{Name: "Rust"},
+				{Synonyms: []string{"k8s", "kube"}},
+			},
+		},
+		{name: "csv without a name column", filename: "skills.csv", content: "skill,category\nGo,Backend\n", wantErr: true},
+		{name: "empty csv", filename: "skills.csv", content: "", wantErr: true},
+		{name: "csv with a bad quote", filename: "skills.csv", content: "name\n\"Go\n", wantErr: true},
+		{
+			name:     "json",
+			filename: "skills.json",
+			content:  `[{"name": "Go", "category": "Backend", "synonyms": ["golang"]}, {"name": "Gin", "parent": "Go"}]`,
+			want: []SkillSeed{
+				{Name: "Go", Category: "Backend", Synonyms: []string{"golang"}},
+				{Name: "Gin", Parent: "Go"},
+			},
+		},
+		{name: "invalid json", filename: "skills.json", content: `{"name": "Go"}`, wantErr: true},
+		{name: "other extension", filename: "skills.txt", content: "name\nGo\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSkillSeeds(tt.filename, []byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSkillSeeds error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSkillSeeds = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkillTaxonomyRefresh(t *testing.T) {
+	local, db := newTaxonomyFixture(t)
+	// Another instance sharing the database
+	other := NewSkillTaxonomy(db)
+	if err := other.Load(); err != nil {
+		t.Fatal(err)
+	}
+	refresh := func(want bool) {
+		t.Helper()
+		if reloaded, err := other.Refresh(); err != nil || reloaded != want {
+			t.Fatalf("Refresh = %v, %v, want %v", reloaded, err, want)
+		}
+	}
+	refresh(false)
+
+	svelte, err := local.CreateSkill(SkillSeed{Name: "Svelte", Synonyms: []string{"SvelteKit"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh(true)
+	refresh(false)
+	if got := other.Canonical("sveltekit"); got != "Svelte" {
+		t.Errorf("Canonical(sveltekit) = %q after a refresh, want Svelte", got)
+	}
+
+	synonyms := []string{"svelte.js"}
+	if _, err := local.UpdateSkill(svelte.ID, SkillUpdate{Synonyms: &synonyms}); err != nil {
+		t.Fatal(err)
+	}
+	refresh(true)
+	if got := other.Canonical("sveltekit"); got != "sveltekit" {
+		t.Errorf("Canonical(sveltekit) = %q after the synonym was replaced", got)
+	}
+
+	if err := local.DeleteSkill(svelte.ID); err != nil {
+		t.Fatal(err)
+	}
+	refresh(true)
+	if got := other.Canonical("svelte.js"); got != "svelte.js" {
+		t.Errorf("Canonical(svelte.js) = %q after the skill was deleted", got)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/unidoc/unioffice/document"
+)
+
+// ResumeFormat describes a file type accepted as a resume.
+type ResumeFormat struct {
+	MIMEType  string
+	Name      string
+	Extension string
+	// Extract returns the plain text of a file.
+	Extract func(content []byte) (string, error)
+	// ExtractDocument, if set, returns the structured text of a file using
+	// its layout. Other formats are structured from their plain text.
+	ExtractDocument func(content []byte) (*Document, error)
+	// Inspect, if set, checks an upload for content that must be refused
+	// and returns its page count when the format records one.
+	Inspect func(content []byte) (int, error)
+}
+
+// resumeFormats is the extractor registry, keyed by MIME type.
+var resumeFormats = map[string]ResumeFormat{}
+
+// RegisterResumeFormat adds or replaces the extractor for a MIME type.
+func RegisterResumeFormat(format ResumeFormat) {
+	resumeFormats[format.MIMEType] = format
+}
+
+func init() {
+	RegisterResumeFormat(ResumeFormat{MIMEType: "application/pdf", Name: "PDF", Extension: ".pdf", Extract: extractTextFromPDF, ExtractDocument: extractPDFDocument, Inspect: inspectPDF})
+	RegisterResumeFormat(ResumeFormat{MIMEType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", Name: "DOCX", Extension: ".docx", Extract: extractTextFromDOCX, Inspect: inspectDOCX})
+	RegisterResumeFormat(ResumeFormat{MIMEType: "application/msword", Name: "DOC", Extension: ".doc", Extract: extractTextFromDOC, Inspect: inspectDOC})
+	RegisterResumeFormat(ResumeFormat{MIMEType: "application/vnd.oasis.opendocument.text", Name: "ODT", Extension: ".odt", Extract: extractTextFromODT, Inspect: inspectODT})
+	RegisterResumeFormat(ResumeFormat{MIMEType: "text/rtf", Name: "RTF", Extension: ".rtf", Extract: extractTextFromRTF})
+	RegisterResumeFormat(ResumeFormat{MIMEType: "text/html", Name: "HTML", Extension: ".html", Extract: extractTextFromHTML})
+	RegisterResumeFormat(ResumeFormat{MIMEType: "text/markdown", Name: "Markdown", Extension: ".md", Extract: extractTextFromMarkdown})
+	RegisterResumeFormat(ResumeFormat{MIMEType: "text/plain", Name: "TXT", Extension: ".txt", Extract: extractTextFromPlain})
+}
+
+// DetectResumeFormat finds the registered format of a file from its magic
+// bytes, walking up to more generic types (e.g. a CSV is plain text). The
+// file name only distinguishes Markdown from plain text, which have no
+// magic bytes of their own.
+func DetectResumeFormat(content []byte, fileName string) (ResumeFormat, bool) {
+	detected := mimetype.Detect(content)
+	for m := detected; m != nil; m = m.Parent() {
+		mimeType := strings.SplitN(m.String(), ";", 2)[0]
+		if mimeType == "text/plain" {
+			switch strings.ToLower(filepath.Ext(fileName)) {
+			case ".md", ".markdown":
+				mimeType = "text/markdown"
+			}
+		}
+		if format, ok := resumeFormats[mimeType]; ok {
+			return format, true
+		}
+	}
+	return ResumeFormat{}, false
+}
+
+// SupportedResumeFormats lists the names of the registered formats.
+func SupportedResumeFormats() []string {
+	names := make([]string, 0, len(resumeFormats))
+	for _, format := range resumeFormats {
+		names = append(names, format.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExtractText extracts plain text from a resume file based on its detected
+// type.
+func ExtractText(filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return ExtractTextFromBytes(content, filepath.Base(filePath))
+}
+
+// ExtractDocument extracts the structured text of a resume file based on
+// its detected type.
+func ExtractDocument(content []byte, fileName string) (*Document, error) {
+	format, ok := DetectResumeFormat(content, fileName)
+	if !ok {
+		return nil, fmt.Errorf("unsupported file type: %s", mimetype.Detect(content).String())
+	}
+	if format.ExtractDocument != nil {
+		doc, err := format.ExtractDocument(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text from %s: %v", format.Name, err)
+		}
+		return doc, nil
+	}
+	text, err := format.Extract(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text from %s: %v", format.Name, err)
+	}
+	return DocumentFromText(text), nil
+}
+
+// ExtractTextFromBytes extracts plain text from the contents of a resume
+// file.
+func ExtractTextFromBytes(content []byte, fileName string) (string, error) {
+	format, ok := DetectResumeFormat(content, fileName)
+	if !ok {
+		return "", fmt.Errorf("unsupported file type: %s", mimetype.Detect(content).String())
+	}
+	text, err := format.Extract(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract text from %s: %v", format.Name, err)
+	}
+	return text, nil
+}
+
+// extractTextFromDOCX extracts plain text from a DOCX file using the unioffice package.
+func extractTextFromDOCX(content []byte) (string, error) {
+	doc, err := document.Read(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("error opening DOCX file: %v", err)
+	}
+	defer doc.Close()
+
+	var buf bytes.Buffer
+	for _, para := range doc.Paragraphs() {
+		for _, run := range para.Runs() {
+			buf.WriteString(run.Text())
+			buf.WriteString(" ")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String(), nil
+}
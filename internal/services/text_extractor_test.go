@@ -0,0 +1,106 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// extractFixtureTypes is the format each fixture in testdata/extract must be
+// detected as. The Markdown in notes.txt is plain text by its name.
+var extractFixtureTypes = map[string]string{
+	"resume.rtf":       "text/rtf",
+	"resume.odt":       "application/vnd.oasis.opendocument.text",
+	"resume.html":      "text/html",
+	"resume.md":        "text/markdown",
+	"notes.txt":        "text/plain",
+	"resume.txt":       "text/plain",
+	"resume-utf16.txt": "text/plain",
+	"resume-1252.txt":  "text/plain",
+}
+
+// TestExtractFixtures detects and extracts each fixture and compares the text
+// with <fixture>.golden. With -update the golden files are rewritten instead.
+func TestExtractFixtures(t *testing.T) {
+	covered := map[string]bool{}
+	for name, mimeType := range extractFixtureTypes {
+		covered[mimeType] = true
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join("testdata", "extract", name)
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			format, ok := DetectResumeFormat(content, name)
+			if !ok || format.MIMEType != mimeType {
+				t.Fatalf("DetectResumeFormat = %q, %v, want %q", format.MIMEType, ok, mimeType)
+			}
+
+			got, err := ExtractTextFromBytes(content, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := path + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run go test with -update): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("extracted text:\n%s\nwant:\n%s", got, want)
+			}
+			if _, err := ExtractDocument(content, name); err != nil {
+				t.Errorf("ExtractDocument: %v", err)
+			}
+		})
+	}
+
+	// Binary formats are covered by their own tests
+	for mimeType, format := range resumeFormats {
+		if format.Inspect == nil && !covered[mimeType] {
+			t.Errorf("no fixture for %s", mimeType)
+		}
+	}
+}
+
+func TestDetectResumeFormatByName(t *testing.T) {
+	content := []byte("# Jane Doe\n\n* Go\n")
+	tests := []struct {
+		name, want string
+	}{
+		{"resume.md", "text/markdown"},
+		{"RESUME.MARKDOWN", "text/markdown"},
+		{"resume.txt", "text/plain"},
+		{"resume", "text/plain"},
+		// The name never overrides the content
+		{"resume.pdf", "text/plain"},
+	}
+	for _, tt := range tests {
+		if format, ok := DetectResumeFormat(content, tt.name); !ok || format.MIMEType != tt.want {
+			t.Errorf("DetectResumeFormat(%q) = %q, %v, want %q", tt.name, format.MIMEType, ok, tt.want)
+		}
+	}
+
+	if format, ok := DetectResumeFormat([]byte("<html><body><p>Jane</p></body></html>"), "resume.md"); !ok || format.MIMEType != "text/html" {
+		t.Errorf("HTML named .md detected as %q, want text/html", format.MIMEType)
+	}
+	if _, ok := DetectResumeFormat([]byte("\x00\x01\x02\x03binary"), "resume.txt"); ok {
+		t.Error("binary content named .txt was accepted")
+	}
+}
+
+func TestMarkdownStripsSyntax(t *testing.T) {
+	got, _ := extractTextFromMarkdown([]byte("## Skills\n* **Go** and [Docker](https://docker.com)\n"))
+	if want := "Skills\n- Go and Docker (https://docker.com)\n"; got != want {
+		t.Errorf("extractTextFromMarkdown = %q, want %q", got, want)
+	}
+	if strings.Contains(got, "#") {
+		t.Errorf("heading marker left in %q", got)
+	}
+}
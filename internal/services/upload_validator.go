@@ -0,0 +1,248 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/unidoc/unipdf/v3/model"
+)
+
+// UploadError is returned when an uploaded file is rejected. Its message is
+// safe to show to the uploader.
+type UploadError struct {
+	Message string
+}
+
+func (e *UploadError) Error() string { return e.Message }
+
+func rejectUpload(format string, args ...interface{}) error {
+	return &UploadError{Message: fmt.Sprintf(format, args...)}
+}
+
+// maxFileNameLength caps the length of stored file names.
+const maxFileNameLength = 100
+
+// ValidatedUpload is an upload that passed validation.
+type ValidatedUpload struct {
+	FileName    string
+	ContentType string
+	Pages       int
+}
+
+// UploadValidator checks uploaded resumes by content rather than by file
+// name: type is detected from magic bytes against the registered resume
+// formats, size and page count are limited,
+// encrypted and macro-enabled documents are refused and the file is passed
+// to the malware scanner.
+type UploadValidator struct {
+	MaxBytes int64
+	MaxPages int
+	Scanner  MalwareScanner
+}
+
+func NewUploadValidator(maxBytes int64, maxPages int, scanner MalwareScanner) *UploadValidator {
+	if scanner == nil {
+		scanner = NoopScanner{}
+	}
+	return &UploadValidator{MaxBytes: maxBytes, MaxPages: maxPages, Scanner: scanner}
+}
+
+// Validate checks an uploaded file and returns its sanitized name and
+// detected type. Rejections are reported as *UploadError.
+func (v *UploadValidator) Validate(ctx context.Context, fileName string, content []byte) (*ValidatedUpload, error) {
+	if len(content) == 0 {
+		return nil, rejectUpload("Uploaded file is empty")
+	}
+	if v.MaxBytes > 0 && int64(len(content)) > v.MaxBytes {
+		return nil, rejectUpload("File exceeds the maximum size of %d MB", v.MaxBytes/(1<<20))
+	}
+
+	if isEncryptedOffice(content) {
+		return nil, rejectUpload("Password-protected documents are not accepted")
+	}
+
+	format, ok := DetectResumeFormat(content, fileName)
+	if !ok {
+		return nil, rejectUpload("Unsupported file type %s; accepted formats are %s",
+			strings.SplitN(mimetype.Detect(content).String(), ";", 2)[0], strings.Join(SupportedResumeFormats(), ", "))
+	}
+
+	pages := 0
+	if format.Inspect != nil {
+		var err error
+		if pages, err = format.Inspect(content); err != nil {
+			return nil, err
+		}
+	}
+	if v.MaxPages > 0 && pages > v.MaxPages {
+		return nil, rejectUpload("Resume has %d pages; the maximum is %d", pages, v.MaxPages)
+	}
+
+	result, err := v.Scanner.Scan(ctx, bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("malware scan failed: %v", err)
+	}
+	if !result.Clean {
+		log.Printf("Upload %q rejected by malware scanner: %s", fileName, result.Signature)
+		return nil, rejectUpload("File was rejected by the malware scanner")
+	}
+
+	return &ValidatedUpload{
+		FileName:    SanitizeFileName(fileName, format.Extension),
+		ContentType: format.MIMEType,
+		Pages:       pages,
+	}, nil
+}
+
+// inspectPDF returns the page count of a PDF, rejecting encrypted files and
+// files with embedded JavaScript or launch actions.
+func inspectPDF(content []byte) (int, error) {
+	for _, marker := range [][]byte{[]byte("/JavaScript"), []byte("/JS "), []byte("/JS("), []byte("/Launch")} {
+		if bytes.Contains(content, marker) {
+			return 0, rejectUpload("PDF files with embedded scripts or actions are not accepted")
+		}
+	}
+
+	reader, err := model.NewPdfReader(bytes.NewReader(content))
+	if err != nil {
+		return 0, rejectUpload("File is not a readable PDF")
+	}
+	encrypted, err := reader.IsEncrypted()
+	if err != nil || encrypted {
+		return 0, rejectUpload("Password-protected documents are not accepted")
+	}
+	pages, err := reader.GetNumPages()
+	if err != nil {
+		return 0, rejectUpload("File is not a readable PDF")
+	}
+	return pages, nil
+}
+
+var (
+	docxPagesPattern = regexp.MustCompile(`<Pages>(\d+)</Pages>`)
+	// Explicit page breaks, and the breaks Word recorded when it last laid
+	// the document out
+	docxPageBreak     = []byte(`w:type="page"`)
+	docxRenderedBreak = []byte(`<w:lastRenderedPageBreak/>`)
+)
+
+// maxDOCXDocumentSize caps how much of a DOCX body is read to count pages.
+const maxDOCXDocumentSize = 64 << 20
+
+// inspectDOCX returns the page count recorded in a DOCX file's properties,
+// rejecting documents that carry VBA macros. Without recorded properties
+// the pages are counted from the page breaks in the body.
+func inspectDOCX(content []byte) (int, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return 0, rejectUpload("File is not a readable DOCX document")
+	}
+
+	pages := 0
+	var document *zip.File
+	for _, file := range archive.File {
+		name := strings.ToLower(file.Name)
+		if strings.HasSuffix(name, "vbaproject.bin") || strings.HasSuffix(name, "vbadata.xml") {
+			return 0, rejectUpload("Documents containing macros are not accepted")
+		}
+		switch name {
+		case "[content_types].xml":
+			data, err := readZipFile(file, 1<<20)
+			if err == nil && bytes.Contains(bytes.ToLower(data), []byte("macroenabled")) {
+				return 0, rejectUpload("Documents containing macros are not accepted")
+			}
+		case "docprops/app.xml":
+			data, err := readZipFile(file, 1<<20)
+			if err == nil {
+				if m := docxPagesPattern.FindSubmatch(data); m != nil {
+					pages, _ = strconv.Atoi(string(m[1]))
+				}
+			}
+		case "word/document.xml":
+			document = file
+		}
+	}
+	if pages > 0 {
+		return pages, nil
+	}
+
+	if document == nil {
+		return 0, rejectUpload("File is not a readable DOCX document")
+	}
+	data, err := readZipFile(document, maxDOCXDocumentSize)
+	if err != nil {
+		return 0, rejectUpload("File is not a readable DOCX document")
+	}
+	breaks := bytes.Count(data, docxPageBreak)
+	if rendered := bytes.Count(data, docxRenderedBreak); rendered > breaks {
+		breaks = rendered
+	}
+	return breaks + 1, nil
+}
+
+func readZipFile(file *zip.File, limit int64) ([]byte, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, limit))
+}
+
+// oleMagic starts every OLE compound file. Password-protected Office
+// documents are stored as compound files with an EncryptedPackage stream.
+var (
+	oleMagic         = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	encryptedPackage = utf16LE("EncryptedPackage")
+)
+
+func isEncryptedOffice(content []byte) bool {
+	return bytes.HasPrefix(content, oleMagic) && bytes.Contains(content, encryptedPackage)
+}
+
+func utf16LE(s string) []byte {
+	b := make([]byte, 0, len(s)*2)
+	for _, r := range s {
+		b = append(b, byte(r), 0)
+	}
+	return b
+}
+
+// SanitizeFileName strips directories, control and shell-sensitive
+// characters from an uploaded file name, caps its length and forces the
+// extension to match the detected type.
+func SanitizeFileName(name, ext string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+
+	var b strings.Builder
+	for _, r := range base {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	base = strings.Trim(b.String(), " ._")
+	if runes := []rune(base); len(runes) > maxFileNameLength {
+		base = strings.TrimSpace(string(runes[:maxFileNameLength]))
+	}
+	if base == "" {
+		base = "resume"
+	}
+	return base + ext
+}
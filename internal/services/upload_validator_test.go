@@ -0,0 +1,104 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// buildDOCX zips the given parts into a minimal DOCX file.
+func buildDOCX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	names := []string{"[Content_Types].xml", "word/document.xml", "docProps/app.xml"}
+	for _, name := range names {
+		content, ok := parts[name]
+		if !ok {
+			continue
+		}
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const docxContentTypes = `<?xml version="1.0"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`
+
+func docxBody(pageBreaks int) string {
+	body := `<w:document><w:body><w:p><w:r><w:t>Jane Doe</w:t></w:r></w:p>`
+	body += strings.Repeat(`<w:p><w:r><w:br w:type="page"/><w:t>More</w:t></w:r></w:p>`, pageBreaks)
+	return body + `</w:body></w:document>`
+}
+
+func TestInspectDOCXPages(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts map[string]string
+		pages int
+	}{
+		{"recorded in properties", map[string]string{
+			"word/document.xml": docxBody(0),
+			"docProps/app.xml":  `<Properties><Pages>7</Pages></Properties>`,
+		}, 7},
+		{"counted from page breaks", map[string]string{
+			"word/document.xml": docxBody(4),
+		}, 5},
+		{"rendered breaks", map[string]string{
+			"word/document.xml": `<w:body><w:p><w:r><w:lastRenderedPageBreak/><w:t>a</w:t></w:r></w:p>` +
+				`<w:p><w:r><w:lastRenderedPageBreak/><w:t>b</w:t></w:r></w:p></w:body>`,
+		}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := inspectDOCX(buildDOCX(t, tt.parts))
+			if err != nil {
+				t.Fatalf("inspectDOCX: %v", err)
+			}
+			if pages != tt.pages {
+				t.Errorf("pages = %d, want %d", pages, tt.pages)
+			}
+		})
+	}
+
+	if _, err := inspectDOCX(buildDOCX(t, map[string]string{"docProps/app.xml": "<Properties/>"})); err == nil {
+		t.Error("DOCX without a document body was accepted")
+	}
+}
+
+func TestValidateDOCXPageLimit(t *testing.T) {
+	validator := NewUploadValidator(0, 3, nil)
+	content := buildDOCX(t, map[string]string{
+		"[Content_Types].xml": docxContentTypes,
+		"word/document.xml":   docxBody(5),
+	})
+
+	_, err := validator.Validate(context.Background(), "resume.docx", content)
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) || !strings.Contains(uploadErr.Message, "6 pages") {
+		t.Errorf("Validate = %v, want the page limit to reject 6 pages", err)
+	}
+}
+
+func TestValidateMalware(t *testing.T) {
+	validator := NewUploadValidator(0, 0, &ClamAVScanner{Address: startClamdStub(t, false), ChunkSize: 1024})
+
+	if _, err := validator.Validate(context.Background(), "resume.txt", []byte("Jane Doe\nEngineer\n")); err != nil {
+		t.Errorf("clean upload rejected: %v", err)
+	}
+	_, err := validator.Validate(context.Background(), "resume.txt", []byte(eicar))
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) {
+		t.Errorf("Validate = %v, want infected upload rejected", err)
+	}
+}